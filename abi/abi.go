@@ -0,0 +1,332 @@
+// Package abi encodes calls and decodes results with the Solidity contract ABI, on top of go-ethereum's abi package.
+//
+// Arguments are given as text, the way they are typed on a command line: numbers in decimal or 0x prefixed hex,
+// addresses, bytes and bytesN in hex, booleans as true or false, strings optionally quoted, arrays as [a,b] and
+// tuples as (a,b). Results are formatted the same way.
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Load reads an ABI from a file holding either the ABI itself or an artifact with an "abi" field,
+// such as those of Hardhat and Foundry.
+func Load(path string) (*gethabi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return a, nil
+}
+
+// Parse decodes an ABI, or an artifact with an "abi" field.
+func Parse(data []byte) (*gethabi.ABI, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, err
+		}
+		if artifact.ABI == nil {
+			return nil, errors.New("no abi field")
+		}
+		data = artifact.ABI
+	}
+	a, err := gethabi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FindMethod returns the method of a with a name, or a signature such as "transfer(address,uint256)".
+// A name matching several overloaded methods is an error.
+func FindMethod(a *gethabi.ABI, name string) (*gethabi.Method, error) {
+	var found []gethabi.Method
+	for _, m := range a.Methods {
+		if m.Sig == name || m.RawName == name {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no method %s in the ABI", name)
+	case 1:
+		return &found[0], nil
+	}
+	sigs := make([]string, len(found))
+	for i, m := range found {
+		sigs[i] = m.Sig
+	}
+	return nil, fmt.Errorf("method %s is overloaded, use one of the signatures %s", name, strings.Join(sigs, ", "))
+}
+
+// MethodFromSignature creates a method from its signature, e.g. "transfer(address,uint256)". It has no outputs.
+func MethodFromSignature(sig string) (*gethabi.Method, error) {
+	selector, err := gethabi.ParseSelector(sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+	}
+	data, err := json.Marshal([]gethabi.SelectorMarshaling{selector})
+	if err != nil {
+		return nil, err
+	}
+	a, err := gethabi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	m := a.Methods[selector.Name]
+	return &m, nil
+}
+
+// EncodeCall returns the calldata calling m with comma separated arguments.
+func EncodeCall(m *gethabi.Method, args string) ([]byte, error) {
+	values, err := ParseArgs(m.Inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Sig, err)
+	}
+	data, err := m.Inputs.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Sig, err)
+	}
+	return append(m.ID, data...), nil
+}
+
+// PackCall returns the calldata calling the method with a signature, e.g. "transfer(address,uint256)", with the Go
+// values go-ethereum packs.
+func PackCall(sig string, args ...any) ([]byte, error) {
+	m, err := MethodFromSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Sig, err)
+	}
+	return append(m.ID, data...), nil
+}
+
+// ParseArgs parses comma separated arguments into the Go values go-ethereum packs.
+func ParseArgs(args gethabi.Arguments, s string) ([]any, error) {
+	items, err := split(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != len(args) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(args), len(items))
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		v, err := ParseValue(arg.Type, items[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// ParseValue parses a value of type t into the Go value go-ethereum packs.
+func ParseValue(t gethabi.Type, s string) (any, error) {
+	v, err := parseValue(t, strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func parseValue(t gethabi.Type, s string) (reflect.Value, error) {
+	typ := t.GetType()
+	switch t.T {
+	case gethabi.IntTy, gethabi.UintTy:
+		n, ok := parseInt(s)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid %v %q", t, s)
+		}
+		if !inRange(t, n) {
+			return reflect.Value{}, fmt.Errorf("%s out of range for %v", s, t)
+		}
+		v := reflect.New(typ).Elem()
+		switch typ.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(n.Int64())
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(n.Uint64())
+		default:
+			v.Set(reflect.ValueOf(n))
+		}
+		return v, nil
+	case gethabi.BoolTy:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bool %q", s)
+		}
+		return reflect.ValueOf(b), nil
+	case gethabi.StringTy:
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			s = unquoted
+		}
+		return reflect.ValueOf(s), nil
+	case gethabi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address %q", s)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+	case gethabi.BytesTy:
+		b, err := decodeHex(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes %q", s)
+		}
+		return reflect.ValueOf(b), nil
+	case gethabi.FixedBytesTy:
+		b, err := decodeHex(s)
+		if err != nil || len(b) > t.Size {
+			return reflect.Value{}, fmt.Errorf("invalid %v %q", t, s)
+		}
+		v := reflect.New(typ).Elem()
+		reflect.Copy(v, reflect.ValueOf(b)) // right padded with zeros
+		return v, nil
+	case gethabi.SliceTy, gethabi.ArrayTy:
+		inner, ok := unwrap(s, "[", "]")
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid %v %q, expected [a,b,...]", t, s)
+		}
+		items, err := split(inner)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.T == gethabi.ArrayTy && len(items) != t.Size {
+			return reflect.Value{}, fmt.Errorf("%v needs %d items, got %d", t, t.Size, len(items))
+		}
+		v := reflect.New(typ).Elem()
+		if t.T == gethabi.SliceTy {
+			v = reflect.MakeSlice(typ, len(items), len(items))
+		}
+		for i, item := range items {
+			elem, err := parseValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+	case gethabi.TupleTy:
+		inner, ok := unwrap(s, "(", ")")
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid %v %q, expected (a,b,...)", t, s)
+		}
+		items, err := split(inner)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(items) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("%v needs %d items, got %d", t, len(t.TupleElems), len(items))
+		}
+		v := reflect.New(typ).Elem()
+		for i, item := range items {
+			elem, err := parseValue(*t.TupleElems[i], item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(i).Set(elem)
+		}
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported type %v", t)
+}
+
+// unwrap removes the brackets around s, reporting whether it had them.
+func unwrap(s, open, close string) (string, bool) {
+	if !strings.HasPrefix(s, open) || !strings.HasSuffix(s, close) || len(s) < 2 {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// parseInt parses a decimal or 0x prefixed hex integer, optionally negative.
+func parseInt(s string) (*big.Int, bool) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	base := 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s, base = s[2:], 16
+	}
+	n, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// inRange reports whether n fits in the integer type t.
+func inRange(t gethabi.Type, n *big.Int) bool {
+	if t.T == gethabi.UintTy {
+		return n.Sign() >= 0 && n.BitLen() <= t.Size
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// split splits a list on the commas that aren't nested in brackets, parentheses or quotes.
+// An empty or blank list has no items.
+func split(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var (
+		items  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced %q in %q", c, s)
+			}
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("unterminated list or string in %q", s)
+	}
+	return append(items, strings.TrimSpace(s[start:])), nil
+}
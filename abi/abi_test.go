@@ -0,0 +1,147 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustLoad(t *testing.T) *gethabi.ABI {
+	a, err := Load("testdata/Token.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestParse(t *testing.T) {
+	a, err := Parse([]byte(`[{"type": "function", "name": "f", "inputs": [], "outputs": []}]`))
+	assert.NoError(t, err)
+	assert.Contains(t, a.Methods, "f")
+
+	_, err = Parse([]byte(`{"bytecode": "0x"}`))
+	assert.EqualError(t, err, "no abi field")
+}
+
+func TestFindMethod(t *testing.T) {
+	a := mustLoad(t)
+
+	m, err := FindMethod(a, "transfer")
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer(address,uint256)", m.Sig)
+	m, err = FindMethod(a, "mint(address)")
+	assert.NoError(t, err)
+	assert.Len(t, m.Inputs, 1)
+
+	_, err = FindMethod(a, "mint")
+	assert.ErrorContains(t, err, "method mint is overloaded")
+	_, err = FindMethod(a, "burn")
+	assert.EqualError(t, err, "no method burn in the ABI")
+}
+
+func TestEncodeCall(t *testing.T) {
+	a := mustLoad(t)
+	m, _ := FindMethod(a, "transfer")
+	data, err := EncodeCall(m, "0x000000000000000000000000000000000000abcd, 100")
+	assert.NoError(t, err)
+	assert.Equal(t, "a9059cbb"+
+		"000000000000000000000000000000000000000000000000000000000000abcd"+
+		"0000000000000000000000000000000000000000000000000000000000000064", common.Bytes2Hex(data))
+
+	_, err = EncodeCall(m, "0xabcd")
+	assert.EqualError(t, err, "transfer(address,uint256): expected 2 arguments, got 1")
+	_, err = EncodeCall(m, "0xabcd,1")
+	assert.EqualError(t, err, `transfer(address,uint256): argument 0: invalid address "0xabcd"`)
+
+	// Without an ABI
+	m, err = MethodFromSignature("transfer(address,uint256)")
+	assert.NoError(t, err)
+	sigData, err := EncodeCall(m, "0x000000000000000000000000000000000000abcd,0x64")
+	assert.NoError(t, err)
+	assert.Equal(t, data, sigData)
+	_, err = MethodFromSignature("transfer(address")
+	assert.Error(t, err)
+}
+
+func TestPackCall(t *testing.T) {
+	data, err := PackCall("transfer(address,uint256)", common.HexToAddress("0xabcd"), big.NewInt(100))
+	assert.NoError(t, err)
+	assert.Equal(t, "a9059cbb"+
+		"000000000000000000000000000000000000000000000000000000000000abcd"+
+		"0000000000000000000000000000000000000000000000000000000000000064", common.Bytes2Hex(data))
+
+	_, err = PackCall("transfer(address,uint256)", common.HexToAddress("0xabcd"))
+	assert.EqualError(t, err, "transfer(address,uint256): argument count mismatch: got 1 for 2")
+	_, err = PackCall("transfer(address")
+	assert.Error(t, err)
+}
+
+func TestParseValue(t *testing.T) {
+	parse := func(typ, s string) (any, error) {
+		abiType, err := gethabi.NewType(typ, "", []gethabi.ArgumentMarshaling{{Name: "a", Type: "uint8"}, {Name: "b", Type: "string"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ParseValue(abiType, s)
+	}
+	tests := []struct {
+		typ, input string
+		want       any
+		wantErr    string
+	}{
+		{typ: "uint256", input: "1000", want: big.NewInt(1000)},
+		{typ: "uint256", input: "0xff", want: big.NewInt(255)},
+		{typ: "uint8", input: "255", want: uint8(255)},
+		{typ: "uint8", input: "256", wantErr: "256 out of range for uint8"},
+		{typ: "uint8", input: "-1", wantErr: "-1 out of range for uint8"},
+		{typ: "int8", input: "-128", want: int8(-128)},
+		{typ: "int8", input: "128", wantErr: "128 out of range for int8"},
+		{typ: "int256", input: "-5", want: big.NewInt(-5)},
+		{typ: "uint64", input: "x", wantErr: `invalid uint64 "x"`},
+		{typ: "bool", input: "true", want: true},
+		{typ: "string", input: `"a, b"`, want: "a, b"},
+		{typ: "string", input: "plain", want: "plain"},
+		{typ: "bytes", input: "0x0102", want: []byte{1, 2}},
+		{typ: "bytes2", input: "0x01", want: [2]byte{1, 0}},
+		{typ: "bytes2", input: "0x010203", wantErr: `invalid bytes2 "0x010203"`},
+		{typ: "uint16[]", input: "[1, 2,3]", want: []uint16{1, 2, 3}},
+		{typ: "uint16[]", input: "[]", want: []uint16{}},
+		{typ: "bool[2]", input: "[true,false]", want: [2]bool{true, false}},
+		{typ: "bool[2]", input: "[true]", wantErr: "bool[2] needs 2 items, got 1"},
+		{typ: "uint16[]", input: "1,2", wantErr: `invalid uint16[] "1,2", expected [a,b,...]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.input, func(t *testing.T) {
+			got, err := parse(tt.typ, tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Tuples are structs
+	got, err := parse("tuple", `(7, "x")`)
+	assert.NoError(t, err)
+	assert.Equal(t, `(7,"x")`, Format(got))
+}
+
+func TestSplit(t *testing.T) {
+	items, err := split(`1, [2, (3, 4)], "a,\"b", ()`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "[2, (3, 4)]", `"a,\"b"`, "()"}, items)
+
+	items, err = split("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, items)
+
+	_, err = split("[1")
+	assert.Error(t, err)
+	_, err = split("1]")
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Value is a decoded value.
+type Value struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (v Value) String() string {
+	if v.Name == "" {
+		return fmt.Sprintf("%s %s", v.Type, v.Value)
+	}
+	return fmt.Sprintf("%s %s = %s", v.Type, v.Name, v.Value)
+}
+
+// Decode decodes ABI encoded values, such as the return data of a method with its outputs as args.
+func Decode(args gethabi.Arguments, data []byte) ([]Value, error) {
+	unpacked, err := args.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		values[i] = Value{Name: arg.Name, Type: arg.Type.String(), Value: Format(unpacked[i])}
+	}
+	return values, nil
+}
+
+// Format formats a value decoded by go-ethereum the way arguments are parsed.
+func Format(v any) string {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 { // bytesN
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		return formatList(rv, "[", "]")
+	case reflect.Slice:
+		return formatList(rv, "[", "]")
+	case reflect.Struct: // tuple
+		items := make([]string, rv.NumField())
+		for i := range items {
+			items[i] = Format(rv.Field(i).Interface())
+		}
+		return "(" + strings.Join(items, ",") + ")"
+	}
+	return fmt.Sprint(v)
+}
+
+func formatList(rv reflect.Value, open, close string) string {
+	items := make([]string, rv.Len())
+	for i := range items {
+		items[i] = Format(rv.Index(i).Interface())
+	}
+	return open + strings.Join(items, ",") + close
+}
@@ -0,0 +1,44 @@
+package abi
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	a := mustLoad(t)
+	m, _ := FindMethod(a, "balanceOf")
+	values, err := Decode(m.Outputs, common.LeftPadBytes([]byte{0x01, 0x00}, 32))
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{{Name: "balance", Type: "uint256", Value: "256"}}, values)
+	assert.Equal(t, "uint256 balance = 256", values[0].String())
+
+	m, _ = FindMethod(a, "transfer")
+	values, err = Decode(m.Outputs, common.LeftPadBytes([]byte{1}, 32))
+	assert.NoError(t, err)
+	assert.Equal(t, "bool true", values[0].String())
+
+	_, err = Decode(m.Outputs, []byte{1})
+	assert.Error(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{uint8(7), "7"},
+		{int16(-7), "-7"},
+		{[]byte{0xab}, "0xab"},
+		{[4]byte{0xde, 0xad, 0xbe, 0xef}, "0xdeadbeef"},
+		{"hi", `"hi"`},
+		{[]bool{true, false}, "[true,false]"},
+		{[2][]uint8{{1}, {}}, "[0x01,0x]"},
+		{common.HexToAddress("0xabcd"), "0x000000000000000000000000000000000000ABcD"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Format(tt.value))
+	}
+}
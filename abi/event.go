@@ -0,0 +1,114 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Event is a decoded event log.
+type Event struct {
+	Name      string  `json:"name"`
+	Signature string  `json:"signature"`
+	Args      []Value `json:"args"`
+}
+
+func (e *Event) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+}
+
+// Events are the events of one or more ABIs, by the topic identifying them.
+type Events map[common.Hash]gethabi.Event
+
+// NewEvents collects the events of abis. Anonymous events are left out, as logs don't identify them.
+func NewEvents(abis ...*gethabi.ABI) Events {
+	events := make(Events)
+	for _, a := range abis {
+		for _, ev := range a.Events {
+			if !ev.Anonymous {
+				events[ev.ID] = ev
+			}
+		}
+	}
+	return events
+}
+
+// Decode decodes a log with the event its first topic identifies.
+func (e Events) Decode(topics []common.Hash, data []byte) (*Event, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("log without topics")
+	}
+	ev, ok := e[topics[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %s", topics[0].Hex())
+	}
+	args, err := DecodeEvent(&ev, topics, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Name: ev.Name, Signature: ev.Sig, Args: args}, nil
+}
+
+// DecodeEvent decodes the arguments of a log of ev, in the order of the event's inputs.
+// The topic of an indexed argument of a dynamic type (string, bytes, arrays and tuples) is the hash of its value,
+// which is all that can be decoded.
+func DecodeEvent(ev *gethabi.Event, topics []common.Hash, data []byte) ([]Value, error) {
+	if !ev.Anonymous {
+		if len(topics) == 0 || topics[0] != ev.ID {
+			return nil, fmt.Errorf("log isn't a %s event", ev.Sig)
+		}
+		topics = topics[1:]
+	}
+
+	var indexed int
+	for _, arg := range ev.Inputs {
+		if arg.Indexed {
+			indexed++
+		}
+	}
+	if len(topics) != indexed {
+		return nil, fmt.Errorf("%s: expected %d indexed arguments, got %d", ev.Sig, indexed, len(topics))
+	}
+	unpacked, err := ev.Inputs.NonIndexed().UnpackValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ev.Sig, err)
+	}
+
+	values := make([]Value, len(ev.Inputs))
+	for i, arg := range ev.Inputs {
+		values[i] = Value{Name: arg.Name, Type: arg.Type.String()}
+		if !arg.Indexed {
+			values[i].Value = Format(unpacked[0])
+			unpacked = unpacked[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if isHashed(arg.Type) {
+			values[i].Value = topic.Hex()
+			continue
+		}
+		v, err := gethabi.Arguments{{Type: arg.Type}}.UnpackValues(topic[:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %v", ev.Sig, i, err)
+		}
+		values[i].Value = Format(v[0])
+	}
+	return values, nil
+}
+
+// isHashed reports whether indexed arguments of type t are stored as their hash in the topics.
+func isHashed(t gethabi.Type) bool {
+	switch t.T {
+	case gethabi.StringTy, gethabi.BytesTy, gethabi.SliceTy, gethabi.ArrayTy, gethabi.TupleTy:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package abi
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsDecode(t *testing.T) {
+	events := NewEvents(mustLoad(t))
+	from, to := common.HexToAddress("0xabcd"), common.HexToAddress("0x1234")
+	transfer := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	ev, err := events.Decode(
+		[]common.Hash{transfer, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		common.LeftPadBytes([]byte{100}, 32),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "Transfer", ev.Name)
+	assert.Equal(t, "Transfer(address,address,uint256)", ev.Signature)
+	assert.Equal(t, []Value{
+		{Name: "from", Type: "address", Value: from.Hex()},
+		{Name: "to", Type: "address", Value: to.Hex()},
+		{Name: "value", Type: "uint256", Value: "100"},
+	}, ev.Args)
+	assert.Equal(t, "Transfer(address from = "+from.Hex()+", address to = "+to.Hex()+", uint256 value = 100)", ev.String())
+
+	// The indexed string is only its hash
+	memo := crypto.Keccak256Hash([]byte("Memo(string,string)"))
+	tag := crypto.Keccak256Hash([]byte("greeting"))
+	data := append(common.LeftPadBytes([]byte{0x20}, 32), common.LeftPadBytes([]byte{2}, 32)...)
+	data = append(data, common.RightPadBytes([]byte("hi"), 32)...)
+	ev, err = events.Decode([]common.Hash{memo, tag}, data)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{
+		{Name: "tag", Type: "string", Value: tag.Hex()},
+		{Name: "text", Type: "string", Value: `"hi"`},
+	}, ev.Args)
+
+	_, err = events.Decode([]common.Hash{memo}, data)
+	assert.ErrorContains(t, err, "expected 1 indexed arguments, got 0")
+	_, err = events.Decode([]common.Hash{common.HexToHash("0x1")}, nil)
+	assert.ErrorContains(t, err, "unknown event")
+	_, err = events.Decode(nil, nil)
+	assert.Error(t, err)
+	_, err = events.Decode([]common.Hash{transfer, {}, {}}, []byte{1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicReasons are the meanings of the Panic(uint256) codes of the Solidity compiler.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion to an invalid enum value",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "too much memory allocated",
+	0x51: "call to an uninitialized function",
+}
+
+// PanicReason returns the meaning of a Solidity panic code, "" if it's unknown.
+func PanicReason(code *big.Int) string {
+	if !code.IsUint64() {
+		return ""
+	}
+	return panicReasons[code.Uint64()]
+}
+
+// DecodeRevert describes revert data: an Error(string), a Panic(uint256) with the meaning of its code, or a custom
+// error of a, which may be nil. It reports false if the data is none of these.
+func DecodeRevert(data []byte, a *gethabi.ABI) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	selector, args := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		values, err := gethabi.Arguments{{Type: mustType("string")}}.UnpackValues(args)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("Error(%s)", Format(values[0])), true
+	case bytes.Equal(selector, panicSelector):
+		values, err := gethabi.Arguments{{Type: mustType("uint256")}}.UnpackValues(args)
+		if err != nil {
+			return "", false
+		}
+		code := values[0].(*big.Int)
+		s := fmt.Sprintf("Panic(0x%02x)", code)
+		if reason := PanicReason(code); reason != "" {
+			s += ": " + reason
+		}
+		return s, true
+	case a != nil:
+		e, err := a.ErrorByID([4]byte(selector))
+		if err != nil {
+			return "", false
+		}
+		values, err := Decode(e.Inputs, args)
+		if err != nil {
+			return "", false
+		}
+		items := make([]string, len(values))
+		for i, v := range values {
+			items[i] = v.Value
+			if v.Name != "" {
+				items[i] = v.Name + ": " + v.Value
+			}
+		}
+		return fmt.Sprintf("%s(%s)", e.Name, strings.Join(items, ", ")), true
+	}
+	return "", false
+}
+
+// EncodeError returns the revert data of require and revert with a reason, an Error(string).
+func EncodeError(reason string) []byte {
+	data, err := gethabi.Arguments{{Type: mustType("string")}}.Pack(reason)
+	if err != nil {
+		panic(err)
+	}
+	return append(bytes.Clone(errorSelector), data...)
+}
+
+// DecodeError returns the reason of revert data that is an Error(string), reporting false if it's something else.
+func DecodeError(data []byte) (string, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], errorSelector) {
+		return "", false
+	}
+	values, err := gethabi.Arguments{{Type: mustType("string")}}.UnpackValues(data[4:])
+	if err != nil {
+		return "", false
+	}
+	return values[0].(string), true
+}
+
+func mustType(t string) gethabi.Type {
+	typ, err := gethabi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
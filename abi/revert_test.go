@@ -0,0 +1,65 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	a := mustLoad(t)
+	word := func(n int64) []byte { return common.LeftPadBytes(big.NewInt(n).Bytes(), 32) }
+	concat := func(parts ...[]byte) []byte {
+		var b []byte
+		for _, p := range parts {
+			b = append(b, p...)
+		}
+		return b
+	}
+	insufficientBalance := a.Errors["InsufficientBalance"].ID.Bytes()[:4]
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"error", concat(errorSelector, word(0x20), word(4), common.RightPadBytes([]byte("oops"), 32)), `Error("oops")`},
+		{"panic", concat(panicSelector, word(0x11)), "Panic(0x11): arithmetic underflow or overflow"},
+		{"unknown panic", concat(panicSelector, word(0x99)), "Panic(0x99)"},
+		{"custom error", concat(insufficientBalance, word(1), word(2)), "InsufficientBalance(available: 1, required: 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeRevert(tt.data, a)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, data := range [][]byte{nil, {1, 2, 3}, concat(errorSelector, word(1)), concat(insufficientBalance, word(1), word(2))[:40]} {
+		_, ok := DecodeRevert(data, a)
+		assert.False(t, ok)
+	}
+	// Custom errors need the ABI
+	_, ok := DecodeRevert(concat(insufficientBalance, word(1), word(2)), nil)
+	assert.False(t, ok)
+}
+
+func TestEncodeError(t *testing.T) {
+	data := EncodeError("oops")
+	assert.Equal(t, errorSelector, data[:4])
+	got, ok := DecodeRevert(data, nil)
+	assert.True(t, ok)
+	assert.Equal(t, `Error("oops")`, got)
+
+	reason, ok := DecodeError(data)
+	assert.True(t, ok)
+	assert.Equal(t, "oops", reason)
+	for _, data := range [][]byte{nil, append(bytes.Clone(panicSelector), make([]byte, 32)...), data[:40]} {
+		_, ok := DecodeError(data)
+		assert.False(t, ok)
+	}
+}
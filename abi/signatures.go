@@ -0,0 +1,161 @@
+package abi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signatures is a database of function and event signatures, resolving the 4-byte selectors of calldata and the
+// topics identifying logs. When signatures collide, the first one added is kept.
+// A nil *Signatures knows no signature.
+type Signatures struct {
+	selectors map[[4]byte]string
+	topics    map[common.Hash]string
+}
+
+// NewSignatures creates an empty signature database.
+func NewSignatures() *Signatures {
+	return &Signatures{selectors: make(map[[4]byte]string), topics: make(map[common.Hash]string)}
+}
+
+// Add adds a signature such as "transfer(address,uint256)", both as a function and as an event.
+func (s *Signatures) Add(sig string) error {
+	sig = strings.Join(strings.Fields(sig), "")
+	if open := strings.IndexByte(sig, '('); open <= 0 || !strings.HasSuffix(sig, ")") {
+		return fmt.Errorf("invalid signature %q", sig)
+	}
+	hash := crypto.Keccak256Hash([]byte(sig))
+	s.addSelector([4]byte(hash[:4]), sig)
+	s.addTopic(hash, sig)
+	return nil
+}
+
+// AddABI adds the signatures of the methods and events of an ABI.
+func (s *Signatures) AddABI(a *gethabi.ABI) {
+	for _, m := range a.Methods {
+		s.addSelector([4]byte(m.ID), m.Sig)
+	}
+	for _, ev := range a.Events {
+		s.addTopic(ev.ID, ev.Sig)
+	}
+}
+
+func (s *Signatures) addSelector(selector [4]byte, sig string) {
+	if _, ok := s.selectors[selector]; !ok {
+		s.selectors[selector] = sig
+	}
+}
+
+func (s *Signatures) addTopic(topic common.Hash, sig string) {
+	if _, ok := s.topics[topic]; !ok {
+		s.topics[topic] = sig
+	}
+}
+
+// Load adds the signatures of a file, see Parse.
+func (s *Signatures) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Parse(data); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Parse adds signatures in one of these formats:
+//
+//   - a JSON object from selectors or topics to signatures, like geth's 4byte.json: {"a9059cbb": "transfer(address,uint256)"}
+//   - a JSON array of signatures: ["transfer(address,uint256)"]
+//   - text with a signature per line, optionally after its selector or topic, blank lines and lines starting with #
+//     being ignored
+//
+// A signature given with its selector or topic is trusted to match it, those of 4byte.directory may not hash to it.
+func (s *Signatures) Parse(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		var sigs map[string]string
+		if err := json.Unmarshal(data, &sigs); err != nil {
+			return err
+		}
+		for id, sig := range sigs {
+			if err := s.addWithID(id, sig); err != nil {
+				return err
+			}
+		}
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var sigs []string
+		if err := json.Unmarshal(data, &sigs); err != nil {
+			return err
+		}
+		for _, sig := range sigs {
+			if err := s.Add(sig); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var err error
+		if id, sig, ok := strings.Cut(text, " "); ok && !strings.Contains(id, "(") {
+			err = s.addWithID(id, sig)
+		} else {
+			err = s.Add(text)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// addWithID adds a signature under a hex selector or topic.
+func (s *Signatures) addWithID(id, sig string) error {
+	b, err := hex.DecodeString(strings.TrimPrefix(id, "0x"))
+	sig = strings.Join(strings.Fields(sig), "")
+	switch {
+	case err == nil && len(b) == 4:
+		s.addSelector([4]byte(b), sig)
+	case err == nil && len(b) == 32:
+		s.addTopic(common.Hash(b), sig)
+	default:
+		return fmt.Errorf("invalid selector or topic %q", id)
+	}
+	return nil
+}
+
+// Function returns the signature of the function whose selector starts data, which is calldata or a selector.
+func (s *Signatures) Function(data []byte) (string, bool) {
+	if s == nil || len(data) < 4 {
+		return "", false
+	}
+	sig, ok := s.selectors[[4]byte(data)]
+	return sig, ok
+}
+
+// Event returns the signature of the event a topic identifies.
+func (s *Signatures) Event(topic common.Hash) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	sig, ok := s.topics[topic]
+	return sig, ok
+}
@@ -0,0 +1,81 @@
+package abi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	transferSelector = common.FromHex("a9059cbb")
+	transferTopic    = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+func TestSignaturesParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"JSON object", `{"a9059cbb": "transfer(address,uint256)", "0x` + common.Bytes2Hex(transferTopic[:]) + `": "Transfer(address,address,uint256)"}`, ""},
+		{"JSON array", `["transfer(address, uint256)", "Transfer(address,address,uint256)"]`, ""},
+		{"Text", "# ERC-20\n\ntransfer(address,uint256)\n0x" + common.Bytes2Hex(transferTopic[:]) + " Transfer(address,address,uint256)\n", ""},
+		{"Invalid selector", `{"a9059c": "transfer(address,uint256)"}`, `invalid selector or topic "a9059c"`},
+		{"Invalid signature", "transfer(address,uint256)\ntransfer", `line 2: invalid signature "transfer"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSignatures()
+			err := s.Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			sig, ok := s.Function(transferSelector)
+			assert.True(t, ok)
+			assert.Equal(t, "transfer(address,uint256)", sig)
+			sig, ok = s.Event(transferTopic)
+			assert.True(t, ok)
+			assert.Equal(t, "Transfer(address,address,uint256)", sig)
+		})
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	s := NewSignatures()
+	s.AddABI(mustLoad(t))
+	// Calldata resolves by its selector
+	sig, ok := s.Function(append(transferSelector, make([]byte, 64)...))
+	assert.True(t, ok)
+	assert.Equal(t, "transfer(address,uint256)", sig)
+	sig, ok = s.Event(transferTopic)
+	assert.True(t, ok)
+	assert.Equal(t, "Transfer(address,address,uint256)", sig)
+
+	// The first signature of a selector is kept
+	require.NoError(t, s.Parse([]byte(`{"a9059cbb": "collision()"}`)))
+	sig, _ = s.Function(transferSelector)
+	assert.Equal(t, "transfer(address,uint256)", sig)
+
+	_, ok = s.Function([]byte{0xa9, 0x05})
+	assert.False(t, ok)
+	_, ok = s.Function(common.FromHex("deadbeef"))
+	assert.False(t, ok)
+	var none *Signatures
+	_, ok = none.Event(transferTopic)
+	assert.False(t, ok)
+
+	path := filepath.Join(t.TempDir(), "sigs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("mint(address)\n"), 0o644))
+	require.NoError(t, s.Load(path))
+	_, ok = s.Function(crypto.Keccak256([]byte("mint(address)")))
+	assert.True(t, ok)
+	require.NoError(t, os.WriteFile(path, []byte("mint"), 0o644))
+	assert.ErrorContains(t, s.Load(path), path+": line 1")
+}
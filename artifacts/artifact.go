@@ -0,0 +1,118 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// parseArtifact decodes a Hardhat or Foundry artifact, which holds a single contract. Hardhat's bytecodes are hex
+// strings, while Foundry's are objects like those of the standard JSON output. A Foundry artifact is named after the
+// compilation target of its metadata, and is left unnamed without one.
+func parseArtifact(data []byte) (*Contract, error) {
+	var a struct {
+		ContractName     string          `json:"contractName"` // Hardhat
+		SourceName       string          `json:"sourceName"`   // Hardhat
+		ABI              json.RawMessage `json:"abi"`
+		Bytecode         json.RawMessage `json:"bytecode"`
+		DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+		StorageLayout    *StorageLayout  `json:"storageLayout"`
+		Metadata         json.RawMessage `json:"metadata"` // Foundry, an object or its JSON
+		AST              struct {
+			AbsolutePath string `json:"absolutePath"`
+		} `json:"ast"`
+		ID *int `json:"id"` // Foundry, index of the source file
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+
+	c := &Contract{Name: a.ContractName, File: a.SourceName, StorageLayout: a.StorageLayout}
+	if c.Name == "" {
+		var metadata struct {
+			Settings struct {
+				CompilationTarget map[string]string `json:"compilationTarget"`
+			} `json:"settings"`
+		}
+		if err := unmarshalEmbedded(a.Metadata, &metadata); err != nil {
+			return nil, err
+		}
+		for file, name := range metadata.Settings.CompilationTarget {
+			c.File, c.Name = file, name
+		}
+		if c.File == "" {
+			c.File = a.AST.AbsolutePath
+		}
+		if a.ID != nil && c.File != "" {
+			c.Sources = map[int]Source{*a.ID: {ID: *a.ID, Name: c.File}}
+		}
+	}
+
+	var err error
+	if c.ABI, err = parseABI(a.ABI); err != nil {
+		return nil, err
+	}
+	if c.Bytecode, err = parseArtifactBytecode(a.Bytecode); err != nil {
+		return nil, err
+	}
+	if c.DeployedBytecode, err = parseArtifactBytecode(a.DeployedBytecode); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseArtifactBytecode decodes a bytecode of Hardhat, a hex string, or of Foundry, an object.
+func parseArtifactBytecode(raw json.RawMessage) (Bytecode, error) {
+	var b standardBytecode
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &b.Object); err != nil {
+			return Bytecode{}, err
+		}
+	} else if err := unmarshalEmbedded(raw, &b); err != nil {
+		return Bytecode{}, err
+	}
+	return b.decode()
+}
+
+// parseABI decodes an ABI, which older versions of solc embed as a string. It's nil if the output has none.
+func parseABI(raw json.RawMessage) (*gethabi.ABI, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var a gethabi.ABI
+	if err := unmarshalEmbedded(raw, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// unmarshalEmbedded decodes JSON into v, which may have been embedded as a string. Missing or empty JSON leaves v
+// unchanged.
+func unmarshalEmbedded(raw json.RawMessage, v any) error {
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		raw = json.RawMessage(s)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// decodeBytecode decodes a bytecode object, which the compiler leaves unlinked if it references libraries.
+func decodeBytecode(object string) ([]byte, error) {
+	if strings.Contains(object, "__") {
+		return nil, errors.New("bytecode has unlinked library references")
+	}
+	code := common.FromHex(object)
+	if len(code)*2 != len(strings.TrimPrefix(object, "0x")) {
+		return nil, errors.New("invalid bytecode")
+	}
+	return code, nil
+}
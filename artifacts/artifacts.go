@@ -0,0 +1,214 @@
+// Package artifacts reads compiled contracts from the output of solc (--combined-json and standard JSON), Hardhat
+// (artifacts/*.json and build-info) and Foundry (out/*.json and build-info), so that tools can take a contract by name
+// rather than its bytecode.
+//
+// What a contract holds depends on what was compiled: Hardhat artifacts have no source maps, which are in the
+// build-info files, and only solc and Foundry report the storage layout when it's requested.
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// errUnknownFormat is the error of a JSON file that isn't an artifact.
+var errUnknownFormat = errors.New("unknown artifact format")
+
+// Contract is a compiled contract.
+type Contract struct {
+	Name             string // e.g. "Counter"
+	File             string // source file defining the contract, e.g. "src/Counter.sol", "" if unknown
+	ABI              *gethabi.ABI
+	Bytecode         Bytecode // creation bytecode
+	DeployedBytecode Bytecode
+	StorageLayout    *StorageLayout // nil if it wasn't requested from the compiler
+	Sources          map[int]Source // sources of the compilation by index, which the source maps refer to
+}
+
+// FullName returns the contract name qualified by its file, e.g. "src/Counter.sol:Counter".
+func (c *Contract) FullName() string {
+	if c.File == "" {
+		return c.Name
+	}
+	return c.File + ":" + c.Name
+}
+
+// Bytecode is the creation or deployed bytecode of a contract. The code is empty for interfaces and abstract
+// contracts.
+type Bytecode struct {
+	Code                []byte
+	SourceMap           string                          // compressed source map, "" if unknown
+	GeneratedSources    []Source                        // sources generated by the compiler, such as #utility.yul
+	ImmutableReferences map[string][]ImmutableReference // by AST id of the immutable, deployed bytecode only
+}
+
+// ImmutableReference is where the value of an immutable variable is written in the deployed bytecode.
+type ImmutableReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Source is a source file of a compilation. Its content is "" unless the artifact holds it.
+type Source struct {
+	ID      int
+	Name    string
+	Content string
+}
+
+// StorageLayout is the storage layout of a contract, as reported by solc.
+type StorageLayout struct {
+	Storage []StorageVariable      `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// StorageVariable is a state variable, or a member of a struct.
+type StorageVariable struct {
+	Label    string `json:"label"`
+	Contract string `json:"contract,omitempty"` // e.g. "src/Counter.sol:Counter", empty for struct members
+	Slot     uint64 `json:"slot,string"`
+	Offset   int    `json:"offset"` // in bytes, within the slot
+	Type     string `json:"type"`   // key in StorageLayout.Types
+}
+
+// StorageType describes a type of the storage layout.
+type StorageType struct {
+	Label         string            `json:"label"`    // e.g. "mapping(address => uint256)"
+	Encoding      string            `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	NumberOfBytes uint64            `json:"numberOfBytes,string"`
+	Key           string            `json:"key,omitempty"`     // mappings
+	Value         string            `json:"value,omitempty"`   // mappings
+	Base          string            `json:"base,omitempty"`    // arrays
+	Members       []StorageVariable `json:"members,omitempty"` // structs
+}
+
+// Load reads the contracts of a file in any of the supported formats, or of the files of a directory, sorted by full
+// name. Files of a directory that aren't artifacts are ignored, as are the build-info directories, which duplicate
+// the artifacts next to them, but an artifact that fails to parse is an error.
+func Load(path string) ([]*Contract, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		contracts, err := loadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return contracts, nil
+	}
+
+	var contracts []*Contract
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() && d.Name() == "build-info" && file != path:
+			return filepath.SkipDir
+		case d.IsDir() || filepath.Ext(file) != ".json" || strings.HasSuffix(file, ".dbg.json"):
+			return nil
+		}
+		found, err := loadFile(file)
+		switch {
+		case errors.Is(err, errUnknownFormat):
+			return nil
+		case err != nil:
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		contracts = append(contracts, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
+
+// loadFile reads the contracts of a file. A Foundry artifact without metadata is named after the file.
+func loadFile(path string) ([]*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contracts {
+		if c.Name == "" {
+			c.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+	}
+	return contracts, nil
+}
+
+// Parse decodes the contracts of an artifact in any of the supported formats, sorted by full name.
+func Parse(data []byte) ([]*Contract, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, errUnknownFormat // JSON, but not an object
+		}
+		return nil, err
+	}
+	var (
+		contracts []*Contract
+		err       error
+	)
+	switch {
+	case fields["output"] != nil: // build-info
+		contracts, err = parseBuildInfo(data)
+	case fields["contracts"] != nil && isCombinedJSON(fields["contracts"]):
+		contracts, err = parseCombinedJSON(data)
+	case fields["contracts"] != nil:
+		contracts, err = ParseStandardJSON(data)
+	case fields["abi"] != nil && fields["bytecode"] != nil:
+		var c *Contract
+		c, err = parseArtifact(data)
+		contracts = []*Contract{c}
+	default:
+		return nil, errUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
+
+// Find returns the contract with a name, either bare ("Counter") or qualified by its file ("src/Counter.sol:Counter").
+// An empty name matches the only contract.
+func Find(contracts []*Contract, name string) (*Contract, error) {
+	var found []*Contract
+	for _, c := range contracts {
+		if name == "" || c.Name == name || c.FullName() == name {
+			found = append(found, c)
+		}
+	}
+	switch {
+	case len(found) == 1:
+		return found[0], nil
+	case len(found) == 0 && name == "":
+		return nil, errors.New("no contract")
+	case len(found) == 0:
+		return nil, fmt.Errorf("no contract %s", name)
+	}
+	names := make([]string, len(found))
+	for i, c := range found {
+		names[i] = c.FullName()
+	}
+	return nil, fmt.Errorf("several contracts match, choose one of %s", strings.Join(names, ", "))
+}
+
+func sortContracts(contracts []*Contract) {
+	sort.SliceStable(contracts, func(i, j int) bool { return contracts[i].FullName() < contracts[j].FullName() })
+}
@@ -0,0 +1,248 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	counterABI    = `[{"type":"function","name":"increment","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`
+	storageLayout = `{"storage":[{"astId":3,"contract":"src/Counter.sol:Counter","label":"number","offset":0,"slot":"1","type":"t_uint256"}],
+		"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		layout bool // whether the format has the storage layout
+		srcMap bool // whether the format has the source maps
+	}{
+		{
+			name: "Standard JSON",
+			json: `{
+				"sources": {"src/Counter.sol": {"id": 0}},
+				"contracts": {"src/Counter.sol": {"Counter": {
+					"abi": ` + counterABI + `,
+					"storageLayout": ` + storageLayout + `,
+					"evm": {
+						"bytecode": {"object": "6001600055", "sourceMap": "0:1:0:-:0"},
+						"deployedBytecode": {"object": "5f00", "sourceMap": "0:1:0:-:0;:::o",
+							"generatedSources": [{"id": 1, "name": "#utility.yul", "contents": "{}"}],
+							"immutableReferences": {"7": [{"start": 1, "length": 32}]}}
+					}
+				}}}
+			}`,
+			layout: true,
+			srcMap: true,
+		},
+		{
+			name: "Combined JSON",
+			json: `{
+				"contracts": {"src/Counter.sol:Counter": {
+					"abi": ` + counterABI + `,
+					"bin": "6001600055", "bin-runtime": "5f00",
+					"srcmap": "0:1:0:-:0", "srcmap-runtime": "0:1:0:-:0;:::o",
+					"storage-layout": ` + storageLayout + `
+				}},
+				"sourceList": ["src/Counter.sol"],
+				"version": "0.8.26"
+			}`,
+			layout: true,
+			srcMap: true,
+		},
+		{
+			name: "Combined JSON before solc 0.8.10",
+			json: `{
+				"contracts": {"src/Counter.sol:Counter": {
+					"abi": ` + quote(counterABI) + `,
+					"bin": "6001600055", "bin-runtime": "5f00",
+					"storage-layout": ` + quote(storageLayout) + `
+				}},
+				"sourceList": ["src/Counter.sol"]
+			}`,
+			layout: true,
+		},
+		{
+			name: "Hardhat",
+			json: `{
+				"_format": "hh-sol-artifact-1",
+				"contractName": "Counter",
+				"sourceName": "src/Counter.sol",
+				"abi": ` + counterABI + `,
+				"bytecode": "0x6001600055",
+				"deployedBytecode": "0x5f00",
+				"linkReferences": {},
+				"deployedLinkReferences": {}
+			}`,
+		},
+		{
+			name: "Foundry",
+			json: `{
+				"abi": ` + counterABI + `,
+				"bytecode": {"object": "0x6001600055", "sourceMap": "0:1:0:-:0", "linkReferences": {}},
+				"deployedBytecode": {"object": "0x5f00", "sourceMap": "0:1:0:-:0;:::o", "linkReferences": {},
+					"immutableReferences": {"7": [{"start": 1, "length": 32}]}},
+				"storageLayout": ` + storageLayout + `,
+				"metadata": {"settings": {"compilationTarget": {"src/Counter.sol": "Counter"}}},
+				"ast": {"absolutePath": "src/Counter.sol"},
+				"id": 0
+			}`,
+			layout: true,
+			srcMap: true,
+		},
+		{
+			name: "Build info",
+			json: `{
+				"_format": "hh-sol-build-info-1",
+				"input": {"language": "Solidity", "sources": {"src/Counter.sol": {"content": "contract Counter {}"}}},
+				"output": {
+					"sources": {"src/Counter.sol": {"id": 0}},
+					"contracts": {"src/Counter.sol": {"Counter": {
+						"abi": ` + counterABI + `,
+						"evm": {
+							"bytecode": {"object": "6001600055", "sourceMap": "0:1:0:-:0"},
+							"deployedBytecode": {"object": "5f00", "sourceMap": "0:1:0:-:0;:::o"}
+						}
+					}}}
+				}
+			}`,
+			srcMap: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contracts, err := Parse([]byte(tt.json))
+			require.NoError(t, err)
+			require.Len(t, contracts, 1)
+			c := contracts[0]
+
+			assert.Equal(t, "src/Counter.sol:Counter", c.FullName())
+			require.NotNil(t, c.ABI)
+			assert.Contains(t, c.ABI.Methods, "increment")
+			assert.Equal(t, []byte{0x60, 0x01, 0x60, 0x00, 0x55}, c.Bytecode.Code)
+			assert.Equal(t, []byte{0x5f, 0x00}, c.DeployedBytecode.Code)
+
+			if tt.srcMap {
+				assert.Equal(t, "0:1:0:-:0", c.Bytecode.SourceMap)
+				assert.Equal(t, "0:1:0:-:0;:::o", c.DeployedBytecode.SourceMap)
+				assert.Equal(t, "src/Counter.sol", c.Sources[0].Name)
+			} else {
+				assert.Empty(t, c.DeployedBytecode.SourceMap)
+			}
+			if tt.layout {
+				require.NotNil(t, c.StorageLayout)
+				assert.Equal(t, []StorageVariable{{Label: "number", Contract: "src/Counter.sol:Counter", Slot: 1, Type: "t_uint256"}},
+					c.StorageLayout.Storage)
+				assert.Equal(t, StorageType{Label: "uint256", Encoding: "inplace", NumberOfBytes: 32}, c.StorageLayout.Types["t_uint256"])
+			} else {
+				assert.Nil(t, c.StorageLayout)
+			}
+		})
+	}
+
+	contracts, err := Parse([]byte(tests[0].json))
+	require.NoError(t, err)
+	deployed := contracts[0].DeployedBytecode
+	assert.Equal(t, map[string][]ImmutableReference{"7": {{Start: 1, Length: 32}}}, deployed.ImmutableReferences)
+	assert.Equal(t, []Source{{ID: 1, Name: "#utility.yul", Content: "{}"}}, deployed.GeneratedSources)
+
+	contracts, err = Parse([]byte(tests[len(tests)-1].json))
+	require.NoError(t, err)
+	assert.Equal(t, "contract Counter {}", contracts[0].Sources[0].Content)
+}
+
+// quote embeds JSON as a string, the way older versions of solc output the ABI and storage layout.
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		json    string
+		wantErr string
+	}{
+		{`[]`, "unknown artifact format"},
+		{`{"abi": [`, ""}, // truncated, the message depends on the JSON implementation
+		{`{"foo": 1}`, "unknown artifact format"},
+		{`{"contracts": {"A.sol": {"A": {"evm": {"deployedBytecode": {"object": "73__$abc$__"}}}}}}`,
+			"A.sol:A: bytecode has unlinked library references"},
+		{`{"contracts": {"A.sol:A": {"bin": "6"}}}`, "A.sol:A: invalid bytecode"},
+		{`{"abi": [], "bytecode": "0x", "deployedBytecode": "0xzz"}`, "invalid bytecode"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.json))
+		if tt.wantErr == "" {
+			assert.Error(t, err, tt.json)
+		} else {
+			assert.EqualError(t, err, tt.wantErr, tt.json)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	artifact := `{"abi": [], "bytecode": {"object": "0x00"}, "deployedBytecode": {"object": "0x00"}}`
+	write("out/Counter.sol/Counter.json", artifact)
+	write("out/Counter.t.sol/CounterTest.json", artifact)
+	write("out/build-info/abc.json", `{"output": {"contracts": {"A.sol": {"A": {}}}}}`)
+	write("artifacts/Token.sol/Token.json", `{"contractName": "Token", "sourceName": "Token.sol", "abi": [], "bytecode": "0x", "deployedBytecode": "0x"}`)
+	write("artifacts/Token.sol/Token.dbg.json", `{"_format": "hh-sol-dbg-1", "buildInfo": "../build-info/abc.json"}`)
+	write("package.json", `{"name": "counter"}`)
+	write("README.md", "# Counter")
+
+	contracts, err := Load(dir)
+	require.NoError(t, err)
+	names := make([]string, len(contracts))
+	for i, c := range contracts {
+		names[i] = c.FullName()
+	}
+	assert.Equal(t, []string{"Counter", "CounterTest", "Token.sol:Token"}, names)
+
+	contracts, err = Load(filepath.Join(dir, "out/build-info/abc.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "A.sol:A", contracts[0].FullName())
+
+	_, err = Load(filepath.Join(dir, "package.json"))
+	assert.EqualError(t, err, filepath.Join(dir, "package.json")+": unknown artifact format")
+
+	write("abi.json", `[{"type": "function", "name": "increment", "inputs": [], "outputs": []}]`)
+	contracts, err = Load(dir)
+	require.NoError(t, err)
+	assert.Len(t, contracts, 3)
+
+	write("out/Broken.sol/Broken.json", `{"abi": [], "bytecode": {"object": "0xzz"}, "deployedBytecode": {"object": "0x"}}`)
+	_, err = Load(dir)
+	assert.EqualError(t, err, filepath.Join(dir, "out/Broken.sol/Broken.json")+": invalid bytecode")
+}
+
+func TestFind(t *testing.T) {
+	contracts := []*Contract{{Name: "A", File: "a.sol"}, {Name: "A", File: "b.sol"}, {Name: "B", File: "b.sol"}}
+
+	c, err := Find(contracts, "b.sol:A")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[1], c)
+	c, err = Find(contracts, "B")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[2], c)
+	_, err = Find(contracts, "A")
+	assert.EqualError(t, err, "several contracts match, choose one of a.sol:A, b.sol:A")
+	_, err = Find(contracts, "C")
+	assert.EqualError(t, err, "no contract C")
+	_, err = Find(nil, "")
+	assert.EqualError(t, err, "no contract")
+	c, err = Find(contracts[2:], "")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[2], c)
+}
@@ -0,0 +1,191 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type standardOutput struct {
+	Sources map[string]struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"` // not part of the output, but present when it's merged with the input
+	} `json:"sources"`
+	Contracts map[string]map[string]struct {
+		ABI           json.RawMessage `json:"abi"`
+		StorageLayout *StorageLayout  `json:"storageLayout"`
+		EVM           struct {
+			Bytecode         standardBytecode `json:"bytecode"`
+			DeployedBytecode standardBytecode `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// standardBytecode is a bytecode of the standard JSON output, which Foundry artifacts use as well.
+type standardBytecode struct {
+	Object           string `json:"object"`
+	SourceMap        string `json:"sourceMap"`
+	GeneratedSources []struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Contents string `json:"contents"`
+	} `json:"generatedSources"`
+	ImmutableReferences map[string][]ImmutableReference `json:"immutableReferences"`
+}
+
+func (b *standardBytecode) decode() (Bytecode, error) {
+	code, err := decodeBytecode(b.Object)
+	if err != nil {
+		return Bytecode{}, err
+	}
+	bytecode := Bytecode{Code: code, SourceMap: b.SourceMap, ImmutableReferences: b.ImmutableReferences}
+	for _, g := range b.GeneratedSources {
+		bytecode.GeneratedSources = append(bytecode.GeneratedSources, Source{ID: g.ID, Name: g.Name, Content: g.Contents})
+	}
+	return bytecode, nil
+}
+
+// ParseStandardJSON decodes a solc standard JSON output and returns its contracts, sorted by full name. Each contract
+// has what was selected in the outputSelection of the input.
+func ParseStandardJSON(data []byte) ([]*Contract, error) {
+	var out standardOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return parseStandardOutput(&out, nil)
+}
+
+// parseStandardOutput returns the contracts of a standard JSON output, with the content of its sources taken from
+// contents when the output doesn't hold it.
+func parseStandardOutput(out *standardOutput, contents map[string]string) ([]*Contract, error) {
+	sources := make(map[int]Source)
+	for name, s := range out.Sources {
+		content := s.Content
+		if content == "" {
+			content = contents[name]
+		}
+		sources[s.ID] = Source{ID: s.ID, Name: name, Content: content}
+	}
+
+	var contracts []*Contract
+	for file, byName := range out.Contracts {
+		for name, c := range byName {
+			contract := &Contract{Name: name, File: file, StorageLayout: c.StorageLayout, Sources: sources}
+			var err error
+			if contract.ABI, err = parseABI(c.ABI); err == nil {
+				if contract.Bytecode, err = c.EVM.Bytecode.decode(); err == nil {
+					contract.DeployedBytecode, err = c.EVM.DeployedBytecode.decode()
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", contract.FullName(), err)
+			}
+			contracts = append(contracts, contract)
+		}
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
+
+// parseBuildInfo decodes a Hardhat or Foundry build-info file, the standard JSON input and output of a compilation.
+func parseBuildInfo(data []byte) ([]*Contract, error) {
+	var info struct {
+		Input struct {
+			Sources map[string]struct {
+				Content string `json:"content"`
+			} `json:"sources"`
+		} `json:"input"`
+		Output standardOutput `json:"output"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	contents := make(map[string]string)
+	for name, s := range info.Input.Sources {
+		contents[name] = s.Content
+	}
+	return parseStandardOutput(&info.Output, contents)
+}
+
+type combinedContract struct {
+	ABI                     json.RawMessage `json:"abi"` // a string before solc 0.8.10
+	Bin                     string          `json:"bin"`
+	BinRuntime              string          `json:"bin-runtime"`
+	SrcMap                  string          `json:"srcmap"`
+	SrcMapRuntime           string          `json:"srcmap-runtime"`
+	StorageLayout           json.RawMessage `json:"storage-layout"` // a string before solc 0.8.10
+	GeneratedSources        json.RawMessage `json:"generated-sources"`
+	GeneratedSourcesRuntime json.RawMessage `json:"generated-sources-runtime"`
+}
+
+// isCombinedJSON reports whether the contracts field of a solc output is that of --combined-json, where contracts
+// are keyed by full name, rather than by file and then by name.
+func isCombinedJSON(contracts json.RawMessage) bool {
+	var byKey map[string]map[string]json.RawMessage
+	if json.Unmarshal(contracts, &byKey) != nil {
+		return false
+	}
+	for _, fields := range byKey {
+		for _, field := range []string{"abi", "bin", "bin-runtime", "srcmap", "srcmap-runtime", "storage-layout"} {
+			if fields[field] != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseCombinedJSON decodes the output of solc --combined-json. Immutable references aren't part of it.
+func parseCombinedJSON(data []byte) ([]*Contract, error) {
+	var out struct {
+		Contracts  map[string]combinedContract `json:"contracts"`
+		SourceList []string                    `json:"sourceList"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	sources := make(map[int]Source)
+	for id, name := range out.SourceList {
+		sources[id] = Source{ID: id, Name: name}
+	}
+
+	var contracts []*Contract
+	for fullName, c := range out.Contracts {
+		contract := &Contract{Name: fullName, Sources: sources}
+		if i := strings.LastIndexByte(fullName, ':'); i >= 0 {
+			contract.File, contract.Name = fullName[:i], fullName[i+1:]
+		}
+		if err := c.decode(contract); err != nil {
+			return nil, fmt.Errorf("%s: %v", fullName, err)
+		}
+		contracts = append(contracts, contract)
+	}
+	sortContracts(contracts)
+	return contracts, nil
+}
+
+func (c *combinedContract) decode(contract *Contract) error {
+	var err error
+	if contract.ABI, err = parseABI(c.ABI); err != nil {
+		return err
+	}
+	creation := standardBytecode{Object: c.Bin, SourceMap: c.SrcMap}
+	deployed := standardBytecode{Object: c.BinRuntime, SourceMap: c.SrcMapRuntime}
+	for _, b := range []struct {
+		raw json.RawMessage
+		to  any
+	}{
+		{c.GeneratedSources, &creation.GeneratedSources},
+		{c.GeneratedSourcesRuntime, &deployed.GeneratedSources},
+		{c.StorageLayout, &contract.StorageLayout},
+	} {
+		if err := unmarshalEmbedded(b.raw, b.to); err != nil {
+			return err
+		}
+	}
+	if contract.Bytecode, err = creation.decode(); err != nil {
+		return err
+	}
+	contract.DeployedBytecode, err = deployed.decode()
+	return err
+}
@@ -0,0 +1,303 @@
+// Package cfg recovers the control-flow graph of EVM bytecode.
+//
+// The code is split into basic blocks: a block starts at the first instruction, at every JUMPDEST and after every
+// instruction ending a block (STOP, RETURN, REVERT, INVALID, SELFDESTRUCT, JUMP and JUMPI). The targets of the jumps
+// are then resolved by tracking the constants pushed on the stack through every block, so that a target pushed long
+// before the jump and moved around with DUP and SWAP, such as the return address of an internal function, is found.
+package cfg
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// maxStates bounds the number of distinct entry stacks analyzed per block, to end the analysis of loops that grow the stack.
+const maxStates = 64
+
+// EdgeKind is how execution goes from a block to another.
+type EdgeKind int
+
+const (
+	Fallthrough EdgeKind = iota // the next block follows without a jump, including a JUMPI not taken
+	Jump                        // a JUMP, or a JUMPI taken
+)
+
+func (k EdgeKind) String() string {
+	if k == Jump {
+		return "jump"
+	}
+	return "fallthrough"
+}
+
+// Edge goes from a block to the block starting at To.
+type Edge struct {
+	To   uint64
+	Kind EdgeKind
+}
+
+// Block is a basic block: a sequence of instructions only entered at its start and only left at its end.
+type Block struct {
+	Start        uint64 // PC of the first instruction
+	Instructions []gevm.Instruction
+	Succs        []Edge   // sorted by destination
+	Preds        []uint64 // starts of the blocks with an edge to this one, sorted
+	Reachable    bool     // reached from the entry block through resolved edges
+	Unresolved   bool     // ends with a jump whose target isn't always a constant
+	Invalid      []uint64 // constant jump targets that aren't a JUMPDEST, so the jump fails
+}
+
+// Last returns the last instruction of the block.
+func (b *Block) Last() gevm.Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// Graph is the control-flow graph of bytecode.
+type Graph struct {
+	Blocks  []*Block // sorted by start
+	byStart map[uint64]*Block
+}
+
+// Block returns the block starting at pc, nil if there is none.
+func (g *Graph) Block(pc uint64) *Block {
+	return g.byStart[pc]
+}
+
+// endsBlock reports whether execution never continues to the instruction following op.
+func endsBlock(op gevm.Opcode) bool {
+	switch op {
+	case gevm.STOP, gevm.RETURN, gevm.REVERT, gevm.INVALID, gevm.SELFDESTRUCT, gevm.JUMP, gevm.JUMPI:
+		return true
+	}
+	return !op.IsDefined()
+}
+
+// Build recovers the control-flow graph of code. The Solidity metadata appended to the code is ignored.
+func Build(code []byte) *Graph {
+	g := &Graph{byStart: make(map[uint64]*Block)}
+	var current *Block
+	for _, ins := range gevm.Disassemble(code) {
+		if current == nil || ins.Op == gevm.JUMPDEST {
+			current = &Block{Start: ins.PC}
+			g.Blocks = append(g.Blocks, current)
+			g.byStart[ins.PC] = current
+		}
+		current.Instructions = append(current.Instructions, ins)
+		if endsBlock(ins.Op) {
+			current = nil
+		}
+	}
+
+	a := &analysis{g: g, seen: make(map[uint64]map[string]bool), succs: make(map[uint64]map[Edge]bool)}
+	if len(g.Blocks) > 0 {
+		a.visit(g.Blocks[0], nil)
+		a.run()
+		for _, b := range g.Blocks {
+			b.Reachable = len(a.seen[b.Start]) > 0
+		}
+		// Blocks only reached through unresolved jumps are analyzed with an unknown stack
+		for _, b := range g.Blocks {
+			if !b.Reachable {
+				a.visit(b, nil)
+				a.run()
+			}
+		}
+	}
+
+	for _, b := range g.Blocks {
+		for e := range a.succs[b.Start] {
+			b.Succs = append(b.Succs, e)
+			to := g.byStart[e.To]
+			to.Preds = append(to.Preds, b.Start)
+		}
+		sort.Slice(b.Succs, func(i, j int) bool {
+			if b.Succs[i].To != b.Succs[j].To {
+				return b.Succs[i].To < b.Succs[j].To
+			}
+			return b.Succs[i].Kind < b.Succs[j].Kind
+		})
+	}
+	for _, b := range g.Blocks {
+		sort.Slice(b.Preds, func(i, j int) bool { return b.Preds[i] < b.Preds[j] })
+		b.Preds = compact(b.Preds)
+	}
+	return g
+}
+
+// value is a stack item, nil if it's not a known constant.
+type value *uint256.Int
+
+// analysis propagates the constants on the stack from block to block.
+type analysis struct {
+	g     *Graph
+	queue []pending
+	seen  map[uint64]map[string]bool // entry stacks analyzed, per block
+	succs map[uint64]map[Edge]bool
+}
+
+type pending struct {
+	block *Block
+	stack []value
+}
+
+// visit queues the analysis of a block entered with a stack, unless it was already analyzed with it.
+func (a *analysis) visit(b *Block, stack []value) {
+	key := stackKey(stack)
+	seen := a.seen[b.Start]
+	if seen == nil {
+		seen = make(map[string]bool)
+		a.seen[b.Start] = seen
+	}
+	if seen[key] || len(seen) >= maxStates {
+		return
+	}
+	seen[key] = true
+	a.queue = append(a.queue, pending{b, stack})
+}
+
+func (a *analysis) run() {
+	for len(a.queue) > 0 {
+		p := a.queue[0]
+		a.queue = a.queue[1:]
+		a.step(p.block, p.stack)
+	}
+}
+
+// step runs a block on an abstract stack and queues its successors.
+func (a *analysis) step(b *Block, entry []value) {
+	s := &abstractStack{items: append([]value(nil), entry...)}
+	for _, ins := range b.Instructions[:len(b.Instructions)-1] {
+		s.apply(ins)
+	}
+
+	last := b.Last()
+	switch last.Op {
+	case gevm.JUMP:
+		a.jump(b, s.pop(), s.items)
+	case gevm.JUMPI:
+		target := s.pop()
+		s.pop()
+		a.jump(b, target, s.items)
+		a.next(b, s.items)
+	default:
+		s.apply(last)
+		if !endsBlock(last.Op) {
+			a.next(b, s.items)
+		}
+	}
+}
+
+func (a *analysis) jump(b *Block, target value, stack []value) {
+	if target == nil {
+		b.Unresolved = true
+		return
+	}
+	pc := (*uint256.Int)(target)
+	to := a.g.byStart[pc.Uint64()]
+	if !pc.IsUint64() || to == nil || to.Instructions[0].Op != gevm.JUMPDEST {
+		b.Invalid = compact(append(b.Invalid, pc.Uint64()))
+		return
+	}
+	a.edge(b, to, Jump, stack)
+}
+
+// next follows the block to the one after it in the code.
+func (a *analysis) next(b *Block, stack []value) {
+	last := b.Last()
+	if next := a.g.byStart[last.PC+1+uint64(len(last.Immediate))]; next != nil {
+		a.edge(b, next, Fallthrough, stack)
+	}
+}
+
+func (a *analysis) edge(from, to *Block, kind EdgeKind, stack []value) {
+	edges := a.succs[from.Start]
+	if edges == nil {
+		edges = make(map[Edge]bool)
+		a.succs[from.Start] = edges
+	}
+	edges[Edge{To: to.Start, Kind: kind}] = true
+	a.visit(to, stack)
+}
+
+// abstractStack is the top of the stack, bottom first. The items below it are unknown.
+type abstractStack struct {
+	items []value
+}
+
+// peek returns the n-th item from the top, growing the known part of the stack with unknown items as needed.
+func (s *abstractStack) peek(n int) *value {
+	if n >= len(s.items) {
+		s.items = append(make([]value, n+1-len(s.items)), s.items...)
+	}
+	return &s.items[len(s.items)-1-n]
+}
+
+func (s *abstractStack) pop() value {
+	v := *s.peek(0)
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+func (s *abstractStack) push(v value) {
+	s.items = append(s.items, v)
+}
+
+// apply runs an instruction that doesn't jump.
+func (s *abstractStack) apply(ins gevm.Instruction) {
+	switch {
+	case ins.Op == gevm.PUSH0:
+		s.push(new(uint256.Int))
+	case ins.Op.IsPush():
+		// A truncated immediate is padded with zeros, like the EVM does
+		imm := make([]byte, ins.Op.PushSize())
+		copy(imm, ins.Immediate)
+		s.push(new(uint256.Int).SetBytes(imm))
+	case ins.Op == gevm.PC:
+		s.push(uint256.NewInt(ins.PC))
+	case ins.Op >= gevm.DUP1 && ins.Op <= gevm.DUP16:
+		s.push(*s.peek(int(ins.Op - gevm.DUP1)))
+	case ins.Op >= gevm.SWAP1 && ins.Op <= gevm.SWAP16:
+		other := s.peek(int(ins.Op-gevm.SWAP1) + 1)
+		top := s.peek(0)
+		*top, *other = *other, *top
+	default:
+		pops, pushes := ins.Op.StackIO()
+		for i := 0; i < pops; i++ {
+			s.pop()
+		}
+		for i := 0; i < pushes; i++ {
+			s.push(nil)
+		}
+	}
+}
+
+// stackKey identifies a stack by its known constants, unknown items below the top being equivalent to missing ones.
+func stackKey(stack []value) string {
+	for len(stack) > 0 && stack[0] == nil {
+		stack = stack[1:]
+	}
+	var sb strings.Builder
+	for _, v := range stack {
+		if v == nil {
+			sb.WriteString("?,")
+		} else {
+			sb.WriteString((*uint256.Int)(v).Hex() + ",")
+		}
+	}
+	return sb.String()
+}
+
+// compact sorts s and removes its duplicates.
+func compact(s []uint64) []uint64 {
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
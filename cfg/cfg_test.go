@@ -0,0 +1,118 @@
+package cfg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// succs returns the successors of the block starting at pc
+func succs(t *testing.T, g *Graph, pc int) []Edge {
+	b := g.Block(uint64(pc))
+	if b == nil {
+		t.Fatalf("no block at %#x", pc)
+	}
+	return b.Succs
+}
+
+// blockStart returns the start of the n-th block.
+func blockStart(g *Graph, n int) uint64 {
+	return g.Blocks[n].Start
+}
+
+func TestBuildBlocks(t *testing.T) {
+	// PUSH1 1, PUSH2 a, JUMPI | STOP | a: JUMPDEST, PUSH0, PUSH0, RETURN
+	code := gevm.NewProgram().Push(1).JumpI("a").Op(gevm.STOP).Label("a").Return(0, 0).Bytes()
+	g := Build(code)
+
+	var starts []uint64
+	for _, b := range g.Blocks {
+		starts = append(starts, b.Start)
+		assert.True(t, b.Reachable)
+	}
+	assert.Equal(t, []uint64{0, 6, 7}, starts)
+	assert.Len(t, g.Block(0).Instructions, 3)
+	assert.Equal(t, gevm.JUMPI, g.Block(0).Last().Op)
+	assert.Equal(t, []Edge{{6, Fallthrough}, {7, Jump}}, succs(t, g, 0))
+	assert.Empty(t, succs(t, g, 6))
+	assert.Empty(t, succs(t, g, 7))
+	assert.Equal(t, []uint64{0}, g.Block(7).Preds)
+	assert.Nil(t, g.Block(1))
+}
+
+func TestBuildFallthroughIntoJumpdest(t *testing.T) {
+	code := gevm.NewProgram().Push(1).Op(gevm.POP).Label("a").Op(gevm.STOP).Bytes()
+	g := Build(code)
+	assert.Len(t, g.Blocks, 2)
+	assert.Equal(t, []Edge{{3, Fallthrough}}, succs(t, g, 0))
+}
+
+func TestBuildInternalFunction(t *testing.T) {
+	// Two calls of a function taking an argument, which swaps its return address to the top before jumping back
+	p := gevm.NewProgram()
+	p.PushLabel("ret1").Push(5).Jump("fn")
+	p.Label("ret1").Op(gevm.POP).PushLabel("ret2").Push(6).Jump("fn")
+	p.Label("ret2").Op(gevm.STOP)
+	p.Label("fn").Op(gevm.SWAP1, gevm.JUMP)
+	code := p.Bytes()
+	g := Build(code)
+
+	fn := g.Blocks[len(g.Blocks)-1]
+	assert.False(t, fn.Unresolved)
+	assert.Equal(t, []Edge{{blockStart(g, 1), Jump}, {blockStart(g, 2), Jump}}, fn.Succs)
+	assert.Equal(t, []uint64{0, blockStart(g, 1)}, fn.Preds)
+}
+
+func TestBuildDupTarget(t *testing.T) {
+	code := gevm.NewProgram().PushLabel("a").Op(gevm.DUP1, gevm.POP, gevm.JUMP).Label("a").Op(gevm.STOP).Bytes()
+	g := Build(code)
+	assert.Equal(t, []Edge{{6, Jump}}, succs(t, g, 0))
+}
+
+func TestBuildUnresolvedAndInvalid(t *testing.T) {
+	p := gevm.NewProgram()
+	p.Push0().Op(gevm.CALLDATALOAD, gevm.JUMP) // 0: unresolved
+	p.Label("a").Push(0x0a).Op(gevm.JUMP)      // 3: only reached through the unresolved jump, jumps into its own PUSH
+	p.Label("b").PushLabel("a").Op(gevm.JUMP)  // 7: also unreachable, jumps to a
+	g := Build(p.Bytes())
+
+	assert.True(t, g.Block(0).Unresolved)
+	assert.Empty(t, succs(t, g, 0))
+	assert.False(t, g.Block(3).Reachable)
+	assert.Equal(t, []uint64{0x0a}, g.Block(3).Invalid)
+	assert.Equal(t, []Edge{{3, Jump}}, succs(t, g, 7))
+	assert.Equal(t, []uint64{7}, g.Block(3).Preds)
+}
+
+func TestBuildLoop(t *testing.T) {
+	// A loop pushing a value on every iteration terminates the analysis
+	code := gevm.NewProgram().Label("loop").Push(1).Jump("loop").Bytes()
+	g := Build(code)
+	assert.Equal(t, []Edge{{0, Jump}}, succs(t, g, 0))
+}
+
+func TestBuildEmptyAndMetadata(t *testing.T) {
+	assert.Empty(t, Build(nil).Blocks)
+
+	metadata := []byte{0xa1, 0x64, 's', 'o', 'l', 'c', 0x43, 0, 8, 26, 0, 10}
+	g := Build(append([]byte{byte(gevm.STOP)}, metadata...))
+	assert.Len(t, g.Blocks, 1)
+}
+
+func TestWriteDOT(t *testing.T) {
+	code := gevm.NewProgram().Push(1).JumpI("a").Op(gevm.STOP).Label("a").Op(gevm.CALLDATASIZE, gevm.JUMP).Bytes()
+	var sb strings.Builder
+	assert.NoError(t, Build(code).WriteDOT(&sb))
+	assert.Equal(t, `digraph cfg {
+	node [shape=box fontname="monospace"];
+	b0000 [label="0x0000: PUSH1 0x01\l0x0002: PUSH2 0x0007\l0x0005: JUMPI\l"];
+	b0006 [label="0x0006: STOP\l"];
+	b0007 [label="0x0007: JUMPDEST\l0x0008: CALLDATASIZE\l0x0009: JUMP\l" color=red];
+	b0000 -> b0006 [style=dashed];
+	b0000 -> b0007;
+}
+`, sb.String())
+}
@@ -0,0 +1,49 @@
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT language, e.g. to render it with `dot -Tsvg`.
+// Blocks unreachable from the entry are grey, and blocks ending with an unresolved jump are red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph cfg {\n")
+	sb.WriteString("\tnode [shape=box fontname=\"monospace\"];\n")
+	for _, b := range g.Blocks {
+		var label strings.Builder
+		for _, ins := range b.Instructions {
+			label.WriteString(dotEscape(ins.String()) + `\l`)
+		}
+		var attrs string
+		switch {
+		case b.Unresolved:
+			attrs = " color=red"
+		case !b.Reachable:
+			attrs = " color=grey fontcolor=grey"
+		}
+		fmt.Fprintf(&sb, "\t%s [label=\"%s\"%s];\n", dotNode(b.Start), label.String(), attrs)
+	}
+	for _, b := range g.Blocks {
+		for _, e := range b.Succs {
+			attrs := ""
+			if e.Kind == Fallthrough {
+				attrs = " [style=dashed]"
+			}
+			fmt.Fprintf(&sb, "\t%s -> %s%s;\n", dotNode(b.Start), dotNode(e.To), attrs)
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func dotNode(pc uint64) string {
+	return fmt.Sprintf("b%04x", pc)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,87 @@
+package cheatcodes
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// assertionFailure is the error of a failed assertion, reverting with its message alone like Foundry's.
+type assertionFailure string
+
+func (f assertionFailure) Error() string {
+	return string(f)
+}
+
+// assertions returns the assertion cheatcodes forge-std's assertTrue, assertEq, assertGt, ... call, each with and
+// without a custom error message.
+func assertions() map[string]func(*Cheatcodes, *gevm.EVM, []any) ([]byte, error) {
+	m := make(map[string]func(*Cheatcodes, *gevm.EVM, []any) ([]byte, error))
+	for name, want := range map[string]bool{"assertTrue": true, "assertFalse": false} {
+		run := func(_ *Cheatcodes, _ *gevm.EVM, args []any) ([]byte, error) {
+			if args[0].(bool) == want {
+				return nil, nil
+			}
+			if len(args) > 1 {
+				return nil, assertionFailure(args[1].(string))
+			}
+			return nil, assertionFailure("assertion failed")
+		}
+		m[name+"(bool)"], m[name+"(bool,string)"] = run, run
+	}
+
+	// compare adds the assertions of a comparison: ok tells if it holds, and failure is the operator describing when it
+	// doesn't, e.g. "!=" for assertEq
+	compare := func(name, typ string, ok func(left, right any) bool, failure string) {
+		run := func(_ *Cheatcodes, _ *gevm.EVM, args []any) ([]byte, error) {
+			if ok(args[0], args[1]) {
+				return nil, nil
+			}
+			prefix := "assertion failed"
+			if len(args) > 2 {
+				prefix = args[2].(string)
+			}
+			return nil, assertionFailure(fmt.Sprintf("%s: %s %s %s", prefix, formatValue(args[0]), failure, formatValue(args[1])))
+		}
+		m[fmt.Sprintf("%s(%s,%s)", name, typ, typ)] = run
+		m[fmt.Sprintf("%s(%s,%s,string)", name, typ, typ)] = run
+	}
+	for _, typ := range []string{"bool", "uint256", "int256", "address", "bytes32", "string", "bytes"} {
+		compare("assertEq", typ, equal, "!=")
+		compare("assertNotEq", typ, func(left, right any) bool { return !equal(left, right) }, "==")
+	}
+	for _, typ := range []string{"uint256", "int256"} {
+		compare("assertGt", typ, func(left, right any) bool { return cmp(left, right) > 0 }, "<=")
+		compare("assertGe", typ, func(left, right any) bool { return cmp(left, right) >= 0 }, "<")
+		compare("assertLt", typ, func(left, right any) bool { return cmp(left, right) < 0 }, ">=")
+		compare("assertLe", typ, func(left, right any) bool { return cmp(left, right) <= 0 }, ">")
+	}
+	return m
+}
+
+// equal reports whether two decoded arguments of the same type are equal.
+func equal(left, right any) bool {
+	switch l := left.(type) {
+	case *big.Int:
+		return l.Cmp(right.(*big.Int)) == 0
+	case []byte:
+		return bytes.Equal(l, right.([]byte))
+	}
+	return left == right
+}
+
+// cmp compares two decoded integers.
+func cmp(left, right any) int {
+	return left.(*big.Int).Cmp(right.(*big.Int))
+}
+
+// formatValue formats a decoded argument for an assertion message, strings as they are.
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return abi.Format(v)
+}
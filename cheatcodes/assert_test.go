@@ -0,0 +1,54 @@
+package cheatcodes
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		sig  string
+		args []any
+		want string // revert reason, "" if the assertion holds
+	}{
+		{"assertTrue(bool)", []any{true}, ""},
+		{"assertTrue(bool)", []any{false}, "assertion failed"},
+		{"assertTrue(bool,string)", []any{false, "not ready"}, "not ready"},
+		{"assertFalse(bool)", []any{true}, "assertion failed"},
+		{"assertEq(uint256,uint256)", []any{big.NewInt(1), big.NewInt(1)}, ""},
+		{"assertEq(uint256,uint256)", []any{big.NewInt(1), big.NewInt(2)}, "assertion failed: 1 != 2"},
+		{"assertEq(int256,int256,string)", []any{big.NewInt(-1), big.NewInt(2), "balance"}, "balance: -1 != 2"},
+		{"assertEq(address,address)", []any{alice, alice}, ""},
+		{"assertEq(address,address)", []any{alice, bob}, "assertion failed: " + alice.Hex() + " != " + bob.Hex()},
+		{"assertEq(bytes32,bytes32)", []any{[32]byte{1}, [32]byte{1}}, ""},
+		{"assertEq(string,string)", []any{"a", "b"}, "assertion failed: a != b"},
+		{"assertEq(bytes,bytes)", []any{[]byte{1}, []byte{1}}, ""},
+		{"assertEq(bytes,bytes)", []any{[]byte{}, []byte{1}}, "assertion failed: 0x != 0x01"},
+		{"assertEq(bool,bool)", []any{true, false}, "assertion failed: true != false"},
+		{"assertNotEq(uint256,uint256)", []any{big.NewInt(1), big.NewInt(1)}, "assertion failed: 1 == 1"},
+		{"assertNotEq(address,address)", []any{alice, bob}, ""},
+		{"assertGt(uint256,uint256)", []any{big.NewInt(2), big.NewInt(1)}, ""},
+		{"assertGt(uint256,uint256)", []any{big.NewInt(1), big.NewInt(1)}, "assertion failed: 1 <= 1"},
+		{"assertGe(int256,int256)", []any{big.NewInt(-2), big.NewInt(-1)}, "assertion failed: -2 < -1"},
+		{"assertLt(uint256,uint256)", []any{big.NewInt(2), big.NewInt(1)}, "assertion failed: 2 >= 1"},
+		{"assertLe(uint256,uint256,string)", []any{big.NewInt(2), big.NewInt(1), "too much"}, "too much: 2 > 1"},
+		{"assertLe(int256,int256)", []any{big.NewInt(1), big.NewInt(1)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sig, func(t *testing.T) {
+			out, err := New().Run(newEVM(), calldata(t, tt.sig, tt.args...))
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, gevm.ErrExecutionReverted)
+			reason, _ := abi.DecodeError(out)
+			assert.Equal(t, tt.want, reason)
+		})
+	}
+}
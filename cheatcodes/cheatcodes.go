@@ -0,0 +1,284 @@
+// Package cheatcodes implements Foundry's cheatcodes as a native contract, so that contract tests written for
+// forge-std's Vm interface can run in gevm.
+//
+// As gevm runs a single call frame, the test code itself is the only code running: prank and startPrank change what
+// CALLER (and optionally ORIGIN) returns in it for the rest of the frame, or until stopPrank, and expectRevert and
+// expectEmit are expectations on the whole execution, checked by Verify once it halts.
+package cheatcodes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"time"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// Address is the address of the cheatcodes, the one forge-std's Vm is at.
+var Address = common.HexToAddress("0x7109709ECfa91a80626fF3989D68f67F5b1DD12D")
+
+// Cheatcodes holds the state of the cheatcodes over an execution, such as the active prank and the expectations.
+// Use a new one for each execution.
+type Cheatcodes struct {
+	prank     *prank
+	revert    *expectedRevert
+	emits     []expectedEmit
+	snapshots []snapshot
+}
+
+// prank is an override of CALLER, and optionally of ORIGIN.
+type prank struct {
+	sender, origin         common.Address
+	prevSender, prevOrigin common.Address
+}
+
+// snapshot is the state saved by the snapshot cheatcode.
+type snapshot struct {
+	storage map[common.Hash]common.Hash
+	state   gevm.WorldState
+	block   gevm.Block
+}
+
+// New returns cheatcodes with no prank or expectation.
+func New() *Cheatcodes {
+	return &Cheatcodes{}
+}
+
+// Register adds the cheatcodes to the native contracts of cfg, at Address.
+func (c *Cheatcodes) Register(cfg *gevm.ChainConfig) {
+	if cfg.Natives == nil {
+		cfg.Natives = make(map[common.Address]gevm.NativeContract)
+	}
+	cfg.Natives[Address] = c
+}
+
+// Run runs the cheatcode called by input. An unknown or failing cheatcode reverts with an Error(string).
+func (c *Cheatcodes) Run(evm *gevm.EVM, input []byte) ([]byte, error) {
+	if len(input) < 4 {
+		return abi.EncodeError("cheatcode without a selector"), gevm.ErrExecutionReverted
+	}
+	cc, ok := cheatcodes[[4]byte(input)]
+	if !ok {
+		return abi.EncodeError(fmt.Sprintf("unknown cheatcode with selector %#x", input[:4])), gevm.ErrExecutionReverted
+	}
+	args, err := cc.method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return abi.EncodeError(fmt.Sprintf("vm.%s: %v", cc.method.Sig, err)), gevm.ErrExecutionReverted
+	}
+	output, err := cc.run(c, evm, args)
+	var failure assertionFailure
+	switch {
+	case errors.As(err, &failure):
+		return abi.EncodeError(failure.Error()), gevm.ErrExecutionReverted
+	case err != nil:
+		return abi.EncodeError(fmt.Sprintf("vm.%s: %v", cc.method.Sig, err)), gevm.ErrExecutionReverted
+	}
+	return output, nil
+}
+
+// cheatcode is a cheatcode's method, to decode its arguments, and its implementation.
+type cheatcode struct {
+	method *gethabi.Method
+	run    func(c *Cheatcodes, evm *gevm.EVM, args []any) ([]byte, error)
+}
+
+// cheatcodes maps the selectors of the supported cheatcodes to them.
+var cheatcodes = func() map[[4]byte]cheatcode {
+	handlers := map[string]func(*Cheatcodes, *gevm.EVM, []any) ([]byte, error){
+		"prank(address)":                          (*Cheatcodes).startPrank,
+		"prank(address,address)":                  (*Cheatcodes).startPrank,
+		"startPrank(address)":                     (*Cheatcodes).startPrank,
+		"startPrank(address,address)":             (*Cheatcodes).startPrank,
+		"stopPrank()":                             (*Cheatcodes).stopPrank,
+		"deal(address,uint256)":                   (*Cheatcodes).deal,
+		"etch(address,bytes)":                     (*Cheatcodes).etch,
+		"warp(uint256)":                           (*Cheatcodes).warp,
+		"roll(uint256)":                           (*Cheatcodes).roll,
+		"store(address,bytes32,bytes32)":          (*Cheatcodes).store,
+		"load(address,bytes32)":                   (*Cheatcodes).load,
+		"snapshot()":                              (*Cheatcodes).snapshot,
+		"snapshotState()":                         (*Cheatcodes).snapshot,
+		"revertTo(uint256)":                       (*Cheatcodes).revertTo,
+		"revertToState(uint256)":                  (*Cheatcodes).revertTo,
+		"expectRevert()":                          (*Cheatcodes).expectRevert,
+		"expectRevert(bytes)":                     (*Cheatcodes).expectRevert,
+		"expectRevert(bytes4)":                    (*Cheatcodes).expectRevert,
+		"expectEmit()":                            (*Cheatcodes).expectEmit,
+		"expectEmit(address)":                     (*Cheatcodes).expectEmit,
+		"expectEmit(bool,bool,bool,bool)":         (*Cheatcodes).expectEmit,
+		"expectEmit(bool,bool,bool,bool,address)": (*Cheatcodes).expectEmit,
+	}
+	maps.Copy(handlers, assertions())
+
+	m := make(map[[4]byte]cheatcode)
+	for sig, run := range handlers {
+		method, err := abi.MethodFromSignature(sig)
+		if err != nil {
+			panic(err)
+		}
+		m[[4]byte(method.ID)] = cheatcode{method, run}
+	}
+	return m
+}()
+
+// startPrank sets CALLER, and optionally ORIGIN, for the rest of the frame or until stopPrank. It implements prank
+// too: there are no calls in the frame for it to end after.
+func (c *Cheatcodes) startPrank(evm *gevm.EVM, args []any) ([]byte, error) {
+	if c.prank != nil {
+		return nil, errors.New("a prank is already active, stop it first")
+	}
+	p := &prank{sender: args[0].(common.Address), origin: evm.Origin, prevSender: evm.Sender, prevOrigin: evm.Origin}
+	if len(args) > 1 {
+		p.origin = args[1].(common.Address)
+	} else if p.origin == (common.Address{}) {
+		p.origin = evm.Sender // ORIGIN defaults to the sender, keep it
+	}
+	c.prank = p
+	evm.Sender, evm.Origin = p.sender, p.origin
+	return nil, nil
+}
+
+// stopPrank ends the active prank.
+func (c *Cheatcodes) stopPrank(evm *gevm.EVM, _ []any) ([]byte, error) {
+	if c.prank == nil {
+		return nil, errors.New("no prank is active")
+	}
+	c.endPrank(evm)
+	return nil, nil
+}
+
+// endPrank restores CALLER and ORIGIN.
+func (c *Cheatcodes) endPrank(evm *gevm.EVM) {
+	evm.Sender, evm.Origin = c.prank.prevSender, c.prank.prevOrigin
+	c.prank = nil
+}
+
+// deal sets the balance of an account.
+func (c *Cheatcodes) deal(evm *gevm.EVM, args []any) ([]byte, error) {
+	balance, overflow := uint256.FromBig(args[1].(*big.Int))
+	if overflow {
+		return nil, errors.New("balance out of range")
+	}
+	state(evm).GetOrNewAccount(args[0].(common.Address)).Balance = balance
+	return nil, nil
+}
+
+// etch sets the code of an account.
+func (c *Cheatcodes) etch(evm *gevm.EVM, args []any) ([]byte, error) {
+	state(evm).GetOrNewAccount(args[0].(common.Address)).Code = bytes.Clone(args[1].([]byte))
+	return nil, nil
+}
+
+// warp sets the timestamp of the block.
+func (c *Cheatcodes) warp(evm *gevm.EVM, args []any) ([]byte, error) {
+	timestamp := args[0].(*big.Int)
+	if !timestamp.IsInt64() {
+		return nil, errors.New("timestamp out of range")
+	}
+	block(evm).Timestamp = time.Unix(timestamp.Int64(), 0)
+	return nil, nil
+}
+
+// roll sets the number of the block.
+func (c *Cheatcodes) roll(evm *gevm.EVM, args []any) ([]byte, error) {
+	number := args[0].(*big.Int)
+	if !number.IsUint64() {
+		return nil, errors.New("block number out of range")
+	}
+	block(evm).Number = number.Uint64()
+	return nil, nil
+}
+
+// store writes a storage slot of an account. The slots of the executing account are in the EVM's storage.
+func (c *Cheatcodes) store(evm *gevm.EVM, args []any) ([]byte, error) {
+	addr, key, value := args[0].(common.Address), common.Hash(args[1].([32]byte)), common.Hash(args[2].([32]byte))
+	if addr == evm.Address {
+		evm.Storage.Set(key, value)
+		return nil, nil
+	}
+	acc := state(evm).GetOrNewAccount(addr)
+	if value == (common.Hash{}) {
+		delete(acc.Storage, key)
+	} else {
+		acc.Storage[key] = value
+	}
+	return nil, nil
+}
+
+// load reads a storage slot of an account.
+func (c *Cheatcodes) load(evm *gevm.EVM, args []any) ([]byte, error) {
+	addr, key := args[0].(common.Address), common.Hash(args[1].([32]byte))
+	if addr == evm.Address {
+		value, _ := evm.Storage.Get(key)
+		return value.Bytes(), nil
+	}
+	var value common.Hash
+	if acc, ok := evm.State[addr]; ok {
+		value = acc.Storage[key]
+	}
+	return value.Bytes(), nil
+}
+
+// snapshot saves the storage, the world state and the block, returning the id to revert to them.
+func (c *Cheatcodes) snapshot(evm *gevm.EVM, _ []any) ([]byte, error) {
+	c.snapshots = append(c.snapshots, snapshot{
+		storage: evm.Storage.Slots(),
+		state:   state(evm).Copy(),
+		block:   *block(evm),
+	})
+	return word(uint64(len(c.snapshots) - 1)), nil
+}
+
+// revertTo restores a snapshot, returning whether it exists. The snapshot is kept, so it can be restored again.
+func (c *Cheatcodes) revertTo(evm *gevm.EVM, args []any) ([]byte, error) {
+	id := args[0].(*big.Int)
+	if !id.IsUint64() || id.Uint64() >= uint64(len(c.snapshots)) {
+		return word(0), nil
+	}
+	s := c.snapshots[id.Uint64()]
+	for key := range evm.Storage.Slots() {
+		if _, ok := s.storage[key]; !ok {
+			evm.Storage.Set(key, common.Hash{})
+		}
+	}
+	for key, value := range s.storage {
+		evm.Storage.Set(key, value)
+	}
+	st := state(evm)
+	for addr := range st {
+		delete(st, addr)
+	}
+	for addr, acc := range s.state.Copy() {
+		st[addr] = acc
+	}
+	*block(evm) = s.block
+	return word(1), nil
+}
+
+// state returns the world state of the EVM, giving it an empty one if it has none.
+func state(evm *gevm.EVM) gevm.WorldState {
+	if evm.State == nil {
+		evm.State = gevm.NewWorldState()
+	}
+	return evm.State
+}
+
+// block returns the block of the EVM, giving it an empty one if it has none.
+func block(evm *gevm.EVM) *gevm.Block {
+	if evm.Block == nil {
+		evm.Block = &gevm.Block{}
+	}
+	return evm.Block
+}
+
+// word ABI encodes an uint256, or a bool with 0 and 1.
+func word(n uint64) []byte {
+	return common.LeftPadBytes(new(big.Int).SetUint64(n).Bytes(), 32)
+}
@@ -0,0 +1,216 @@
+package cheatcodes
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+var (
+	sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	contract = common.HexToAddress("0x1000")
+	alice    = common.HexToAddress("0xa11ce")
+	bob      = common.HexToAddress("0xb0b")
+)
+
+// calldata encodes a call to a cheatcode.
+func calldata(t *testing.T, sig string, args ...any) []byte {
+	t.Helper()
+	data, err := abi.PackCall(sig, args...)
+	require.NoError(t, err)
+	return data
+}
+
+// run calls a cheatcode, requiring it to succeed.
+func run(t *testing.T, c *Cheatcodes, evm *gevm.EVM, sig string, args ...any) []byte {
+	t.Helper()
+	out, err := c.Run(evm, calldata(t, sig, args...))
+	require.NoError(t, err)
+	return out
+}
+
+// call appends a call to a cheatcode to p, with its output at memory 0.
+func call(t *testing.T, p *gevm.Program, sig string, args ...any) *gevm.Program {
+	data := calldata(t, sig, args...)
+	return p.MstoreBytes(0x100, data).Call(nil, Address, 0, 0x100, len(data), 0, 0x20).Op(gevm.POP)
+}
+
+func newEVM() *gevm.EVM {
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+	evm := gevm.NewEVM(sender, 1_000_000, 0, 1, 30_000_000, nil, nil, block)
+	evm.Verbose = false
+	evm.Address = contract
+	return evm
+}
+
+func TestPrank(t *testing.T) {
+	// CALLER and ORIGIN after each cheatcode call
+	p := gevm.NewProgram().Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "prank(address)", alice).Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "roll(uint256)", big.NewInt(2)).Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "stopPrank()").Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "startPrank(address,address)", alice, bob).Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "roll(uint256)", big.NewInt(3)).Op(gevm.CALLER, gevm.ORIGIN)
+	call(t, p, "stopPrank()").Op(gevm.CALLER, gevm.ORIGIN)
+
+	evm := newEVM()
+	New().Register(&evm.ChainConfig)
+	evm.Code = p.Bytes()
+	res := evm.Execute()
+	require.NoError(t, res.Err)
+
+	want := []common.Address{
+		sender, sender, // before
+		alice, sender, alice, sender, // prank lasts for the rest of the frame
+		sender, sender, // until stopPrank
+		alice, bob, alice, bob, // startPrank with an origin
+		sender, sender,
+	}
+	stack := make([]common.Address, evm.Stack.Len())
+	for i := range stack {
+		stack[i] = common.Address(evm.Stack.Back(len(stack) - 1 - i).Bytes20())
+	}
+	assert.Equal(t, want, stack)
+
+	c := New()
+	run(t, c, evm, "startPrank(address)", alice)
+	_, err := c.Run(evm, calldata(t, "prank(address)", bob))
+	assert.ErrorIs(t, err, gevm.ErrExecutionReverted)
+	run(t, c, evm, "stopPrank()")
+	out, err := c.Run(evm, calldata(t, "stopPrank()"))
+	assert.ErrorIs(t, err, gevm.ErrExecutionReverted)
+	reason, _ := abi.DecodeError(out)
+	assert.Equal(t, "vm.stopPrank(): no prank is active", reason)
+}
+
+func TestState(t *testing.T) {
+	evm := newEVM()
+	c := New()
+	code := []byte{0x60, 0x01}
+	run(t, c, evm, "deal(address,uint256)", alice, big.NewInt(1e18))
+	run(t, c, evm, "etch(address,bytes)", bob, code)
+	run(t, c, evm, "warp(uint256)", big.NewInt(1700000000))
+	run(t, c, evm, "roll(uint256)", big.NewInt(42))
+	run(t, c, evm, "store(address,bytes32,bytes32)", contract, common.HexToHash("0x1"), common.HexToHash("0xaa"))
+	run(t, c, evm, "store(address,bytes32,bytes32)", bob, common.HexToHash("0x2"), common.HexToHash("0xbb"))
+
+	assert.Equal(t, uint256.NewInt(1e18), evm.State[alice].Balance)
+	assert.Equal(t, code, evm.State[bob].Code)
+	assert.Equal(t, int64(1700000000), evm.Block.Timestamp.Unix())
+	assert.Equal(t, uint64(42), evm.Block.Number)
+	value, isWarm := evm.Storage.Get(common.HexToHash("0x1"))
+	assert.Equal(t, common.HexToHash("0xaa"), value)
+	assert.False(t, isWarm)
+	assert.Equal(t, common.HexToHash("0xbb"), evm.State[bob].Storage[common.HexToHash("0x2")])
+
+	assert.Equal(t, common.HexToHash("0xaa").Bytes(), run(t, c, evm, "load(address,bytes32)", contract, common.HexToHash("0x1")))
+	assert.Equal(t, common.HexToHash("0xbb").Bytes(), run(t, c, evm, "load(address,bytes32)", bob, common.HexToHash("0x2")))
+	assert.Equal(t, make([]byte, 32), run(t, c, evm, "load(address,bytes32)", alice, common.HexToHash("0x2")))
+}
+
+func TestStoreMappingSlot(t *testing.T) {
+	// The slot of a mapping entry is a hash, whose low 64 bits must not collide with another slot
+	entry := crypto.Keccak256Hash(common.LeftPadBytes(alice.Bytes(), 32), common.LeftPadBytes([]byte{1}, 32))
+	low := common.BytesToHash(entry[24:])
+	c := New()
+	p := gevm.NewProgram()
+	call(t, p, "store(address,bytes32,bytes32)", contract, entry, common.HexToHash("0x2a"))
+	p.Push(entry).Op(gevm.SLOAD).Push(low).Op(gevm.SLOAD)
+
+	evm := newEVM()
+	c.Register(&evm.ChainConfig)
+	evm.Code = p.Bytes()
+	require.NoError(t, evm.Execute().Err)
+	assert.Equal(t, uint64(0), evm.Stack.Back(0).Uint64())
+	assert.Equal(t, uint64(0x2a), evm.Stack.Back(1).Uint64())
+	assert.Equal(t, common.HexToHash("0x2a").Bytes(), run(t, c, evm, "load(address,bytes32)", contract, entry))
+	assert.Equal(t, map[common.Hash]common.Hash{entry: common.HexToHash("0x2a")}, evm.Storage.Slots())
+}
+
+func TestSnapshot(t *testing.T) {
+	evm := newEVM()
+	c := New()
+	run(t, c, evm, "store(address,bytes32,bytes32)", contract, common.HexToHash("0x1"), common.HexToHash("0xaa"))
+	run(t, c, evm, "deal(address,uint256)", alice, big.NewInt(1))
+	assert.Equal(t, word(0), run(t, c, evm, "snapshot()"))
+
+	run(t, c, evm, "store(address,bytes32,bytes32)", contract, common.HexToHash("0x1"), common.HexToHash("0xbb"))
+	run(t, c, evm, "store(address,bytes32,bytes32)", contract, common.HexToHash("0x2"), common.HexToHash("0xcc"))
+	run(t, c, evm, "deal(address,uint256)", alice, big.NewInt(2))
+	run(t, c, evm, "deal(address,uint256)", bob, big.NewInt(3))
+	run(t, c, evm, "roll(uint256)", big.NewInt(100))
+	assert.Equal(t, word(1), run(t, c, evm, "snapshotState()"))
+
+	for range 2 { // a snapshot can be reverted to more than once
+		assert.Equal(t, word(1), run(t, c, evm, "revertTo(uint256)", big.NewInt(0)))
+		assert.Equal(t, map[common.Hash]common.Hash{common.HexToHash("0x1"): common.HexToHash("0xaa"), common.HexToHash("0x2"): {}}, evm.Storage.Slots())
+		assert.Equal(t, uint256.NewInt(1), evm.State[alice].Balance)
+		assert.False(t, evm.State.Exist(bob))
+		assert.Equal(t, uint64(1), evm.Block.Number)
+		run(t, c, evm, "deal(address,uint256)", alice, big.NewInt(5))
+	}
+
+	assert.Equal(t, word(1), run(t, c, evm, "revertToState(uint256)", big.NewInt(1)))
+	assert.Equal(t, uint64(100), evm.Block.Number)
+	assert.Equal(t, word(0), run(t, c, evm, "revertTo(uint256)", big.NewInt(2)))
+}
+
+func TestRunErrors(t *testing.T) {
+	evm := newEVM()
+	tests := []struct {
+		input []byte
+		want  string
+	}{
+		{nil, "cheatcode without a selector"},
+		{[]byte{0xde, 0xad, 0xbe, 0xef}, "unknown cheatcode with selector 0xdeadbeef"},
+		{calldata(t, "roll(uint256)", big.NewInt(1))[:20], "vm.roll(uint256): abi: cannot marshal in to go type: length insufficient 16 require 32"},
+		{calldata(t, "roll(uint256)", new(big.Int).Lsh(big.NewInt(1), 64)), "vm.roll(uint256): block number out of range"},
+	}
+	for _, tt := range tests {
+		out, err := New().Run(evm, tt.input)
+		assert.ErrorIs(t, err, gevm.ErrExecutionReverted)
+		reason, ok := abi.DecodeError(out)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, reason)
+	}
+}
+
+// The cheatcodes change the world state of ApplyMessage, and their changes are undone if the execution fails.
+func TestApplyMessage(t *testing.T) {
+	p := gevm.NewProgram()
+	call(t, p, "deal(address,uint256)", alice, big.NewInt(7))
+	p.Push(alice).Op(gevm.BALANCE).Push0().Op(gevm.SSTORE)
+	code := p.Bytes()
+
+	for _, fail := range []bool{false, true} {
+		state := gevm.NewWorldState()
+		state.GetOrNewAccount(sender).Balance = uint256.NewInt(1e18)
+		state.GetOrNewAccount(contract).Code = code
+		if fail {
+			state[contract].Code = append(code, byte(gevm.INVALID))
+		}
+		chainConfig := gevm.ChainConfig{ChainID: 1, GasLimit: 30_000_000}
+		New().Register(&chainConfig)
+		block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+		res, err := gevm.ApplyMessage(state, &gevm.Message{From: sender, To: &contract, GasLimit: 1_000_000}, block, chainConfig, nil)
+		require.NoError(t, err)
+
+		if fail {
+			assert.Error(t, res.Err)
+			assert.False(t, state.Exist(alice))
+			continue
+		}
+		require.NoError(t, res.Err)
+		assert.Equal(t, uint256.NewInt(7), state[alice].Balance)
+		assert.Equal(t, common.HexToHash("0x7"), state[contract].Storage[common.Hash{}])
+	}
+}
@@ -0,0 +1,151 @@
+package cheatcodes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// expectedRevert is the revert expected by expectRevert.
+type expectedRevert struct {
+	data    []byte
+	anyData bool
+}
+
+// expectedEmit is the event expected by expectEmit. It's described by the first log emitted after the expectEmit
+// call, and must then be matched by a later log.
+type expectedEmit struct {
+	index       int     // index in the logs of the log describing the event
+	checkTopics [3]bool // whether topics 1 to 3 must match, topic 0 always does
+	checkData   bool
+	emitter     *common.Address // address that must emit the event, any if nil
+}
+
+// expectRevert expects the execution to revert, with any data or the given one.
+// Data matches if it's equal to the revert data, or to the reason of an Error(string) revert.
+func (c *Cheatcodes) expectRevert(_ *gevm.EVM, args []any) ([]byte, error) {
+	if c.revert != nil {
+		return nil, errors.New("already expecting a revert")
+	}
+	c.revert = &expectedRevert{anyData: len(args) == 0}
+	if len(args) > 0 {
+		switch data := args[0].(type) {
+		case []byte:
+			c.revert.data = bytes.Clone(data)
+		case [4]byte:
+			c.revert.data = data[:]
+		}
+	}
+	return nil, nil
+}
+
+// expectEmit expects the next event the code emits to be emitted again later in the execution. The topics 1 to 3 and
+// the data are checked as requested, all of them by default, and the emitter if an address is given.
+func (c *Cheatcodes) expectEmit(evm *gevm.EVM, args []any) ([]byte, error) {
+	e := expectedEmit{index: len(*evm.LogRecord), checkTopics: [3]bool{true, true, true}, checkData: true}
+	if len(args) >= 4 {
+		e.checkTopics = [3]bool{args[0].(bool), args[1].(bool), args[2].(bool)}
+		e.checkData = args[3].(bool)
+	}
+	if len(args) == 1 || len(args) == 5 {
+		emitter := args[len(args)-1].(common.Address)
+		e.emitter = &emitter
+	}
+	c.emits = append(c.emits, e)
+	return nil, nil
+}
+
+// Verify checks the expectations set during the execution of res, returning its error once they are accounted for:
+// nil if the execution reverted as expected, and an error if it didn't revert when expected to, or didn't emit an
+// expected event. The logs describing the expected events are removed from res.Logs.
+func (c *Cheatcodes) Verify(res *gevm.ExecutionResult) error {
+	if c.revert != nil {
+		switch {
+		case res.Err == nil:
+			return errors.New("call did not revert as expected")
+		case !errors.Is(res.Err, gevm.ErrExecutionReverted):
+			return res.Err
+		case !c.revert.anyData && !revertMatches(c.revert.data, res.ReturnData):
+			return fmt.Errorf("Error != expected error: %s != %s", describeRevert(res.ReturnData), describeRevert(c.revert.data))
+		}
+		return nil
+	}
+	if res.Err != nil || len(c.emits) == 0 {
+		return res.Err
+	}
+
+	descriptions := make(map[int]bool)
+	for _, e := range c.emits {
+		if e.index >= len(res.Logs) {
+			return errors.New("expected an event to be emitted after the expectEmit call")
+		}
+		descriptions[e.index] = true
+	}
+	next := 0
+	for _, e := range c.emits {
+		expected, found := res.Logs[e.index], false
+		for i := max(next, e.index+1); i < len(res.Logs) && !found; i++ {
+			if !descriptions[i] && e.matches(expected, res.Logs[i]) {
+				next, found = i+1, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("log != expected log: %s", describeLog(expected))
+		}
+	}
+	logs := make([]*types.Log, 0, len(res.Logs)-len(descriptions))
+	for i, log := range res.Logs {
+		if !descriptions[i] {
+			logs = append(logs, log)
+		}
+	}
+	res.Logs = logs
+	return nil
+}
+
+// matches reports whether log matches the expected one, with the checks of e.
+func (e expectedEmit) matches(expected, log *types.Log) bool {
+	if len(log.Topics) != len(expected.Topics) {
+		return false
+	}
+	for i := range log.Topics {
+		if (i == 0 || e.checkTopics[i-1]) && log.Topics[i] != expected.Topics[i] {
+			return false
+		}
+	}
+	if e.checkData && !bytes.Equal(log.Data, expected.Data) {
+		return false
+	}
+	return e.emitter == nil || log.Address == *e.emitter
+}
+
+// revertMatches reports whether revert data matches the expected data.
+func revertMatches(expected, data []byte) bool {
+	if bytes.Equal(expected, data) {
+		return true
+	}
+	reason, ok := abi.DecodeError(data)
+	return ok && reason == string(expected)
+}
+
+// describeRevert formats revert data as its reason or panic if it has one, and as hex otherwise.
+func describeRevert(data []byte) string {
+	if s, ok := abi.DecodeRevert(data, nil); ok {
+		return s
+	}
+	return hexutil.Encode(data)
+}
+
+// describeLog formats a log as its first topic, or its data if it has no topics.
+func describeLog(log *types.Log) string {
+	if len(log.Topics) == 0 {
+		return "event without topics and data " + hexutil.Encode(log.Data)
+	}
+	return "event " + log.Topics[0].Hex()
+}
@@ -0,0 +1,162 @@
+package cheatcodes
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func TestExpectRevert(t *testing.T) {
+	reverted := func(data []byte) *gevm.ExecutionResult {
+		return &gevm.ExecutionResult{Err: gevm.ErrExecutionReverted, ReturnData: data}
+	}
+	custom := []byte{0x12, 0x34, 0x56, 0x78}
+
+	tests := []struct {
+		name    string
+		sig     string
+		args    []any
+		res     *gevm.ExecutionResult
+		wantErr string
+	}{
+		{"any data", "expectRevert()", nil, reverted(abi.EncodeError("oops")), ""},
+		{"reason", "expectRevert(bytes)", []any{[]byte("oops")}, reverted(abi.EncodeError("oops")), ""},
+		{"encoded reason", "expectRevert(bytes)", []any{abi.EncodeError("oops")}, reverted(abi.EncodeError("oops")), ""},
+		{"selector", "expectRevert(bytes4)", []any{[4]byte(custom)}, reverted(custom), ""},
+		{"no revert", "expectRevert()", nil, &gevm.ExecutionResult{}, "call did not revert as expected"},
+		{"other failure", "expectRevert()", nil, &gevm.ExecutionResult{Err: gevm.ErrInvalidOpcode}, "invalid opcode"},
+		{"other reason", "expectRevert(bytes)", []any{[]byte("oops")}, reverted(abi.EncodeError("nope")),
+			`Error != expected error: Error("nope") != 0x6f6f7073`},
+		{"other selector", "expectRevert(bytes4)", []any{[4]byte(custom)}, reverted(nil), "Error != expected error: 0x != 0x12345678"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			run(t, c, newEVM(), tt.sig, tt.args...)
+			err := c.Verify(tt.res)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	c := New()
+	run(t, c, newEVM(), "expectRevert()")
+	_, err := c.Run(newEVM(), calldata(t, "expectRevert()"))
+	assert.ErrorIs(t, err, gevm.ErrExecutionReverted)
+}
+
+func TestExpectEmit(t *testing.T) {
+	topic := common.HexToHash("0xe1")
+	// log2 emits a LOG2 with the topic, a second topic and a byte of data
+	log2 := func(p *gevm.Program, topic2, data uint64) *gevm.Program {
+		return p.Mstore(0, data).Push(topic2).Push(topic).Push(1).Push(0x1f).Op(gevm.LOG2)
+	}
+
+	tests := []struct {
+		name     string
+		program  func(p *gevm.Program) *gevm.Program
+		wantErr  string
+		wantLogs int
+	}{
+		{
+			name: "Match",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(call(t, p, "expectEmit()"), 2, 0xaa), 2, 0xaa)
+			},
+			wantLogs: 1,
+		},
+		{
+			name: "Match after other logs",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(log2(call(t, p, "expectEmit()"), 2, 0xaa), 3, 0xaa), 2, 0xaa)
+			},
+			wantLogs: 2,
+		},
+		{
+			name: "Unchecked topic and data",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(call(t, p, "expectEmit(bool,bool,bool,bool)", false, true, true, false), 2, 0xaa), 3, 0xbb)
+			},
+			wantLogs: 1,
+		},
+		{
+			name: "Emitter",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(call(t, p, "expectEmit(address)", contract), 2, 0xaa), 2, 0xaa)
+			},
+			wantLogs: 1,
+		},
+		{
+			name: "Two expected events in order",
+			program: func(p *gevm.Program) *gevm.Program {
+				log2(call(t, p, "expectEmit()"), 2, 0xaa)
+				log2(call(t, p, "expectEmit()"), 3, 0xaa)
+				return log2(log2(p, 2, 0xaa), 3, 0xaa)
+			},
+			wantLogs: 2,
+		},
+		{
+			name: "Two expected events out of order",
+			program: func(p *gevm.Program) *gevm.Program {
+				log2(call(t, p, "expectEmit()"), 2, 0xaa)
+				log2(call(t, p, "expectEmit()"), 3, 0xaa)
+				return log2(log2(p, 3, 0xaa), 2, 0xaa)
+			},
+			wantErr: "log != expected log: event " + topic.Hex(),
+		},
+		{
+			name: "Other data",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(call(t, p, "expectEmit()"), 2, 0xaa), 2, 0xbb)
+			},
+			wantErr: "log != expected log: event " + topic.Hex(),
+		},
+		{
+			name: "Other emitter",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(log2(call(t, p, "expectEmit(bool,bool,bool,bool,address)", true, true, true, true, alice), 2, 0xaa), 2, 0xaa)
+			},
+			wantErr: "log != expected log: event " + topic.Hex(),
+		},
+		{
+			name: "Not emitted",
+			program: func(p *gevm.Program) *gevm.Program {
+				return log2(call(t, p, "expectEmit()"), 2, 0xaa)
+			},
+			wantErr: "log != expected log: event " + topic.Hex(),
+		},
+		{
+			name: "No description",
+			program: func(p *gevm.Program) *gevm.Program {
+				return call(t, p, "expectEmit()")
+			},
+			wantErr: "expected an event to be emitted after the expectEmit call",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			evm := newEVM()
+			c.Register(&evm.ChainConfig)
+			evm.Code = tt.program(gevm.NewProgram()).Bytes()
+			res := evm.Execute()
+			require.NoError(t, res.Err)
+
+			err := c.Verify(res)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, res.Logs, tt.wantLogs)
+		})
+	}
+}
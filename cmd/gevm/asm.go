@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// runAsm implements `gevm asm [--bin] [file]`, reading the source from stdin if no file is given.
+func runAsm(args []string) int {
+	fs := flag.NewFlagSet("asm", flag.ExitOnError)
+	bin := fs.Bool("bin", false, "write the bytecode as raw binary instead of hex")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm asm [--bin] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	name := fs.Arg(0)
+	var (
+		src []byte
+		err error
+	)
+	if name == "" || name == "-" {
+		name = "<stdin>"
+		src, err = io.ReadAll(os.Stdin)
+	} else {
+		src, err = os.ReadFile(name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm asm:", err)
+		return 2
+	}
+
+	code, err := gevm.Assemble(string(src))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s:%v\n", name, err)
+		return 1
+	}
+	if *bin {
+		os.Stdout.Write(code)
+	} else {
+		fmt.Println(common.Bytes2Hex(code))
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// runCall implements `gevm call [flags] [code]`: it executes code with ABI encoded calldata and decodes the result.
+func runCall(args []string) int {
+	fs := flag.NewFlagSet("call", flag.ExitOnError)
+	env := addEnvFlags(fs)
+	src := addSourceFlags(fs)
+	abiFile := fs.String("abi", "", "ABI of the contract, or an artifact with an abi field, also decoding the logs (default the ABI of --contract)")
+	method := fs.String("method", "", "method to call, by name or signature (a signature is enough without --abi)")
+	callArgs := fs.String("args", "", "comma separated arguments, arrays as [a,b] and tuples as (a,b)")
+	jsonOutput := fs.Bool("json", false, "print the result as JSON")
+	var sigFiles signatureFiles
+	fs.Var(&sigFiles, "signatures", "signature database resolving the events of the logs (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm call --method <method> [--abi file | --artifacts path [--contract name]] [--args a,b] [flags] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	artifact, _, err := src.load(env, fs)
+	var (
+		contractABI *gethabi.ABI
+		m           *gethabi.Method
+	)
+	if err == nil {
+		contractABI, m, err = loadMethod(*abiFile, artifact, *method)
+	}
+	if err == nil && *env.input != "" {
+		err = errors.New("--input can't be used with --method, the calldata is encoded from --args")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm call:", err)
+		return 2
+	}
+	sigs, err := sigFiles.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm call:", err)
+		return 2
+	}
+	calldata, err := abi.EncodeCall(m, *callArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm call:", err)
+		return 2
+	}
+	*env.input = hexutil.Encode(calldata)
+	evm, err := env.newEVM(fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm call:", err)
+		return 2
+	}
+
+	logs := newConsole(evm, *jsonOutput)
+
+	result := evm.Execute()
+	out := newRunOutput(result)
+	out.Console = logs.Messages
+	if contractABI != nil {
+		out.decodeEvents(abi.NewEvents(contractABI))
+	}
+	switch {
+	case result.Err == nil && len(m.Outputs) > 0:
+		out.Returns, err = abi.Decode(m.Outputs, result.ReturnData)
+		if err != nil {
+			out.Error = fmt.Sprintf("can't decode the output of %s: %v", m.Sig, err)
+		}
+	case errors.Is(result.Err, gevm.ErrExecutionReverted):
+		out.Revert, _ = abi.DecodeRevert(result.ReturnData, contractABI)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+	} else {
+		printRunOutput(out, sigs)
+	}
+	if out.Error != "" {
+		return 1
+	}
+	return 0
+}
+
+// loadMethod returns the ABI in file, or else the contract's, nil if there is none, and the method to call in it.
+func loadMethod(file string, contract *artifacts.Contract, method string) (*gethabi.ABI, *gethabi.Method, error) {
+	if method == "" {
+		return nil, nil, errors.New("--method is required")
+	}
+	if file == "" && contract != nil && contract.ABI != nil {
+		m, err := abi.FindMethod(contract.ABI, method)
+		return contract.ABI, m, err
+	}
+	if file == "" {
+		m, err := abi.MethodFromSignature(method)
+		return nil, m, err
+	}
+	a, err := abi.Load(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := abi.FindMethod(a, method)
+	return a, m, err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Jesserc/gevm/cfg"
+)
+
+// runCFG implements `gevm cfg [--dot] [--codefile file | --artifacts path [--contract name]] [code]`.
+func runCFG(args []string) int {
+	fs := flag.NewFlagSet("cfg", flag.ExitOnError)
+	codeFile := fs.String("codefile", "", "file containing the bytecode, hex encoded or raw binary ('-' for stdin)")
+	src := addSourceFlags(fs)
+	dot := fs.Bool("dot", false, "print the graph in the Graphviz DOT language")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm cfg [--dot] [--codefile file] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	code, err := src.code(fs.Arg(0), *codeFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm cfg:", err)
+		return 2
+	}
+	g := cfg.Build(code)
+	if *dot {
+		if err := g.WriteDOT(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "gevm cfg:", err)
+			return 1
+		}
+		return 0
+	}
+
+	for i, b := range g.Blocks {
+		if i > 0 {
+			fmt.Println()
+		}
+		var notes []string
+		if !b.Reachable {
+			notes = append(notes, "unreachable")
+		}
+		if b.Unresolved {
+			notes = append(notes, "unresolved jump")
+		}
+		for _, pc := range b.Invalid {
+			notes = append(notes, fmt.Sprintf("invalid jump to 0x%04x", pc))
+		}
+		fmt.Printf("block 0x%04x", b.Start)
+		if len(notes) > 0 {
+			fmt.Printf(" (%s)", strings.Join(notes, ", "))
+		}
+		fmt.Println()
+		for _, ins := range b.Instructions {
+			fmt.Println("  ", ins)
+		}
+		for _, e := range b.Succs {
+			fmt.Printf("   -> 0x%04x (%v)\n", e.To, e.Kind)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Jesserc/gevm/coverage"
+)
+
+// runCoverage implements `gevm coverage [flags] [code]`, running code once per input and reporting what was executed.
+func runCoverage(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	env := addEnvFlags(fs)
+	src := addSourceFlags(fs)
+	inputs := fs.String("inputs", "", "file of calldata to run the code with, hex encoded, one per line ('#' starts a comment)")
+	annotate := fs.Bool("annotate", false, "print the disassembly annotated with the execution counts")
+	lcov := fs.String("lcov", "", "write the coverage of the Solidity source to a file in the LCOV format (needs --artifacts)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm coverage [flags] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	_, contract, err := src.load(env, fs)
+	if err == nil && *lcov != "" && contract == nil {
+		err = fmt.Errorf("--lcov needs the source map of a contract of --artifacts")
+	}
+	calldata := []string{*env.input}
+	if err == nil && *inputs != "" {
+		calldata, err = readInputs(*inputs)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm coverage:", err)
+		return 2
+	}
+
+	var c *coverage.Coverage
+	failed := 0
+	for _, input := range calldata {
+		*env.input = input
+		evm, err := env.newEVM(fs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gevm coverage:", err)
+			return 2
+		}
+		if c == nil {
+			c = coverage.New(evm.Code)
+		}
+		evm.Tracer = c
+		if evm.Execute().Failed() {
+			failed++
+		}
+	}
+
+	if *annotate {
+		c.WriteAnnotated(os.Stdout)
+		fmt.Println()
+	}
+	s := c.Summary()
+	fmt.Printf("Runs:         %d (%d failed)\n", len(calldata), failed)
+	fmt.Printf("Instructions: %.1f%% (%d/%d)\n", s.InstructionPercent(), s.InstructionsHit, s.Instructions)
+	fmt.Printf("Branches:     %.1f%% (%d/%d)\n", s.BranchPercent(), s.BranchesHit, s.Branches)
+
+	if *lcov != "" {
+		f, err := os.Create(*lcov)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gevm coverage:", err)
+			return 1
+		}
+		defer f.Close()
+		if err := c.WriteLCOV(f, contract.Map); err != nil {
+			fmt.Fprintln(os.Stderr, "gevm coverage:", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// readInputs reads a file of calldata, one per line, skipping blank lines and comments.
+func readInputs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var inputs []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			inputs = append(inputs, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no inputs in %s", path)
+	}
+	return inputs, nil
+}
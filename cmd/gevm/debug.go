@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/console"
+	"github.com/Jesserc/gevm/debugger"
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+const debugHelp = `Commands:
+  s, step [n]                  execute n instructions (default 1)
+  n, next                      step over the current instruction (runs internal function calls to completion)
+  c, continue                  run until a breakpoint, a watchpoint or the end
+  in                           step to the next source line, entering function calls (needs --artifacts)
+  over                         step to the next source line of the current function (needs --artifacts)
+  out                          run until the current function returns (needs --artifacts)
+  list [n]                     print n source lines around the current one (default 5)
+  back [n]                     go back n steps in the recorded history (default 1)
+  goto <n>                     go to step n, back in the history or forward by running
+  lastwrite <slot>             go back to the last step that wrote a storage slot
+  b, break <pc>                break before the instruction at pc
+  b, break <OPCODE>            break before every instruction with that opcode
+  b, break slot <slot>         break before every SSTORE to a storage slot
+  w, watch <offset> <size>     break after an instruction changes a range of memory
+  d, delete <id>               delete a breakpoint or watchpoint
+  i, info                      list breakpoints and watchpoints
+  stack                        print the stack, top first
+  memory [offset size]         print memory
+  storage                      print storage
+  transient                    print transient storage
+  logs                         print the emitted logs, decoded with --abi
+  gas                          print the remaining gas and refund
+  where                        print the current instruction
+  set stack <n> <value>        replace the n-th stack item from the top
+  set storage <slot> <value>   write a storage slot
+  q, quit                      kill the execution and exit
+  h, help                      print this help
+
+While looking at a past step, step and next move forward in the history and continue returns to the current step.
+`
+
+// runDebug implements `gevm debug [flags] [code]`, an interactive debugger reading commands from stdin.
+func runDebug(args []string) int {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	env := addEnvFlags(fs)
+	src := addSourceFlags(fs)
+	var abis abiFiles
+	fs.Var(&abis, "abi", "ABI, or artifact with an abi field, to decode the logs with (repeatable)")
+	var sigFiles signatureFiles
+	fs.Var(&sigFiles, "signatures", "signature database resolving the events of the logs (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm debug [flags] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	artifact, contract, err := src.load(env, fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm debug:", err)
+		return 2
+	}
+	events, err := abis.events(artifact)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm debug:", err)
+		return 2
+	}
+	sigs, err := sigFiles.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm debug:", err)
+		return 2
+	}
+	evm, err := env.newEVM(fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm debug:", err)
+		return 2
+	}
+	console.New(os.Stdout).Register(&evm.ChainConfig)
+	d := debugger.New(evm)
+	defer d.Close()
+	if contract != nil {
+		d.SetSourceMap(contract.Map)
+	}
+	debugLoop(d, events, sigs, os.Stdin, os.Stdout)
+	return 0
+}
+
+// debugSession is the state of the interactive debugger.
+type debugSession struct {
+	d      *debugger.Debugger
+	view   *debugger.Snapshot // past state being looked at, nil for the current state
+	events abi.Events         // decoding the logs, nil without ABIs
+	sigs   *abi.Signatures    // resolving the events of the logs, nil without signature databases
+	out    io.Writer
+}
+
+// debugLoop reads commands from in until quit or end of input, writing their output to out.
+func debugLoop(d *debugger.Debugger, events abi.Events, sigs *abi.Signatures, in io.Reader, out io.Writer) {
+	s := &debugSession{d: d, events: events, sigs: sigs, out: out}
+	s.printPause()
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "(gevm) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "q" || fields[0] == "quit" {
+			return
+		}
+		if err := s.command(fields); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func (s *debugSession) command(fields []string) error {
+	d, out := s.d, s.out
+	cmd, args := fields[0], fields[1:]
+	state := s.state()
+	switch cmd {
+	case "s", "step", "n", "next":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+		}
+		if s.view != nil {
+			return s.travel(min(s.view.Step+n, d.Steps()))
+		}
+		for i := 0; i < n && !d.Finished(); i++ {
+			var err error
+			if cmd == "s" || cmd == "step" {
+				err = d.Step()
+			} else {
+				err = d.Next()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		s.printPause()
+	case "c", "continue":
+		if s.view != nil {
+			return s.travel(d.Steps())
+		}
+		if err := d.Continue(); err != nil {
+			return err
+		}
+		s.printPause()
+	case "in", "over", "out":
+		if s.view != nil {
+			return fmt.Errorf("source stepping is only possible from the current step, continue to return to it")
+		}
+		var err error
+		switch cmd {
+		case "in":
+			err = d.StepIn()
+		case "over":
+			err = d.StepOver()
+		default:
+			err = d.StepOut()
+		}
+		if err != nil {
+			return err
+		}
+		s.printPause()
+	case "list":
+		n := 5
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid line count %q", args[0])
+			}
+		}
+		loc, ok := s.location()
+		if !ok || loc.Line == 0 {
+			return fmt.Errorf("no source line for the current instruction")
+		}
+		first := max(loc.Line-n/2, 1)
+		for i := first; i < first+n && i <= loc.Source.Lines(); i++ {
+			marker := " "
+			if i == loc.Line {
+				marker = ">"
+			}
+			fmt.Fprintf(out, "%s%5d | %s\n", marker, i, loc.Source.Line(i))
+		}
+	case "back":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+		}
+		return s.travel(max(state.Step-n, 0))
+	case "goto":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: goto <n>")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid step %q", args[0])
+		}
+		if n <= d.Steps() {
+			return s.travel(n)
+		}
+		s.view = nil
+		if err := d.RunTo(n); err != nil {
+			return err
+		}
+		s.printPause()
+	case "lastwrite":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: lastwrite <slot>")
+		}
+		slot, err := parseUint256(args[0])
+		if err != nil {
+			return err
+		}
+		n, ok := d.Recorder().LastWrite(slot.Bytes32(), state.Step)
+		if !ok {
+			return fmt.Errorf("slot %s was not written", slot.Hex())
+		}
+		w := d.Recorder().Steps()[n].Storage
+		fmt.Fprintf(out, "slot %s written at step %d: %s -> %s\n", slot.Hex(), n, w.Old.Hex(), w.New.Hex())
+		return s.travel(n)
+	case "b", "break":
+		return debugBreak(d, args, out)
+	case "w", "watch":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: watch <offset> <size>")
+		}
+		offset, err := parseUint64(args[0])
+		if err != nil {
+			return err
+		}
+		size, err := parseUint64(args[1])
+		if err != nil {
+			return err
+		}
+		w, err := d.Watch(offset, size)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "watchpoint", w)
+	case "d", "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete <id>")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || !d.Delete(id) {
+			return fmt.Errorf("no breakpoint or watchpoint %s", args[0])
+		}
+	case "i", "info":
+		for _, b := range d.Breakpoints() {
+			fmt.Fprintln(out, "breakpoint", b)
+		}
+		for _, w := range d.Watchpoints() {
+			fmt.Fprintln(out, "watchpoint", w)
+		}
+	case "stack":
+		for i := len(state.Stack) - 1; i >= 0; i-- {
+			fmt.Fprintf(out, "%4d: %s\n", len(state.Stack)-1-i, state.Stack[i].Hex())
+		}
+	case "memory":
+		offset, size := uint64(0), uint64(len(state.Memory))
+		if len(args) == 2 {
+			var err error
+			if offset, err = parseUint64(args[0]); err != nil {
+				return err
+			}
+			if size, err = parseUint64(args[1]); err != nil {
+				return err
+			}
+			if offset > gevm.MaxMemorySize || size > gevm.MaxMemorySize {
+				return fmt.Errorf("memory range out of bounds")
+			}
+		}
+		printMemory(out, state.Memory, offset, size)
+	case "storage":
+		printSlots(out, state.Storage)
+	case "transient":
+		printSlots(out, state.Transient)
+	case "logs":
+		for i := range *d.EVM().LogRecord {
+			fmt.Fprintln(out, formatLog(s.events, s.sigs, &(*d.EVM().LogRecord)[i]))
+		}
+	case "gas":
+		fmt.Fprintf(out, "gas left: %d, refund: %d\n", state.Gas, state.Refund)
+	case "where":
+		s.printPause()
+	case "set":
+		if s.view != nil {
+			return fmt.Errorf("a past state can't be changed, continue to return to the current step")
+		}
+		return debugSet(d, args)
+	case "h", "help":
+		fmt.Fprint(out, debugHelp)
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// state returns the state being looked at.
+func (s *debugSession) state() *debugger.Snapshot {
+	if s.view != nil {
+		return s.view
+	}
+	return s.d.State()
+}
+
+// travel looks at the state before step n, which is the current state if n is the current step.
+func (s *debugSession) travel(n int) error {
+	if n == s.d.Steps() {
+		s.view = nil
+		s.printPause()
+		return nil
+	}
+	view, err := s.d.StateAt(n)
+	if err != nil {
+		return err
+	}
+	s.view = view
+	s.printPause()
+	return nil
+}
+
+func debugBreak(d *debugger.Debugger, args []string, out io.Writer) error {
+	switch {
+	case len(args) == 2 && args[0] == "slot":
+		slot, err := parseUint256(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "breakpoint", d.Break(debugger.BreakSlot, slot))
+	case len(args) == 1:
+		for i := 0; i < 256; i++ {
+			if strings.EqualFold(gevm.Opcode(i).String(), args[0]) {
+				fmt.Fprintln(out, "breakpoint", d.Break(debugger.BreakOpcode, uint256.NewInt(uint64(i))))
+				return nil
+			}
+		}
+		pc, err := parseUint256(args[0])
+		if err != nil {
+			return fmt.Errorf("%q is neither a pc nor an opcode", args[0])
+		}
+		fmt.Fprintln(out, "breakpoint", d.Break(debugger.BreakPC, pc))
+	default:
+		return fmt.Errorf("usage: break <pc> | break <OPCODE> | break slot <slot>")
+	}
+	return nil
+}
+
+func debugSet(d *debugger.Debugger, args []string) error {
+	if len(args) != 3 || (args[0] != "stack" && args[0] != "storage") {
+		return fmt.Errorf("usage: set stack <n> <value> | set storage <slot> <value>")
+	}
+	value, err := parseUint256(args[2])
+	if err != nil {
+		return err
+	}
+	if args[0] == "stack" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid stack index %q", args[1])
+		}
+		return d.SetStack(n, value)
+	}
+	slot, err := parseUint256(args[1])
+	if err != nil {
+		return err
+	}
+	d.SetStorage(slot.Bytes32(), common.Hash(value.Bytes32()))
+	return nil
+}
+
+// printPause prints the step being looked at and the instruction it's about to execute, or the result once finished.
+func (s *debugSession) printPause() {
+	d, out := s.d, s.out
+	if s.view == nil && d.Finished() {
+		fmt.Fprintf(out, "execution finished after %d steps\n", d.Steps())
+		res := newRunOutput(d.Result())
+		fmt.Fprintln(out, "output:", hexutil.Encode(res.Output))
+		fmt.Fprintln(out, "gas used:", res.GasUsed)
+		if res.Error != "" {
+			fmt.Fprintln(out, "error:", res.Error)
+		}
+		return
+	}
+
+	state, reason := s.state(), d.Reason()
+	if s.view != nil {
+		reason = fmt.Sprintf("history, current step is %d", d.Steps())
+	}
+	code := d.EVM().Code
+	ins := gevm.Instruction{PC: state.PC, Op: state.Op}
+	if size := state.Op.PushSize(); size > 0 {
+		end := min(int(state.PC)+1+size, len(code))
+		ins.Immediate = code[state.PC+1 : end]
+		ins.Truncated = len(ins.Immediate) < size
+	}
+	fmt.Fprintf(out, "[%s] step %d, gas %d\n%v\n", reason, state.Step, state.Gas, ins)
+	if loc, ok := s.location(); ok {
+		fmt.Fprintf(out, "%v  %s\n", loc, strings.TrimSpace(loc.Source.Line(loc.Line)))
+	}
+}
+
+// location returns the source location of the instruction being looked at.
+func (s *debugSession) location() (sourcemap.Location, bool) {
+	m := s.d.SourceMap()
+	if m == nil {
+		return sourcemap.Location{}, false
+	}
+	return m.Lookup(s.state().PC)
+}
+
+func printMemory(out io.Writer, data []byte, offset, size uint64) {
+	for i := offset; i < offset+size; i += 32 {
+		row := make([]byte, min(32, offset+size-i))
+		if i < uint64(len(data)) {
+			copy(row, data[i:])
+		}
+		fmt.Fprintf(out, "0x%04x: %x\n", i, row)
+	}
+}
+
+func printSlots(out io.Writer, slots map[common.Hash]common.Hash) {
+	keys := make([]common.Hash, 0, len(slots))
+	for k := range slots {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	for _, k := range keys {
+		fmt.Fprintf(out, "%s: %s\n", new(uint256.Int).SetBytes32(k[:]).Hex(), slots[k].Hex())
+	}
+}
+
+// parseUint256 parses a decimal or 0x prefixed hex number.
+func parseUint256(s string) (*uint256.Int, error) {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	v, overflow := uint256.FromBig(n)
+	if overflow {
+		return nil, fmt.Errorf("%s does not fit in 256 bits", s)
+	}
+	return v, nil
+}
+
+func parseUint64(s string) (uint64, error) {
+	v, err := parseUint256(s)
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsUint64() {
+		return 0, fmt.Errorf("%s does not fit in 64 bits", s)
+	}
+	return v.Uint64(), nil
+}
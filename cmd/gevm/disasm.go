@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// runDisasm implements `gevm disasm [--codefile file] [code]`.
+func runDisasm(args []string) int {
+	fs := flag.NewFlagSet("disasm", flag.ExitOnError)
+	codeFile := fs.String("codefile", "", "file containing the bytecode, hex encoded or raw binary ('-' for stdin)")
+	src := addSourceFlags(fs)
+	var sigFiles signatureFiles
+	fs.Var(&sigFiles, "signatures", "signature database resolving the selectors and topics pushed (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm disasm [--codefile file | --artifacts path [--contract name]] [--signatures file] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	code, err := src.code(fs.Arg(0), *codeFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm disasm:", err)
+		return 2
+	}
+	sigs, err := sigFiles.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm disasm:", err)
+		return 2
+	}
+	for _, ins := range gevm.Disassemble(code) {
+		if sig, ok := pushedSignature(sigs, ins); ok {
+			fmt.Printf("%v  ; %s\n", ins, sig)
+		} else {
+			fmt.Println(ins)
+		}
+	}
+	if _, metadata := gevm.SplitMetadata(code); metadata != nil {
+		fmt.Printf("metadata (%d bytes): %s\n", len(metadata), hexutil.Encode(metadata))
+		if version := solcVersion(metadata); version != "" {
+			fmt.Println("compiler: solc", version)
+		}
+	}
+	return 0
+}
+
+// pushedSignature returns the signature of the function selector a PUSH4 pushes, or of the event topic a PUSH32 pushes.
+func pushedSignature(sigs *abi.Signatures, ins gevm.Instruction) (string, bool) {
+	switch {
+	case ins.Truncated:
+		return "", false
+	case ins.Op == gevm.PUSH4:
+		return sigs.Function(ins.Immediate)
+	case ins.Op == gevm.PUSH32:
+		return sigs.Event(common.Hash(ins.Immediate))
+	}
+	return "", false
+}
+
+// solcVersion extracts the compiler version from Solidity metadata, stored under the "solc" key as 3 bytes.
+func solcVersion(metadata []byte) string {
+	key := []byte("\x64solc\x43")
+	i := bytes.Index(metadata, key)
+	if i < 0 || i+len(key)+3 > len(metadata) {
+		return ""
+	}
+	v := metadata[i+len(key):]
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
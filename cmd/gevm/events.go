@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// abiFiles is a repeatable flag of ABIs, or artifacts with an abi field, whose events decode the logs.
+type abiFiles []string
+
+func (f *abiFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *abiFiles) Set(file string) error {
+	*f = append(*f, file)
+	return nil
+}
+
+// events loads the events of the ABIs and of the contract, which may be nil, or returns nil if there are none.
+func (f *abiFiles) events(contract *artifacts.Contract) (abi.Events, error) {
+	var events abi.Events
+	if contract != nil && contract.ABI != nil {
+		events = abi.NewEvents(contract.ABI)
+	}
+	if len(*f) == 0 {
+		return events, nil
+	}
+	if events == nil {
+		events = make(abi.Events)
+	}
+	for _, file := range *f {
+		a, err := abi.Load(file)
+		if err != nil {
+			return nil, err
+		}
+		for id, ev := range abi.NewEvents(a) {
+			events[id] = ev
+		}
+	}
+	return events, nil
+}
+
+// signatureFiles is a repeatable flag of signature databases, resolving selectors and topics the ABIs don't cover.
+// See abi.Signatures.Parse for the formats.
+type signatureFiles []string
+
+func (f *signatureFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *signatureFiles) Set(file string) error {
+	*f = append(*f, file)
+	return nil
+}
+
+// load loads the signature databases, nil if there are none.
+func (f *signatureFiles) load() (*abi.Signatures, error) {
+	if len(*f) == 0 {
+		return nil, nil
+	}
+	sigs := abi.NewSignatures()
+	for _, file := range *f {
+		if err := sigs.Load(file); err != nil {
+			return nil, err
+		}
+	}
+	return sigs, nil
+}
+
+// decodeEvents decodes the logs of out, leaving nil the events of logs no ABI event matches.
+func (out *runOutput) decodeEvents(events abi.Events) {
+	if len(events) == 0 || len(out.Logs) == 0 {
+		return
+	}
+	out.Events = make([]*abi.Event, len(out.Logs))
+	for i, log := range out.Logs {
+		out.Events[i], _ = events.Decode(log.Topics, log.Data)
+	}
+}
+
+// eventTracer prints the logs the code emits as it runs, decoded with the events or resolved with the signatures.
+type eventTracer struct {
+	events abi.Events
+	sigs   *abi.Signatures
+	out    io.Writer
+	logs   int // logs already printed
+}
+
+func (t *eventTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	t.flush(evm)
+}
+
+func (t *eventTracer) CaptureEnd(evm *gevm.EVM) {
+	t.flush(evm)
+}
+
+func (t *eventTracer) flush(evm *gevm.EVM) {
+	for ; t.logs < len(*evm.LogRecord); t.logs++ {
+		fmt.Fprintln(t.out, "event", formatLog(t.events, t.sigs, &(*evm.LogRecord)[t.logs]))
+	}
+}
+
+// formatLog formats a log as its decoded event if one matches, or as its raw topics and data, after the signature of
+// its event if it is known.
+func formatLog(events abi.Events, sigs *abi.Signatures, log *gevm.Log) string {
+	if ev, err := events.Decode(log.Topics, log.Data); err == nil {
+		return fmt.Sprintf("%d: %s %v", log.Index, log.Address.Hex(), ev)
+	}
+	s := fmt.Sprintf("%d: %s", log.Index, log.Address.Hex())
+	if sig, ok := eventSignature(sigs, log.Topics); ok {
+		s += " " + sig
+	}
+	return s + fmt.Sprintf(" topics %v data %v", log.Topics, log.Data)
+}
+
+// eventSignature returns the signature of the event the first topic of a log identifies.
+func eventSignature(sigs *abi.Signatures, topics []common.Hash) (string, bool) {
+	if len(topics) == 0 {
+		return "", false
+	}
+	return sigs.Event(topics[0])
+}
+
+// tracers notifies several tracers in order.
+type tracers []gevm.Tracer
+
+func (ts tracers) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	for _, t := range ts {
+		t.CaptureState(evm, pc, op)
+	}
+}
+
+func (ts tracers) CaptureEnd(evm *gevm.EVM) {
+	for _, t := range ts {
+		t.CaptureEnd(evm)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/node"
+)
+
+// runNode implements `gevm node [flags]`, a development node serving JSON-RPC over HTTP.
+func runNode(args []string) int {
+	fs := flag.NewFlagSet("node", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8545", "address to listen on")
+	chainID := fs.Uint64("chainid", 1337, "chain ID")
+	accounts := fs.Int("accounts", 10, "number of funded development accounts")
+	balance := fs.Uint64("balance", 10_000, "balance of the development accounts, in ether")
+	baseFee := fs.Uint64("basefee", 0, "base fee of every block, in wei")
+	gasLimit := fs.Uint64("gaslimit", defaultGasLimit, "block gas limit")
+	forkName := fs.String("fork", string(gevm.Cancun), "fork rules to execute with")
+	var sigFiles signatureFiles
+	fs.Var(&sigFiles, "signatures", "signature database resolving the functions called and the events logged (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm node [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	fork, err := gevm.ParseFork(*forkName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm node:", err)
+		return 2
+	}
+	sigs, err := sigFiles.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm node:", err)
+		return 2
+	}
+	alloc := gevm.NewWorldState()
+	wei := new(uint256.Int).Mul(uint256.NewInt(*balance), uint256.NewInt(1e18))
+	fmt.Println("Accounts:")
+	for i := 0; i < *accounts; i++ {
+		key := devKey(i)
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		alloc.GetOrNewAccount(address).Balance = wei.Clone()
+		fmt.Printf("  %s  private key %x\n", address.Hex(), crypto.FromECDSA(key))
+	}
+
+	n := node.New(node.Config{
+		ChainID:  *chainID,
+		GasLimit: *gasLimit,
+		BaseFee:  *baseFee,
+		Fork:     fork,
+		Alloc:    alloc,
+		Mined: func(b *node.Block) {
+			for i, r := range b.Receipts {
+				status := "ok"
+				if r.Status == types.ReceiptStatusFailed {
+					status = "failed"
+				}
+				fmt.Printf("block %d  tx %s", b.Number(), r.TxHash.Hex())
+				if tx := b.Transactions[i]; tx.To() != nil {
+					if sig, ok := sigs.Function(tx.Data()); ok {
+						fmt.Printf("  %s", sig)
+					}
+				}
+				fmt.Printf("  gas used %d  %s", r.GasUsed, status)
+				if r.ContractAddress != (common.Address{}) {
+					fmt.Printf("  contract %s", r.ContractAddress.Hex())
+				}
+				fmt.Println()
+				for _, log := range r.Logs {
+					if sig, ok := eventSignature(sigs, log.Topics); ok {
+						fmt.Printf("  log %d  %s  %s\n", log.Index, log.Address.Hex(), sig)
+					}
+				}
+			}
+		},
+	})
+	fmt.Printf("\nListening on http://%s (chain ID %d)\n", *addr, *chainID)
+	if err := http.ListenAndServe(*addr, n); err != nil {
+		fmt.Fprintln(os.Stderr, "gevm node:", err)
+		return 1
+	}
+	return 0
+}
+
+// devKey derives the private key of the i-th development account. The keys are public, never use them on a real network.
+func devKey(i int) *ecdsa.PrivateKey {
+	seed := binary.BigEndian.AppendUint64([]byte("gevm development account"), uint64(i))
+	key, err := crypto.ToECDSA(crypto.Keccak256(seed))
+	if err != nil {
+		panic(err) // a hash is a valid key but with negligible probability
+	}
+	return key
+}
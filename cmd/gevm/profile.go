@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Jesserc/gevm/profiler"
+)
+
+// runProfile implements `gevm profile [flags] [code]`, printing where an execution spends its gas.
+func runProfile(args []string) int {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	env := addEnvFlags(fs)
+	src := addSourceFlags(fs)
+	top := fs.Int("top", 20, "number of instructions to list, most expensive first")
+	pprof := fs.String("pprof", "", "also write the profile to a file in the pprof format")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm profile [flags] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	_, _, err := src.load(env, fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm profile:", err)
+		return 2
+	}
+	evm, err := env.newEVM(fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm profile:", err)
+		return 2
+	}
+	p := profiler.New()
+	evm.Tracer = p
+	result := evm.Execute()
+	if result.Err != nil {
+		fmt.Printf("Error: %v\n\n", result.Err)
+	}
+
+	if err := p.WriteText(os.Stdout, *top); err != nil {
+		fmt.Fprintln(os.Stderr, "gevm profile:", err)
+		return 1
+	}
+	if *pprof != "" {
+		f, err := os.Create(*pprof)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gevm profile:", err)
+			return 1
+		}
+		defer f.Close()
+		if err := p.WritePprof(f); err != nil {
+			fmt.Fprintln(os.Stderr, "gevm profile:", err)
+			return 1
+		}
+	}
+	return 0
+}
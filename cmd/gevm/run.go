@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/console"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Defaults of the run command, the sender and receiver spell "sender" and "receiver" like geth's evm tool.
+var (
+	defaultSender   = common.BytesToAddress([]byte("sender"))
+	defaultReceiver = common.BytesToAddress([]byte("receiver"))
+)
+
+const (
+	defaultChainID  = 1
+	defaultGasLimit = 30_000_000 // block gas limit, raised to the execution gas if that's higher
+)
+
+// runOutput is the result of the run command, as printed with --json.
+type runOutput struct {
+	Output  hexutil.Bytes `json:"output"`
+	GasUsed uint64        `json:"gasUsed"`
+	Refund  uint64        `json:"refund"`
+	Logs    []*types.Log  `json:"logs"`
+	Events  []*abi.Event  `json:"events,omitempty"`  // decoded logs, with ABIs, null where no event matches
+	Returns []abi.Value   `json:"returns,omitempty"` // decoded output, with an ABI
+	Error   string        `json:"error,omitempty"`
+	Revert  string        `json:"revert,omitempty"` // decoded revert data
+	Source  string        `json:"source,omitempty"` // source location execution failed at, with --solc
+
+	Console []console.Message `json:"console,omitempty"` // messages of console.log
+}
+
+// envFlags are the flags describing the code to execute and its environment, shared by the commands running code.
+type envFlags struct {
+	code, codeFile, input      *string
+	gas, value                 *uint64
+	sender, receiver, coinbase *string
+	number, timestamp, baseFee *uint64
+	fork                       *string
+}
+
+func addEnvFlags(fs *flag.FlagSet) *envFlags {
+	return &envFlags{
+		code:      fs.String("code", "", "bytecode to execute, hex encoded (can also be passed as the only argument)"),
+		codeFile:  fs.String("codefile", "", "file containing the bytecode, hex encoded or raw binary ('-' for stdin)"),
+		input:     fs.String("input", "", "calldata, hex encoded"),
+		gas:       fs.Uint64("gas", 10_000_000, "gas available to the execution"),
+		value:     fs.Uint64("value", 0, "value sent with the call, in wei"),
+		sender:    fs.String("sender", defaultSender.Hex(), "address of the caller"),
+		receiver:  fs.String("receiver", defaultReceiver.Hex(), "address of the account executing the code"),
+		number:    fs.Uint64("number", 0, "block number"),
+		timestamp: fs.Uint64("timestamp", 0, "block timestamp in seconds (default current time)"),
+		baseFee:   fs.Uint64("basefee", 0, "block base fee, also used as the gas price"),
+		coinbase:  fs.String("coinbase", common.Address{}.Hex(), "block coinbase"),
+		fork:      fs.String("fork", string(gevm.Cancun), "fork rules to execute with"),
+	}
+}
+
+// newEVM creates the EVM described by the flags, the code being either a flag or the only argument of fs.
+func (f *envFlags) newEVM(fs *flag.FlagSet) (*gevm.EVM, error) {
+	code := *f.code
+	if fs.NArg() > 1 || (fs.NArg() == 1 && code != "") {
+		return nil, errors.New("the code must be given only once")
+	}
+	if fs.NArg() == 1 {
+		code = fs.Arg(0)
+	}
+
+	bytecode, err := loadCode(code, *f.codeFile)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := decodeHex(*f.input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input: %v", err)
+	}
+	var addrs [3]common.Address
+	for i, s := range []string{*f.sender, *f.receiver, *f.coinbase} {
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		addrs[i] = common.HexToAddress(s)
+	}
+	fork, err := gevm.ParseFork(*f.fork)
+	if err != nil {
+		return nil, err
+	}
+	blockTime := time.Now()
+	if *f.timestamp != 0 {
+		blockTime = time.Unix(int64(*f.timestamp), 0)
+	}
+
+	block := gevm.NewBlock(addrs[2], *f.baseFee, *f.number, 0, *f.baseFee, blockTime)
+	evm := gevm.NewEVM(addrs[0], *f.gas, *f.value, defaultChainID, max(*f.gas, defaultGasLimit), bytecode, calldata, block)
+	evm.Address = addrs[1]
+	evm.Fork = fork
+	evm.Verbose = false
+	return evm, nil
+}
+
+// runCode implements `gevm run [flags] [code]`. It exits with 1 if execution reverted or failed.
+func runCode(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	env := addEnvFlags(fs)
+	jsonOutput := fs.Bool("json", false, "print the result as JSON")
+	trace := fs.Bool("trace", false, "print the EVM state after every step")
+	var abis abiFiles
+	fs.Var(&abis, "abi", "ABI, or artifact with an abi field, to decode the logs with (repeatable)")
+	var sigFiles signatureFiles
+	fs.Var(&sigFiles, "signatures", "signature database resolving the events of the logs (repeatable)")
+	src := addSourceFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm run [flags] [code]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	artifact, contract, err := src.load(env, fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm run:", err)
+		return 2
+	}
+	events, err := abis.events(artifact)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm run:", err)
+		return 2
+	}
+	sigs, err := sigFiles.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm run:", err)
+		return 2
+	}
+	evm, err := env.newEVM(fs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm run:", err)
+		return 2
+	}
+	evm.Verbose = *trace
+	var (
+		tracer *sourceTracer
+		ts     tracers
+	)
+	if contract != nil {
+		tracer = &sourceTracer{m: contract.Map}
+		if *trace {
+			tracer.out = os.Stdout
+		}
+		ts = append(ts, tracer)
+	}
+	if (events != nil || sigs != nil) && *trace {
+		ts = append(ts, &eventTracer{events: events, sigs: sigs, out: os.Stdout})
+	}
+	if len(ts) > 0 {
+		evm.Tracer = ts
+	}
+	logs := newConsole(evm, *jsonOutput)
+
+	result := evm.Execute()
+	out := newRunOutput(result)
+	out.Console = logs.Messages
+	out.decodeEvents(events)
+	if tracer != nil && result.Failed() {
+		out.Source = tracer.location()
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+	} else {
+		printRunOutput(out, sigs)
+	}
+
+	if result.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// newConsole registers console.log on evm. Its messages are printed as they are logged, unless the output is JSON.
+func newConsole(evm *gevm.EVM, jsonOutput bool) *console.Console {
+	c := console.New(os.Stdout)
+	if jsonOutput {
+		c.Output = nil
+	}
+	c.Register(&evm.ChainConfig)
+	return c
+}
+
+func newRunOutput(result *gevm.ExecutionResult) runOutput {
+	out := runOutput{
+		Output:  result.ReturnData,
+		GasUsed: result.UsedGas,
+		Refund:  result.Refund,
+		Logs:    result.Logs,
+	}
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+	if errors.Is(result.Err, gevm.ErrExecutionReverted) {
+		out.Revert, _ = abi.DecodeRevert(result.ReturnData, nil)
+	}
+	if out.Logs == nil {
+		out.Logs = []*types.Log{}
+	}
+	return out
+}
+
+// printRunOutput prints the result of an execution, with the signatures of the events of the logs ABIs didn't decode.
+func printRunOutput(out runOutput, sigs *abi.Signatures) {
+	fmt.Println("Output:  ", hexutil.Encode(out.Output))
+	for _, v := range out.Returns {
+		fmt.Println("  ", v)
+	}
+	fmt.Println("Gas used:", out.GasUsed)
+	fmt.Println("Refund:  ", out.Refund)
+	for i, log := range out.Logs {
+		fmt.Printf("Log %d:    %s topics %v data %s\n", i, log.Address.Hex(), log.Topics, hexutil.Encode(log.Data))
+		if i < len(out.Events) && out.Events[i] != nil {
+			fmt.Println("  ", out.Events[i])
+		} else if sig, ok := eventSignature(sigs, log.Topics); ok {
+			fmt.Println("  ", sig)
+		}
+	}
+	if out.Error != "" {
+		fmt.Println("Error:   ", out.Error)
+	}
+	if out.Revert != "" {
+		fmt.Println("Revert:  ", out.Revert)
+	}
+	if out.Source != "" {
+		fmt.Println("Source:  ", out.Source)
+	}
+}
+
+// loadCode returns the bytecode given either inline or as a file.
+// A file holding valid hex (optionally 0x prefixed, surrounding whitespace ignored) is decoded, anything else is used as raw binary.
+func loadCode(code, file string) ([]byte, error) {
+	switch {
+	case code != "" && file != "":
+		return nil, errors.New("--code and --codefile are mutually exclusive")
+	case code != "":
+		b, err := decodeHex(code)
+		if err != nil {
+			return nil, fmt.Errorf("invalid code: %v", err)
+		}
+		return b, nil
+	case file == "":
+		return nil, errors.New("no code given, use --code or --codefile")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b, err := decodeHex(string(bytes.TrimSpace(data))); err == nil {
+		return b, nil
+	}
+	return data, nil
+}
+
+// decodeHex decodes a hex string with or without the 0x prefix.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+// sourceFlags select a compiled contract, to execute its deployed bytecode and map it back to its Solidity source.
+type sourceFlags struct {
+	artifacts, solc, contract *string
+}
+
+func addSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	return &sourceFlags{
+		artifacts: fs.String("artifacts", "", "compiled contracts: solc --combined-json or standard JSON output, Hardhat or Foundry artifact or build-info, or a directory of them"),
+		solc:      fs.String("solc", "", "same as --artifacts"),
+		contract:  fs.String("contract", "", "contract of the artifacts, as Name or file.sol:Name (default the only one)"),
+	}
+}
+
+// loadArtifact returns the selected contract, nil if no artifacts are given. Contracts without deployed bytecode,
+// such as interfaces, can't be selected.
+func (f *sourceFlags) loadArtifact() (*artifacts.Contract, error) {
+	path := *f.artifacts
+	switch {
+	case path != "" && *f.solc != "":
+		return nil, errors.New("--artifacts and --solc are mutually exclusive")
+	case path == "":
+		path = *f.solc
+	}
+	if path == "" {
+		return nil, nil
+	}
+	all, err := artifacts.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	var deployable []*artifacts.Contract
+	for _, c := range all {
+		if len(c.DeployedBytecode.Code) > 0 {
+			deployable = append(deployable, c)
+		}
+	}
+	contract, err := artifacts.Find(deployable, *f.contract)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return contract, nil
+}
+
+// load returns the selected contract and its source map, nil if no artifacts are given, and nil for the source map if
+// the contract has none. The contract's code is executed unless code is given.
+func (f *sourceFlags) load(env *envFlags, fs *flag.FlagSet) (*artifacts.Contract, *sourcemap.Contract, error) {
+	a, err := f.loadArtifact()
+	if a == nil || err != nil {
+		return nil, nil, err
+	}
+	if *env.code == "" && *env.codeFile == "" && fs.NArg() == 0 {
+		*env.code = common.Bytes2Hex(a.DeployedBytecode.Code)
+	}
+	if a.DeployedBytecode.SourceMap == "" {
+		return a, nil, nil
+	}
+	contract, err := sourcemap.NewContract(a, sourcemap.ReadSourceFrom(filepath.Dir(*f.artifacts+*f.solc)))
+	return a, contract, err
+}
+
+// code returns the code given as argument or file, or else the deployed bytecode of the selected contract.
+func (f *sourceFlags) code(arg, file string) ([]byte, error) {
+	if arg == "" && file == "" {
+		a, err := f.loadArtifact()
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			return a.DeployedBytecode.Code, nil
+		}
+	}
+	return loadCode(arg, file)
+}
+
+// sourceTracer tracks the source location of the executed code, printing each new line reached if out is set.
+type sourceTracer struct {
+	m    *sourcemap.Map
+	out  io.Writer
+	pc   uint64 // last instruction executed
+	last sourcemap.Location
+}
+
+func (t *sourceTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	t.pc = pc
+	loc, ok := t.m.Lookup(pc)
+	if !ok || t.out == nil || (loc.Source == t.last.Source && loc.Line == t.last.Line) {
+		return
+	}
+	t.last = loc
+	fmt.Fprintf(t.out, "%v  %s\n", loc, strings.TrimSpace(loc.Source.Line(loc.Line)))
+}
+
+func (t *sourceTracer) CaptureEnd(evm *gevm.EVM) {}
+
+// location returns the source location of the last instruction executed, "" if unknown.
+func (t *sourceTracer) location() string {
+	loc, ok := t.m.Lookup(t.pc)
+	if !ok {
+		return ""
+	}
+	return loc.String()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Jesserc/gevm/statetest"
+)
+
+// runStateTests implements `gevm statetest [--fork name] [--all] <file-or-dir>...`.
+func runStateTests(args []string) int {
+	fs := flag.NewFlagSet("statetest", flag.ExitOnError)
+	fork := fs.String("fork", statetest.DefaultFork, "fork whose post states are checked")
+	all := fs.Bool("all", false, "print passing and skipped tests as well as failing ones")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm statetest [flags] <file-or-dir>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var passed, failed, skipped int
+	for _, path := range fs.Args() {
+		results, err := statetest.RunPath(path, *fork)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, result := range results {
+			switch {
+			case result.Skipped:
+				skipped++
+			case result.Pass:
+				passed++
+			default:
+				failed++
+			}
+			if *all || (!result.Pass && !result.Skipped) {
+				fmt.Printf("%s (%s)\n", result, result.File)
+			}
+			if result.State != nil {
+				state, _ := json.MarshalIndent(result.State, "", "  ")
+				fmt.Printf("post state computed by gevm:\n%s\n", state)
+			}
+		}
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
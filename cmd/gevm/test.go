@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/testrunner"
+)
+
+// runTests implements `gevm test [flags] [path...]`, running the test contracts of Foundry or Hardhat artifacts.
+// Tests gevm can't run are skipped, and don't fail the command.
+func runTests(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	matchTest := fs.String("match-test", "", "only run the test functions matching this regular expression")
+	matchContract := fs.String("match-contract", "", "only run the test contracts matching this regular expression")
+	verbose := fs.Bool("v", false, "print the logs and console.log messages of passing tests as well as failing ones")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gevm test [flags] [path...]")
+		fmt.Fprintln(fs.Output(), "Paths are artifacts in any format gevm reads, or directories of them, out by default.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	testRE, err := regexp.Compile(*matchTest)
+	var contractRE *regexp.Regexp
+	if err == nil {
+		contractRE, err = regexp.Compile(*matchContract)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gevm test:", err)
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"out"}
+	}
+	var contracts []*artifacts.Contract
+	for _, path := range paths {
+		found, err := artifacts.Load(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gevm test:", err)
+			return 1
+		}
+		contracts = append(contracts, found...)
+	}
+
+	var suites, passed, failed, skipped int
+	for _, c := range contracts {
+		if len(c.Bytecode.Code) == 0 || len(testrunner.Tests(c)) == 0 || !contractRE.MatchString(c.Name) {
+			continue
+		}
+		results, err := testrunner.Run(c, testRE.MatchString)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gevm test:", err)
+			return 1
+		}
+		if len(results) == 0 {
+			continue
+		}
+		suites++
+		fmt.Printf("Ran %d tests for %s\n", len(results), c.FullName())
+		for _, result := range results {
+			switch {
+			case result.Passed:
+				passed++
+				fmt.Printf("[PASS] %s (gas: %d)\n", result.Test, result.GasUsed)
+			case result.Skipped:
+				skipped++
+				fmt.Printf("[SKIP: %s] %s\n", result.Reason, result.Test)
+				continue
+			default:
+				failed++
+				fmt.Printf("[FAIL: %s] %s (gas: %d)\n", result.Reason, result.Test, result.GasUsed)
+			}
+			if *verbose || !result.Passed {
+				printTestLogs(result)
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Ran %d test suites: %d tests passed, %d failed, %d skipped\n", suites, passed, failed, skipped)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printTestLogs prints the console.log messages and the events of a test, decoded when its contract's ABI has them.
+func printTestLogs(result testrunner.Result) {
+	if len(result.Console) == 0 && len(result.Logs) == 0 {
+		return
+	}
+	fmt.Println("Logs:")
+	for _, msg := range result.Console {
+		fmt.Println(" ", msg.Text)
+	}
+	for i, log := range result.Logs {
+		if ev := result.Events[i]; ev != nil {
+			fmt.Printf("  emit %v\n", ev)
+		} else {
+			fmt.Printf("  log %s topics %v data %v\n", log.Address.Hex(), log.Topics, log.Data)
+		}
+	}
+}
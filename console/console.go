@@ -0,0 +1,68 @@
+// Package console implements Hardhat's console.log as a native contract, so that contracts debugged with console.sol
+// print their messages when running in gevm.
+package console
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Address is the address console.sol calls, "console.log" in ASCII.
+var Address = common.HexToAddress("0x000000000000000000636F6e736F6c652e6c6f67")
+
+// Message is a message logged by console.log.
+type Message struct {
+	Text  string `json:"text"`
+	PC    uint64 `json:"pc"`    // program counter of the call to console.log
+	Depth int    `json:"depth"` // call depth of the code calling console.log, 1 for the transaction's frame
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("console.log (pc %d, depth %d): %s", m.PC, m.Depth, m.Text)
+}
+
+// Tracer is implemented by the tracers that want the messages, which a Console passes to the EVM's tracer.
+type Tracer interface {
+	CaptureConsoleLog(evm *gevm.EVM, msg Message)
+}
+
+// Console records the messages logged by console.log, in order.
+type Console struct {
+	Messages []Message
+	Output   io.Writer // optional, prints the messages as they are logged
+}
+
+// New returns a console printing its messages to out, which may be nil.
+func New(out io.Writer) *Console {
+	return &Console{Output: out}
+}
+
+// Register adds the console to the native contracts of cfg, at Address.
+func (c *Console) Register(cfg *gevm.ChainConfig) {
+	if cfg.Natives == nil {
+		cfg.Natives = make(map[common.Address]gevm.NativeContract)
+	}
+	cfg.Natives[Address] = c
+}
+
+// Run logs the message of a console.log call. It never fails, so that logging doesn't change the execution: a call it
+// can't decode is logged as such.
+func (c *Console) Run(evm *gevm.EVM, input []byte) ([]byte, error) {
+	text, err := Format(input)
+	if err != nil {
+		text = err.Error()
+	}
+	msg := Message{Text: text, PC: evm.PC, Depth: 1} // gevm runs a single call frame
+	c.Messages = append(c.Messages, msg)
+	if c.Output != nil {
+		fmt.Fprintln(c.Output, msg)
+	}
+	if t, ok := evm.Tracer.(Tracer); ok {
+		t.CaptureConsoleLog(evm, msg)
+	}
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// messageTracer records the messages passed to the tracer.
+type messageTracer struct {
+	messages []Message
+}
+
+func (t *messageTracer) CaptureState(*gevm.EVM, uint64, gevm.Opcode) {}
+func (t *messageTracer) CaptureEnd(*gevm.EVM)                        {}
+func (t *messageTracer) CaptureConsoleLog(_ *gevm.EVM, msg Message) {
+	t.messages = append(t.messages, msg)
+}
+
+// staticCall appends a STATICCALL of console.log with input to p, returning the PC of the STATICCALL.
+func staticCall(p *gevm.Program, input []byte) uint64 {
+	p.MstoreBytes(0, input).StaticCall(nil, Address, 0, len(input), 0, 0)
+	return uint64(p.Size() - 1)
+}
+
+func TestConsole(t *testing.T) {
+	p := gevm.NewProgram()
+	pc1 := staticCall(p, encode(t, "log(string,uint256)", "x = %d", big.NewInt(7)))
+	pc2 := staticCall(p, []byte{0xde, 0xad, 0xbe, 0xef})
+
+	var out bytes.Buffer
+	c := New(&out)
+	tracer := &messageTracer{}
+	evm := gevm.NewEVM(common.Address{}, 1_000_000, 0, 1, 30_000_000, p.Bytes(), nil, gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0)))
+	evm.Verbose = false
+	evm.Tracer = tracer
+	c.Register(&evm.ChainConfig)
+
+	res := evm.Execute()
+	require.NoError(t, res.Err)
+	want := []Message{
+		{Text: "x = 7", PC: pc1, Depth: 1},
+		{Text: "console.log call with an unknown selector 0xdeadbeef", PC: pc2, Depth: 1},
+	}
+	assert.Equal(t, want, c.Messages)
+	assert.Equal(t, want, tracer.messages)
+	assert.Equal(t, fmt.Sprintf("console.log (pc %d, depth 1): x = 7\nconsole.log (pc %d, depth 1): console.log call with an unknown selector 0xdeadbeef\n", pc1, pc2), out.String())
+	// Both calls succeed
+	require.Equal(t, 2, evm.Stack.Len())
+	assert.Equal(t, uint64(1), evm.Stack.Back(0).Uint64())
+	assert.Equal(t, uint64(1), evm.Stack.Back(1).Uint64())
+}
@@ -0,0 +1,117 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Jesserc/gevm/abi"
+)
+
+// Format decodes the calldata of a console.log call into its message.
+//
+// Like Hardhat, the arguments are formatted the way Node.js' util.format does: if the first one is a string,
+// its %s, %d, %i, %o and %O are replaced by the next arguments, and the remaining ones are appended with spaces.
+func Format(input []byte) (string, error) {
+	if len(input) < 4 {
+		return "", fmt.Errorf("console.log call without a selector: %#x", input)
+	}
+	m, ok := methods[[4]byte(input)]
+	if !ok {
+		return "", fmt.Errorf("console.log call with an unknown selector %#x", input[:4])
+	}
+	values, err := m.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return "", fmt.Errorf("can't decode console.log call %s: %v", m.Sig, err)
+	}
+	args := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			args[i] = s
+		} else {
+			args[i] = abi.Format(v)
+		}
+	}
+	if len(values) > 0 {
+		if format, ok := values[0].(string); ok {
+			return substitute(format, args[1:]), nil
+		}
+	}
+	return strings.Join(args, " "), nil
+}
+
+// substitute replaces the format specifiers of format by args, appending the unused ones.
+func substitute(format string, args []string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+		switch format[i+1] {
+		case 's', 'd', 'i', 'o', 'O':
+			if len(args) == 0 {
+				sb.WriteByte('%') // nothing left to substitute, the specifier is kept
+				continue
+			}
+			sb.WriteString(args[0])
+			args = args[1:]
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			continue
+		}
+		i++
+	}
+	for _, arg := range args {
+		sb.WriteString(" " + arg)
+	}
+	return sb.String()
+}
+
+// methods maps the selectors of the functions of console.sol to them, to decode their arguments.
+var methods = func() map[[4]byte]*gethabi.Method {
+	m := make(map[[4]byte]*gethabi.Method)
+	add := func(sig string) {
+		method, err := abi.MethodFromSignature(sig)
+		if err != nil {
+			panic(err)
+		}
+		m[[4]byte(method.ID)] = method
+		// Early versions of console.sol hashed uint and int instead of uint256 and int256
+		if legacy := strings.ReplaceAll(sig, "int256", "int"); legacy != sig {
+			m[[4]byte(crypto.Keccak256([]byte(legacy)))] = method
+		}
+	}
+
+	add("log()")
+	for _, t := range []string{"uint256", "int256", "string", "bool", "address"} {
+		add("log(" + t + ")")
+	}
+	for name, t := range map[string]string{"Int": "int256", "Uint": "uint256", "String": "string", "Bool": "bool", "Address": "address", "Bytes": "bytes"} {
+		add("log" + name + "(" + t + ")")
+	}
+	for n := 1; n <= 32; n++ {
+		add(fmt.Sprintf("logBytes%d(bytes%d)", n, n))
+	}
+
+	// log with 2 to 4 arguments of these types
+	types := []string{"uint256", "string", "bool", "address"}
+	var combine func(params []string)
+	combine = func(params []string) {
+		if len(params) >= 2 {
+			add("log(" + strings.Join(params, ",") + ")")
+		}
+		if len(params) == 4 {
+			return
+		}
+		for _, t := range types {
+			combine(append(params[:len(params):len(params)], t))
+		}
+	}
+	combine(nil)
+	return m
+}()
@@ -0,0 +1,74 @@
+package console
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/abi"
+)
+
+// encode returns the calldata of a console.sol function.
+func encode(t *testing.T, sig string, args ...any) []byte {
+	t.Helper()
+	data, err := abi.PackCall(sig, args...)
+	require.NoError(t, err)
+	return data
+}
+
+func TestFormat(t *testing.T) {
+	addr := common.HexToAddress("0x5B38Da6a701c568545dCfcB03FcB875f56beddC4")
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"empty", encode(t, "log()"), ""},
+		{"string", encode(t, "log(string)", "hello"), "hello"},
+		{"uint", encode(t, "log(uint256)", big.NewInt(42)), "42"},
+		{"legacy uint", append(hexutil.MustDecode("0xf5b1bba9"), common.LeftPadBytes([]byte{42}, 32)...), "42"},
+		{"int", encode(t, "logInt(int256)", big.NewInt(-1)), "-1"},
+		{"bytes", encode(t, "logBytes(bytes)", []byte{0xca, 0xfe}), "0xcafe"},
+		{"bytes4", encode(t, "logBytes4(bytes4)", [4]byte{1, 2, 3, 4}), "0x01020304"},
+		{"arguments", encode(t, "log(uint256,bool,address)", big.NewInt(1), true, addr), "1 true " + addr.Hex()},
+		{"format", encode(t, "log(string,address,uint256)", "%s has %d tokens", addr, big.NewInt(10)), addr.Hex() + " has 10 tokens"},
+		{"format with extra arguments", encode(t, "log(string,uint256,string)", "100%% of %i", big.NewInt(3), "more"), "100% of 3 more"},
+		{"format without arguments", encode(t, "log(string,string)", "%s and %s", "a"), "a and %s"},
+		{"string arguments", encode(t, "log(string,string,string,string)", "a", "b", "c", "d"), "a b c d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Format([]byte{1, 2})
+	assert.EqualError(t, err, "console.log call without a selector: 0x0102")
+	_, err = Format([]byte{0xde, 0xad, 0xbe, 0xef})
+	assert.EqualError(t, err, "console.log call with an unknown selector 0xdeadbeef")
+	_, err = Format(encode(t, "log(uint256)", big.NewInt(1))[:8])
+	assert.ErrorContains(t, err, "can't decode console.log call log(uint256)")
+}
+
+func TestMethods(t *testing.T) {
+	// log(), 5 log with one argument, 6 named ones, 32 logBytesN and 4^2 + 4^3 + 4^4 log with several arguments,
+	// plus the legacy selectors of the 223 with an uint256 or int256
+	assert.Len(t, methods, 380+223)
+	for selector, sig := range map[string]string{
+		"0x41304fac": "log(string)",
+		"0xf82c50f1": "log(uint256)",
+		"0xf5b1bba9": "log(uint256)",
+		"0xb60e72cc": "log(string,uint256)",
+		"0x9710a9d0": "log(string,uint256)",
+		"0x80e6a20b": "log(address,bool,string,uint256)",
+		"0x9e127b6e": "log(address,bool,string,uint256)",
+	} {
+		assert.Equal(t, sig, methods[[4]byte(hexutil.MustDecode(selector))].Sig, selector)
+	}
+}
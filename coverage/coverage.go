@@ -0,0 +1,159 @@
+// Package coverage measures which instructions of a bytecode, and which directions of its JUMPIs, executions reach.
+//
+// A Coverage is a tracer: set it on the EVMs running the code, as many times as needed, then report the coverage
+// as percentages, as an annotated disassembly or, given the source map of the code, as LCOV.
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Branch counts how many times a JUMPI jumped or fell through.
+type Branch struct {
+	Taken    uint64
+	NotTaken uint64
+}
+
+// Coverage accumulates the instructions executed in a bytecode.
+type Coverage struct {
+	code         []byte
+	instructions []gevm.Instruction
+	hits         map[uint64]uint64 // executions per PC
+	branches     map[uint64]*Branch
+
+	evm     *gevm.EVM // EVM last traced, and whether it runs the code
+	matches bool
+	jumpi   *uint64 // PC of the JUMPI that just executed, its direction being known on the next step
+}
+
+// New creates the coverage of code. Executions of any other code are ignored.
+func New(code []byte) *Coverage {
+	c := &Coverage{
+		code:         bytes.Clone(code),
+		instructions: gevm.Disassemble(code),
+		hits:         make(map[uint64]uint64),
+		branches:     make(map[uint64]*Branch),
+	}
+	for _, ins := range c.instructions {
+		if ins.Op == gevm.JUMPI {
+			c.branches[ins.PC] = new(Branch)
+		}
+	}
+	return c
+}
+
+// CaptureState implements gevm.Tracer.
+func (c *Coverage) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	if evm != c.evm {
+		c.evm, c.matches, c.jumpi = evm, bytes.Equal(evm.Code, c.code), nil
+	}
+	if !c.matches {
+		return
+	}
+	c.branch(pc)
+	c.hits[pc]++
+	if op == gevm.JUMPI {
+		c.jumpi = &pc
+	}
+}
+
+// CaptureEnd implements gevm.Tracer.
+func (c *Coverage) CaptureEnd(evm *gevm.EVM) {
+	if evm == c.evm && c.matches {
+		// A JUMPI that's the last instruction of the code falls through to the implicit STOP
+		if c.jumpi != nil && evm.PC == *c.jumpi+1 {
+			c.branch(evm.PC)
+		}
+	}
+	c.evm, c.jumpi = nil, nil
+}
+
+// branch records the direction of the JUMPI that just executed, if any, given the PC it went to.
+func (c *Coverage) branch(next uint64) {
+	if c.jumpi == nil {
+		return
+	}
+	if next == *c.jumpi+1 {
+		c.branches[*c.jumpi].NotTaken++
+	} else {
+		c.branches[*c.jumpi].Taken++
+	}
+	c.jumpi = nil
+}
+
+// Hits returns how many times the instruction at pc was executed.
+func (c *Coverage) Hits(pc uint64) uint64 {
+	return c.hits[pc]
+}
+
+// Branch returns the directions taken by the JUMPI at pc, nil if there is no JUMPI at pc.
+func (c *Coverage) Branch(pc uint64) *Branch {
+	return c.branches[pc]
+}
+
+// Summary is the coverage of a bytecode.
+type Summary struct {
+	Instructions, InstructionsHit int
+	Branches, BranchesHit         int // two per JUMPI, one for each direction
+}
+
+// InstructionPercent returns the percentage of instructions executed.
+func (s Summary) InstructionPercent() float64 {
+	return percent(s.InstructionsHit, s.Instructions)
+}
+
+// BranchPercent returns the percentage of JUMPI directions taken.
+func (s Summary) BranchPercent() float64 {
+	return percent(s.BranchesHit, s.Branches)
+}
+
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(hit) / float64(total)
+}
+
+// Summary counts the instructions and branches covered.
+func (c *Coverage) Summary() Summary {
+	var s Summary
+	for _, ins := range c.instructions {
+		s.Instructions++
+		if c.hits[ins.PC] > 0 {
+			s.InstructionsHit++
+		}
+	}
+	for _, b := range c.branches {
+		s.Branches += 2
+		if b.Taken > 0 {
+			s.BranchesHit++
+		}
+		if b.NotTaken > 0 {
+			s.BranchesHit++
+		}
+	}
+	return s
+}
+
+// WriteAnnotated writes the disassembly of the code, each instruction prefixed with the number of times it executed
+// ("-" if never), and each JUMPI followed by the number of times it jumped and fell through.
+func (c *Coverage) WriteAnnotated(w io.Writer) error {
+	for _, ins := range c.instructions {
+		count := "-"
+		if n := c.hits[ins.PC]; n > 0 {
+			count = fmt.Sprint(n)
+		}
+		line := fmt.Sprintf("%8s  %v", count, ins)
+		if b := c.branches[ins.PC]; b != nil {
+			line += fmt.Sprintf("  [taken %d, not taken %d]", b.Taken, b.NotTaken)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func run(c *Coverage, code, calldata []byte) {
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+	evm := gevm.NewEVM(common.Address{}, 100_000, 0, 1, 30_000_000, code, calldata, block)
+	evm.Verbose = false
+	evm.Tracer = c
+	evm.Execute()
+}
+
+// branchy jumps to "set" if the first calldata word is not zero
+var branchy = gevm.NewProgram().Push0().Op(gevm.CALLDATALOAD).JumpI("set").Op(gevm.STOP).
+	Label("set").Sstore(0, 1).Op(gevm.STOP).Bytes()
+
+func TestCoverage(t *testing.T) {
+	c := New(branchy)
+	run(c, branchy, nil)
+
+	assert.Equal(t, uint64(1), c.Hits(0))
+	assert.Equal(t, uint64(0), c.Hits(7)) // JUMPDEST
+	assert.Equal(t, &Branch{Taken: 0, NotTaken: 1}, c.Branch(5))
+	assert.Nil(t, c.Branch(0))
+	assert.Equal(t, Summary{Instructions: 10, InstructionsHit: 5, Branches: 2, BranchesHit: 1}, c.Summary())
+	assert.Equal(t, 50.0, c.Summary().InstructionPercent())
+	assert.Equal(t, 50.0, c.Summary().BranchPercent())
+
+	// Other code isn't counted
+	run(c, gevm.NewProgram().Op(gevm.STOP).Bytes(), nil)
+	assert.Equal(t, uint64(1), c.Hits(0))
+
+	run(c, branchy, common.LeftPadBytes([]byte{1}, 32))
+	assert.Equal(t, uint64(2), c.Hits(0))
+	assert.Equal(t, &Branch{Taken: 1, NotTaken: 1}, c.Branch(5))
+	assert.Equal(t, Summary{Instructions: 10, InstructionsHit: 10, Branches: 2, BranchesHit: 2}, c.Summary())
+}
+
+func TestCoverageJumpiAtEnd(t *testing.T) {
+	// The JUMPI falls through to the end of the code
+	code := gevm.NewProgram().Label("a").Push0().JumpI("a").Bytes()
+	c := New(code)
+	run(c, code, nil)
+	assert.Equal(t, &Branch{NotTaken: 1}, c.Branch(5))
+}
+
+func TestCoverageFailedJumpi(t *testing.T) {
+	// The JUMPI to an invalid destination fails, so it has no direction
+	code := gevm.NewProgram().Push(1).Push(42).Op(gevm.JUMPI, gevm.STOP).Bytes()
+	c := New(code)
+	run(c, code, nil)
+	assert.Equal(t, uint64(1), c.Hits(4))
+	assert.Equal(t, &Branch{}, c.Branch(4))
+}
+
+func TestSummaryWithoutCode(t *testing.T) {
+	s := New(nil).Summary()
+	assert.Equal(t, 100.0, s.InstructionPercent())
+	assert.Equal(t, 100.0, s.BranchPercent())
+}
+
+func TestWriteAnnotated(t *testing.T) {
+	c := New(branchy)
+	run(c, branchy, nil)
+	run(c, branchy, nil)
+
+	var sb strings.Builder
+	assert.NoError(t, c.WriteAnnotated(&sb))
+	assert.Equal(t, `       2  0x0000: PUSH0
+       2  0x0001: CALLDATALOAD
+       2  0x0002: PUSH2 0x0007
+       2  0x0005: JUMPI  [taken 0, not taken 2]
+       2  0x0006: STOP
+       -  0x0007: JUMPDEST
+       -  0x0008: PUSH1 0x01
+       -  0x000a: PUSH1 0x00
+       -  0x000c: SSTORE
+       -  0x000d: STOP
+`, sb.String())
+}
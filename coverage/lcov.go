@@ -0,0 +1,86 @@
+package coverage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+// WriteLCOV writes the coverage of the source files in the LCOV format, mapping the instructions to their source lines
+// with the source map of the code. The hits of a line are the most an instruction on it executed, and each JUMPI is a
+// branch of its line. Files without content, such as those generated by the compiler, are left out.
+func (c *Coverage) WriteLCOV(w io.Writer, m *sourcemap.Map) error {
+	type branch struct {
+		line int
+		b    *Branch
+	}
+	type file struct {
+		src      *sourcemap.Source
+		lines    map[int]uint64
+		branches []branch
+	}
+	files := make(map[string]*file)
+	for _, ins := range c.instructions {
+		loc, ok := m.Lookup(ins.PC)
+		if !ok || loc.Line == 0 || strings.HasPrefix(loc.Source.Name, "#") {
+			continue
+		}
+		f := files[loc.Source.Name]
+		if f == nil {
+			f = &file{src: loc.Source, lines: make(map[int]uint64)}
+			files[loc.Source.Name] = f
+		}
+		f.lines[loc.Line] = max(f.lines[loc.Line], c.hits[ins.PC])
+		if b := c.branches[ins.PC]; b != nil {
+			f.branches = append(f.branches, branch{loc.Line, b})
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		f := files[name]
+		fmt.Fprintf(&sb, "TN:\nSF:%s\n", name)
+
+		lines := make([]int, 0, len(f.lines))
+		for line := range f.lines {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		hit := 0
+		for _, line := range lines {
+			fmt.Fprintf(&sb, "DA:%d,%d\n", line, f.lines[line])
+			if f.lines[line] > 0 {
+				hit++
+			}
+		}
+
+		branchesHit := 0
+		for i, br := range f.branches {
+			for j, n := range []uint64{br.b.Taken, br.b.NotTaken} {
+				taken := "-" // the branch's line never executed
+				if f.lines[br.line] > 0 {
+					taken = fmt.Sprint(n)
+				}
+				if n > 0 {
+					branchesHit++
+				}
+				fmt.Fprintf(&sb, "BRDA:%d,%d,%d,%s\n", br.line, i, j, taken)
+			}
+		}
+		if len(f.branches) > 0 {
+			fmt.Fprintf(&sb, "BRF:%d\nBRH:%d\n", 2*len(f.branches), branchesHit)
+		}
+		fmt.Fprintf(&sb, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,40 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+func TestWriteLCOV(t *testing.T) {
+	// if (x != 0) { y = 1; } on lines 1 and 2, the generated STOP having no source
+	src := sourcemap.NewSource(0, "A.sol", "if (x != 0) {\n  y = 1;\n}\n")
+	entries, err := sourcemap.Parse("0:11:0;;;;;14:6;;;;-1:0:-1")
+	assert.NoError(t, err)
+	m, err := sourcemap.NewMap(branchy, entries, map[int]*sourcemap.Source{0: src})
+	assert.NoError(t, err)
+
+	c := New(branchy)
+	run(c, branchy, nil)
+	run(c, branchy, nil)
+
+	var sb strings.Builder
+	assert.NoError(t, c.WriteLCOV(&sb, m))
+	assert.Equal(t, `TN:
+SF:A.sol
+DA:1,2
+DA:2,0
+BRDA:1,0,0,0
+BRDA:1,0,1,2
+BRF:2
+BRH:1
+LF:2
+LH:1
+end_of_record
+`, sb.String())
+	assert.Len(t, gevm.Disassemble(branchy), len(entries))
+}
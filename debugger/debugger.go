@@ -0,0 +1,381 @@
+// Package debugger steps through the execution of EVM bytecode.
+//
+// The EVM runs in its own goroutine, with the Debugger attached as its tracer. Before every instruction the tracer
+// decides whether execution must pause, and if so blocks until the debugger resumes it. While execution is paused,
+// the EVM state can be inspected and modified through EVM().
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+// ErrFinished is returned when resuming an execution that already ended.
+var ErrFinished = errors.New("execution finished")
+
+// errKilled halts the EVM when the debugger is closed before execution ends.
+var errKilled = errors.New("killed by the debugger")
+
+// BreakKind is what a breakpoint matches.
+type BreakKind int
+
+const (
+	BreakPC     BreakKind = iota // the instruction at a PC is about to execute
+	BreakOpcode                  // an opcode is about to execute
+	BreakSlot                    // a storage slot is about to be written
+)
+
+// Breakpoint pauses execution before an instruction.
+type Breakpoint struct {
+	ID    int
+	Kind  BreakKind
+	Value uint256.Int // PC, opcode or storage slot, depending on Kind
+}
+
+func (b *Breakpoint) String() string {
+	switch b.Kind {
+	case BreakPC:
+		return fmt.Sprintf("#%d pc %#x", b.ID, b.Value.Uint64())
+	case BreakOpcode:
+		return fmt.Sprintf("#%d opcode %v", b.ID, gevm.Opcode(b.Value.Uint64()))
+	default:
+		return fmt.Sprintf("#%d storage slot %s", b.ID, b.Value.Hex())
+	}
+}
+
+// Watchpoint pauses execution after an instruction changed a range of memory.
+type Watchpoint struct {
+	ID     int
+	Offset uint64
+	Size   uint64
+	value  []byte // contents of the range when last checked
+}
+
+func (w *Watchpoint) String() string {
+	return fmt.Sprintf("#%d memory[%#x:%#x]", w.ID, w.Offset, w.Offset+w.Size)
+}
+
+// Debugger controls the execution of an EVM.
+type Debugger struct {
+	evm    *gevm.EVM
+	resume chan bool     // resumes execution, or kills it if false
+	paused chan struct{} // signals that execution paused or finished
+
+	// Set by the EVM goroutine before it signals paused
+	pc       uint64
+	op       gevm.Opcode
+	captured int    // instructions seen by the tracer
+	steps    int    // instructions executed so far
+	reason   string // why execution paused
+	result   *gevm.ExecutionResult
+	finished bool
+	prevPC   uint64 // instruction executed before the current one
+	prevOp   gevm.Opcode
+
+	// pause decides, before an instruction, whether execution pauses there when no breakpoint matches
+	pause       func(pc uint64) bool
+	skipBreaks  bool // ignore breakpoints and watchpoints until execution pauses
+	recorder    *Recorder
+	sourceMap   *sourcemap.Map
+	breakpoints []*Breakpoint
+	watchpoints []*Watchpoint
+	nextID      int
+}
+
+// New creates a debugger for evm and starts executing it, pausing before the first instruction.
+// The EVM must not be used until execution finished.
+func New(evm *gevm.EVM) *Debugger {
+	d := &Debugger{
+		evm:      evm,
+		resume:   make(chan bool),
+		paused:   make(chan struct{}),
+		pause:    func(uint64) bool { return true },
+		nextID:   1,
+		recorder: NewRecorder(),
+	}
+	evm.Tracer = d
+	go func() {
+		d.result = evm.Execute()
+		d.finished = true
+		if d.reason == "" {
+			d.reason = "execution finished"
+		} else {
+			d.reason = "execution finished; " + d.reason
+		}
+		d.paused <- struct{}{}
+	}()
+	<-d.paused
+	return d
+}
+
+// EVM returns the EVM being debugged. It may only be accessed while execution is paused or finished.
+func (d *Debugger) EVM() *gevm.EVM {
+	return d.evm
+}
+
+// PC returns the PC of the instruction execution paused before.
+func (d *Debugger) PC() uint64 {
+	return d.pc
+}
+
+// Op returns the opcode of the instruction execution paused before.
+func (d *Debugger) Op() gevm.Opcode {
+	return d.op
+}
+
+// Steps returns the number of instructions executed so far.
+func (d *Debugger) Steps() int {
+	return d.steps
+}
+
+// Reason describes why execution last paused.
+func (d *Debugger) Reason() string {
+	return d.reason
+}
+
+// Finished reports whether execution ended.
+func (d *Debugger) Finished() bool {
+	return d.finished
+}
+
+// Result returns the outcome of the execution, nil until it finished.
+func (d *Debugger) Result() *gevm.ExecutionResult {
+	return d.result
+}
+
+// Step executes the current instruction and pauses before the next one.
+func (d *Debugger) Step() error {
+	return d.run(func(uint64) bool { return true })
+}
+
+// Next steps over the current instruction: it runs until the instruction that follows it in the code is reached.
+// For a JUMP into an internal function, which returns to the JUMPDEST that follows the JUMP, this runs the whole function.
+func (d *Debugger) Next() error {
+	next := d.pc + 1 + uint64(d.op.PushSize())
+	return d.run(func(pc uint64) bool { return pc == next })
+}
+
+// Continue runs until a breakpoint or watchpoint is hit, or execution finishes.
+func (d *Debugger) Continue() error {
+	return d.run(func(uint64) bool { return false })
+}
+
+// RunTo runs until step n, ignoring breakpoints and watchpoints. Use StateAt for the steps already executed.
+func (d *Debugger) RunTo(n int) error {
+	if n <= d.steps {
+		return fmt.Errorf("step %d was already executed", n)
+	}
+	d.skipBreaks = true
+	defer func() { d.skipBreaks = false }()
+	return d.run(func(uint64) bool { return d.steps == n })
+}
+
+// Recorder returns the recording of the execution so far.
+func (d *Debugger) Recorder() *Recorder {
+	return d.recorder
+}
+
+// StateAt reconstructs the state before step n, which must not be later than the current step.
+func (d *Debugger) StateAt(n int) (*Snapshot, error) {
+	return d.recorder.State(n)
+}
+
+// State returns the current state.
+func (d *Debugger) State() *Snapshot {
+	evm := d.evm
+	return &Snapshot{
+		Step:      d.steps,
+		PC:        d.pc,
+		Op:        d.op,
+		Gas:       evm.Gas,
+		Refund:    evm.Refund,
+		Stack:     slices.Clone(evm.Data()),
+		Memory:    bytes.Clone(evm.Memory.Data()),
+		Storage:   evm.Storage.Slots(),
+		Transient: evm.Transient.Slots(),
+	}
+}
+
+// LastWrite returns the last step that wrote a storage slot.
+func (d *Debugger) LastWrite(slot common.Hash) (*Step, int, bool) {
+	n, ok := d.recorder.LastWrite(slot, d.steps)
+	if !ok {
+		return nil, 0, false
+	}
+	return d.recorder.Steps()[n], n, true
+}
+
+// Close kills the execution if it's still running.
+func (d *Debugger) Close() {
+	if !d.finished {
+		d.resume <- false
+		<-d.paused
+	}
+}
+
+func (d *Debugger) run(pause func(pc uint64) bool) error {
+	if d.finished {
+		return ErrFinished
+	}
+	d.pause = pause
+	d.resume <- true
+	<-d.paused
+	return nil
+}
+
+// Break adds a breakpoint and returns it.
+func (d *Debugger) Break(kind BreakKind, value *uint256.Int) *Breakpoint {
+	b := &Breakpoint{ID: d.nextID, Kind: kind, Value: *value}
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	return b
+}
+
+// Watch adds a memory watchpoint and returns it. The range must not be empty, nor extend past gevm.MaxMemorySize.
+func (d *Debugger) Watch(offset, size uint64) (*Watchpoint, error) {
+	if size == 0 || offset > gevm.MaxMemorySize || size > gevm.MaxMemorySize-offset {
+		return nil, fmt.Errorf("invalid watchpoint memory[%#x:%#x], the memory is at most %#x bytes", offset, offset+size, gevm.MaxMemorySize)
+	}
+	w := &Watchpoint{ID: d.nextID, Offset: offset, Size: size, value: d.memory(offset, size)}
+	d.nextID++
+	d.watchpoints = append(d.watchpoints, w)
+	return w, nil
+}
+
+// Breakpoints returns the breakpoints.
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	return d.breakpoints
+}
+
+// Watchpoints returns the watchpoints.
+func (d *Debugger) Watchpoints() []*Watchpoint {
+	return d.watchpoints
+}
+
+// Delete removes the breakpoint or watchpoint with the given id, reporting whether it existed.
+func (d *Debugger) Delete(id int) bool {
+	for i, b := range d.breakpoints {
+		if b.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	for i, w := range d.watchpoints {
+		if w.ID == id {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetStack replaces the n-th item from the top of the stack (0 being the top).
+func (d *Debugger) SetStack(n int, value *uint256.Int) error {
+	if n < 0 || n >= d.evm.Stack.Len() {
+		return fmt.Errorf("stack item %d out of range, the stack has %d items", n, d.evm.Stack.Len())
+	}
+	d.evm.Stack.Back(n).Set(value)
+	d.recorder.pokeStack(n, value)
+	return nil
+}
+
+// SetStorage writes a storage slot, without warming it.
+func (d *Debugger) SetStorage(slot common.Hash, value common.Hash) {
+	d.evm.Storage.Set(slot, value)
+	d.recorder.poke(slot, value)
+}
+
+// CaptureState implements gevm.Tracer. It runs in the EVM goroutine and blocks while execution is paused.
+func (d *Debugger) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	d.recorder.CaptureState(evm, pc, op)
+	d.steps = d.captured
+	d.captured++
+	first := d.captured == 1
+	d.prevPC, d.prevOp = d.pc, d.op
+	d.pc, d.op = pc, op
+
+	reason := d.check(first)
+	if reason == "" {
+		return
+	}
+	d.reason = reason
+	d.paused <- struct{}{}
+	if !<-d.resume {
+		panic(errKilled.Error())
+	}
+}
+
+// CaptureEnd implements gevm.Tracer. The watchpoints the last instruction changed are reported with the end of
+// execution, as there is no next instruction to pause before.
+func (d *Debugger) CaptureEnd(evm *gevm.EVM) {
+	d.recorder.CaptureEnd(evm)
+	d.steps = d.captured
+	d.reason = strings.Join(d.checkWatchpoints(d.op, d.pc), "; ")
+}
+
+// check returns why execution must pause before the current instruction, or "" if it must not.
+func (d *Debugger) check(first bool) string {
+	if first {
+		return "entry"
+	}
+	reasons := d.checkWatchpoints(d.prevOp, d.prevPC)
+	for _, b := range d.breakpoints {
+		if !d.skipBreaks && d.matches(b) {
+			reason := fmt.Sprintf("breakpoint %v", b)
+			if b.Kind == BreakSlot {
+				reason += fmt.Sprintf(", writing %s", d.evm.Stack.Back(1).Hex())
+			}
+			reasons = append(reasons, reason)
+		}
+	}
+	switch {
+	case len(reasons) > 0:
+		return strings.Join(reasons, "; ")
+	case d.pause(d.pc):
+		return "step"
+	}
+	return ""
+}
+
+// checkWatchpoints returns the watchpoints changed by the instruction op at pc, and records their new contents.
+func (d *Debugger) checkWatchpoints(op gevm.Opcode, pc uint64) []string {
+	var reasons []string
+	for _, w := range d.watchpoints {
+		value := d.memory(w.Offset, w.Size)
+		if !d.skipBreaks && !bytes.Equal(value, w.value) {
+			reasons = append(reasons, fmt.Sprintf("watchpoint %v changed by %v at %#x", w, op, pc))
+		}
+		w.value = value
+	}
+	return reasons
+}
+
+func (d *Debugger) matches(b *Breakpoint) bool {
+	switch b.Kind {
+	case BreakPC:
+		return b.Value.IsUint64() && b.Value.Uint64() == d.pc
+	case BreakOpcode:
+		return b.Value.IsUint64() && b.Value.Uint64() == uint64(d.op)
+	default:
+		return d.op == gevm.SSTORE && d.evm.Stack.Len() >= 2 && d.evm.Stack.Back(0).Eq(&b.Value)
+	}
+}
+
+// memory returns a range of memory, zero padded past the end of the allocated memory.
+func (d *Debugger) memory(offset, size uint64) []byte {
+	value := make([]byte, size)
+	data := d.evm.Memory.Data()
+	if offset < uint64(len(data)) {
+		copy(value, data[offset:])
+	}
+	return value
+}
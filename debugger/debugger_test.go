@@ -0,0 +1,153 @@
+package debugger
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func newEVM(code []byte) *gevm.EVM {
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+	evm := gevm.NewEVM(common.Address{}, 100_000, 0, 1, 30_000_000, code, nil, block)
+	evm.Verbose = false
+	return evm
+}
+
+// countdown stores 2, 1 and 0 in slot 0, then returns 32 bytes of memory holding 42
+var countdown = gevm.NewProgram().Push(3).
+	Label("loop").Push(1).Op(gevm.SWAP1, gevm.SUB, gevm.DUP1).Push0().Op(gevm.SSTORE, gevm.DUP1).JumpI("loop").
+	Mstore(0, 42).Return(0, 32)
+
+func TestStep(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	assert.Equal(t, "entry", d.Reason())
+	assert.Equal(t, uint64(0), d.PC())
+	assert.Equal(t, gevm.PUSH1, d.Op())
+
+	assert.NoError(t, d.Step())
+	assert.Equal(t, uint64(2), d.PC())
+	assert.Equal(t, gevm.JUMPDEST, d.Op())
+	assert.Equal(t, 1, d.Steps())
+	assert.Equal(t, uint64(3), d.EVM().Stack.Back(0).Uint64())
+
+	for !d.Finished() {
+		assert.NoError(t, d.Step())
+	}
+	assert.NoError(t, d.Result().Err)
+	assert.Equal(t, common.BigToHash(uint256.NewInt(42).ToBig()).Bytes(), d.Result().ReturnData)
+	assert.ErrorIs(t, d.Step(), ErrFinished)
+}
+
+func TestBreakpoints(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	d.Break(BreakPC, uint256.NewInt(2))
+	d.Break(BreakOpcode, uint256.NewInt(uint64(gevm.RETURN)))
+
+	// The loop runs 3 times
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, d.Continue())
+		assert.Equal(t, uint64(2), d.PC())
+		assert.Contains(t, d.Reason(), "breakpoint #1 pc 0x2")
+	}
+	assert.NoError(t, d.Continue())
+	assert.Equal(t, gevm.RETURN, d.Op())
+	assert.NoError(t, d.Continue())
+	assert.True(t, d.Finished())
+}
+
+func TestSlotBreakpoint(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	d.Break(BreakSlot, uint256.NewInt(0))
+	d.Break(BreakSlot, uint256.NewInt(1)) // never written
+
+	for _, value := range []string{"0x2", "0x1", "0x0"} {
+		assert.NoError(t, d.Continue())
+		assert.Equal(t, gevm.SSTORE, d.Op())
+		assert.Equal(t, "breakpoint #1 storage slot 0x0, writing "+value, d.Reason())
+	}
+	assert.NoError(t, d.Continue())
+	assert.True(t, d.Finished())
+}
+
+func TestWatchpoint(t *testing.T) {
+	code := gevm.NewProgram().Mstore(0, 1).Mstore(0x20, 2).Mstore(0, 3).Op(gevm.STOP).Bytes()
+	d := New(newEVM(code))
+	w, err := d.Watch(0, 32)
+	require.NoError(t, err)
+
+	assert.NoError(t, d.Continue())
+	assert.Equal(t, "watchpoint #1 memory[0x0:0x20] changed by MSTORE at 0x4", d.Reason())
+	assert.NoError(t, d.Continue())
+	assert.Equal(t, "watchpoint #1 memory[0x0:0x20] changed by MSTORE at 0xe", d.Reason())
+
+	assert.True(t, d.Delete(w.ID))
+	assert.False(t, d.Delete(w.ID))
+
+	_, err = d.Watch(0, 0)
+	assert.Error(t, err)
+	_, err = d.Watch(0, gevm.MaxMemorySize+1)
+	assert.EqualError(t, err, "invalid watchpoint memory[0x0:0x2000001], the memory is at most 0x2000000 bytes")
+	_, err = d.Watch(gevm.MaxMemorySize, 1)
+	assert.Error(t, err)
+	_, err = d.Watch(math.MaxUint64, 2)
+	assert.Error(t, err)
+}
+
+func TestWatchpointAtHalt(t *testing.T) {
+	// The last instruction changes the watched memory, there is no next one to pause before
+	code := gevm.NewProgram().Mstore(0, 1).Bytes()
+	d := New(newEVM(code))
+	_, err := d.Watch(0, 32)
+	require.NoError(t, err)
+
+	assert.NoError(t, d.Continue())
+	assert.True(t, d.Finished())
+	assert.Equal(t, "execution finished; watchpoint #1 memory[0x0:0x20] changed by MSTORE at 0x4", d.Reason())
+}
+
+func TestNext(t *testing.T) {
+	// Calls an internal function: PUSH the return address, JUMP to the function, which JUMPs back
+	code := gevm.NewProgram().
+		PushLabel("ret").Jump("fn").Label("ret").Op(gevm.STOP).
+		Label("fn").Push(1).Op(gevm.POP, gevm.JUMP).Bytes()
+	d := New(newEVM(code))
+	assert.NoError(t, d.Step())
+	assert.Equal(t, gevm.PUSH2, d.Op())
+	assert.NoError(t, d.Step())
+	assert.Equal(t, gevm.JUMP, d.Op())
+
+	// Step over the whole function
+	assert.NoError(t, d.Next())
+	assert.Equal(t, gevm.JUMPDEST, d.Op())
+	assert.Equal(t, uint64(7), d.PC())
+	assert.Equal(t, 7, d.Steps())
+}
+
+func TestPoke(t *testing.T) {
+	// SSTORE(0, 1), RETURN the value of slot 1
+	code := gevm.NewProgram().Sstore(0, 1).Push(1).Op(gevm.SLOAD).Push0().Op(gevm.MSTORE).Return(0, 32).Bytes()
+	d := New(newEVM(code))
+	assert.NoError(t, d.Step())
+	assert.NoError(t, d.SetStack(0, uint256.NewInt(7)))
+	assert.Error(t, d.SetStack(1, uint256.NewInt(7)))
+	d.SetStorage(common.HexToHash("0x1"), common.HexToHash("0x2a"))
+
+	assert.NoError(t, d.Continue())
+	slots := d.EVM().Storage.Slots()
+	assert.Equal(t, common.HexToHash("0x07"), slots[common.Hash{}])
+	assert.Equal(t, common.HexToHash("0x2a").Bytes(), d.Result().ReturnData)
+}
+
+func TestClose(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	d.Close()
+	assert.True(t, d.Finished())
+	assert.ErrorContains(t, d.Result().Err, "killed")
+}
@@ -0,0 +1,260 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Step is an executed instruction, along with the changes it made to the EVM state.
+type Step struct {
+	PC     uint64
+	Op     gevm.Opcode
+	Gas    uint64 // gas left before the instruction
+	Refund uint64 // refund counter before the instruction
+
+	Popped    []uint256.Int // stack items the instruction removed, bottom first
+	Pushed    []uint256.Int // stack items the instruction added, bottom first
+	Memory    *MemoryWrite  // nil if memory didn't change
+	Storage   *SlotWrite    // written storage slot, nil if none
+	Transient *SlotWrite    // written transient storage slot, nil if none
+
+	pokes      map[common.Hash]common.Hash // storage slots changed by the debugger before the instruction
+	stackPokes map[int]uint256.Int         // stack items changed by the debugger before the instruction, by index from the bottom
+}
+
+// MemoryWrite is a change of memory: memory is resized to Size, then New is written at Offset.
+type MemoryWrite struct {
+	Offset uint64
+	Old    []byte // previous contents of the written range, within the previous size
+	New    []byte
+	Size   uint64
+}
+
+// SlotWrite is a write to a storage or transient storage slot.
+type SlotWrite struct {
+	Slot common.Hash
+	Old  common.Hash
+	New  common.Hash
+}
+
+// Snapshot is the EVM state before a step.
+type Snapshot struct {
+	Step      int // index of the step about to execute, equal to the number of recorded steps once execution ended
+	PC        uint64
+	Op        gevm.Opcode
+	Gas       uint64
+	Refund    uint64
+	Stack     []uint256.Int // bottom first
+	Memory    []byte
+	Storage   map[common.Hash]common.Hash
+	Transient map[common.Hash]common.Hash
+}
+
+// Recorder is a tracer recording every step of an execution as a delta of the state before it,
+// so that the state at any earlier point can be reconstructed.
+type Recorder struct {
+	steps []*Step
+
+	// State at the start of the execution
+	initialStorage   map[common.Hash]common.Hash
+	initialTransient map[common.Hash]common.Hash
+
+	// State after the last recorded step, to compute the delta of the next one
+	stack  []uint256.Int
+	memory []byte
+	gas    uint64
+	refund uint64
+	ended  bool
+}
+
+// NewRecorder creates a recorder. Set it as the tracer of an EVM before running it.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Steps returns the recorded steps.
+func (r *Recorder) Steps() []*Step {
+	return r.steps
+}
+
+// CaptureState implements gevm.Tracer.
+func (r *Recorder) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	if len(r.steps) == 0 {
+		r.initialStorage = evm.Storage.Slots()
+		r.initialTransient = evm.Transient.Slots()
+	} else {
+		r.finish(evm)
+	}
+
+	step := &Step{PC: pc, Op: op, Gas: evm.Gas, Refund: evm.Refund}
+	// Record the slot and its value before the write, the new value is known once the instruction executed
+	if (op == gevm.SSTORE || op == gevm.TSTORE) && evm.Stack.Len() >= 2 {
+		slot := common.Hash(evm.Stack.Back(0).Bytes32())
+		if op == gevm.SSTORE {
+			old, _ := evm.Storage.Get(slot)
+			step.Storage = &SlotWrite{Slot: slot, Old: old}
+		} else {
+			step.Transient = &SlotWrite{Slot: slot, Old: evm.Transient.Load(slot)}
+		}
+	}
+	r.steps = append(r.steps, step)
+}
+
+// CaptureEnd implements gevm.Tracer.
+func (r *Recorder) CaptureEnd(evm *gevm.EVM) {
+	if len(r.steps) > 0 {
+		r.finish(evm)
+	}
+	r.gas, r.refund = evm.Gas, evm.Refund
+	r.ended = true
+}
+
+// finish records the changes made by the last step.
+func (r *Recorder) finish(evm *gevm.EVM) {
+	step := r.steps[len(r.steps)-1]
+
+	// Items below the deepest changed one are untouched
+	stack := evm.Data()
+	same := 0
+	for same < len(r.stack) && same < len(stack) && r.stack[same] == stack[same] {
+		same++
+	}
+	step.Popped = slices.Clone(r.stack[same:])
+	step.Pushed = slices.Clone(stack[same:])
+	r.stack = slices.Clone(stack)
+
+	if memory := evm.Memory.Data(); !bytes.Equal(memory, r.memory) {
+		step.Memory = diffMemory(r.memory, memory)
+		r.memory = bytes.Clone(memory)
+	}
+
+	// The write didn't happen if the instruction failed, which leaves the PC unchanged
+	failed := evm.PC == step.PC
+	if step.Storage != nil {
+		step.Storage.New, _ = evm.Storage.Get(step.Storage.Slot)
+		if failed {
+			step.Storage = nil
+		}
+	}
+	if step.Transient != nil {
+		step.Transient.New = evm.Transient.Load(step.Transient.Slot)
+		if failed {
+			step.Transient = nil
+		}
+	}
+}
+
+// diffMemory returns the smallest write turning old into new.
+func diffMemory(old, new []byte) *MemoryWrite {
+	start := 0
+	for start < len(old) && start < len(new) && old[start] == new[start] {
+		start++
+	}
+	end := len(new)
+	for end > start && end <= len(old) && old[end-1] == new[end-1] {
+		end--
+	}
+	w := &MemoryWrite{Offset: uint64(start), New: bytes.Clone(new[start:end]), Size: uint64(len(new))}
+	if start < len(old) {
+		w.Old = bytes.Clone(old[start:min(end, len(old))])
+	}
+	return w
+}
+
+// State reconstructs the EVM state before step n, by replaying the recorded deltas from the start of the execution.
+// n may be the number of recorded steps, for the state at the end of the execution.
+func (r *Recorder) State(n int) (*Snapshot, error) {
+	last := len(r.steps)
+	if !r.ended {
+		last-- // the state after the current step is not known yet
+	}
+	if n < 0 || n > last || last < 0 {
+		return nil, fmt.Errorf("step %d out of range [0, %d]", n, max(last, 0))
+	}
+
+	s := &Snapshot{
+		Step:      n,
+		Storage:   maps.Clone(r.initialStorage),
+		Transient: maps.Clone(r.initialTransient),
+	}
+	if s.Storage == nil {
+		s.Storage, s.Transient = make(map[common.Hash]common.Hash), make(map[common.Hash]common.Hash)
+	}
+	for _, step := range r.steps[:n] {
+		for i, value := range step.stackPokes {
+			s.Stack[i] = value
+		}
+		s.Stack = append(s.Stack[:len(s.Stack)-len(step.Popped)], step.Pushed...)
+		if w := step.Memory; w != nil {
+			if uint64(len(s.Memory)) < w.Size {
+				s.Memory = append(s.Memory, make([]byte, w.Size-uint64(len(s.Memory)))...)
+			}
+			copy(s.Memory[w.Offset:], w.New)
+		}
+		for slot, value := range step.pokes {
+			s.Storage[slot] = value
+		}
+		if w := step.Storage; w != nil {
+			s.Storage[w.Slot] = w.New
+		}
+		if w := step.Transient; w != nil {
+			s.Transient[w.Slot] = w.New
+		}
+	}
+	if len(s.Stack) == 0 {
+		s.Stack = nil
+	}
+	s.Stack = slices.Clone(s.Stack)
+
+	if n < len(r.steps) {
+		step := r.steps[n]
+		s.PC, s.Op, s.Gas, s.Refund = step.PC, step.Op, step.Gas, step.Refund
+	} else {
+		s.Gas, s.Refund = r.gas, r.refund
+	}
+	return s, nil
+}
+
+// poke records that the storage slot was changed from outside of the EVM before the current step.
+func (r *Recorder) poke(slot common.Hash, value common.Hash) {
+	if len(r.steps) == 0 {
+		return
+	}
+	step := r.steps[len(r.steps)-1]
+	if step.pokes == nil {
+		step.pokes = make(map[common.Hash]common.Hash)
+	}
+	step.pokes[slot] = value
+}
+
+// pokeStack records that the n-th stack item from the top was changed from outside of the EVM before the current step.
+func (r *Recorder) pokeStack(n int, value *uint256.Int) {
+	if len(r.steps) == 0 || n >= len(r.stack) {
+		return
+	}
+	step := r.steps[len(r.steps)-1]
+	if step.stackPokes == nil {
+		step.stackPokes = make(map[int]uint256.Int)
+	}
+	i := len(r.stack) - 1 - n
+	step.stackPokes[i] = *value
+	// The delta of the current step starts from the poked stack
+	r.stack[i] = *value
+}
+
+// LastWrite returns the index of the last step before step n that wrote a storage slot.
+func (r *Recorder) LastWrite(slot common.Hash, n int) (int, bool) {
+	for i := min(n, len(r.steps)) - 1; i >= 0; i-- {
+		if w := r.steps[i].Storage; w != nil && w.Slot == slot {
+			return i, true
+		}
+	}
+	return 0, false
+}
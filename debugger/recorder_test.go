@@ -0,0 +1,159 @@
+package debugger
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// snapshotTracer records the full state before every step, next to a Recorder.
+type snapshotTracer struct {
+	*Recorder
+	snapshots []*Snapshot
+}
+
+func (t *snapshotTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	t.snapshots = append(t.snapshots, &Snapshot{
+		Step:      len(t.snapshots),
+		PC:        pc,
+		Op:        op,
+		Gas:       evm.Gas,
+		Refund:    evm.Refund,
+		Stack:     slices.Clone(evm.Data()),
+		Memory:    bytes.Clone(evm.Memory.Data()),
+		Storage:   evm.Storage.Slots(),
+		Transient: evm.Transient.Slots(),
+	})
+	t.Recorder.CaptureState(evm, pc, op)
+}
+
+func TestRecorderState(t *testing.T) {
+	code := gevm.NewProgram().Sstore(1, 0xaa).
+		Push(3).Label("loop").Push(1).Op(gevm.SWAP1, gevm.SUB, gevm.DUP1, gevm.DUP1).Push0().Op(gevm.SSTORE).
+		Push(0x20).Op(gevm.MUL, gevm.DUP1, gevm.MSTORE8, gevm.DUP1).JumpI("loop").
+		Push(7).Push(2).Op(gevm.TSTORE).Mstore(0x40, 0x1234).Return(0, 0x60).Bytes()
+	evm := newEVM(code)
+	evm.Storage.Set(common.HexToHash("0x5"), common.HexToHash("0x05"))
+	tracer := &snapshotTracer{Recorder: NewRecorder()}
+	evm.Tracer = tracer
+	assert.NoError(t, evm.Execute().Err)
+
+	steps := tracer.Steps()
+	assert.Len(t, steps, len(tracer.snapshots))
+	for i, want := range tracer.snapshots {
+		got, err := tracer.State(i)
+		assert.NoError(t, err)
+		if len(want.Stack) == 0 {
+			want.Stack = nil
+		}
+		if len(want.Memory) == 0 {
+			want.Memory = nil
+		}
+		assert.Equal(t, want, got, "step %d", i)
+	}
+
+	final, err := tracer.State(len(steps))
+	assert.NoError(t, err)
+	assert.Equal(t, evm.Gas, final.Gas)
+	assert.True(t, maps.Equal(evm.Storage.Slots(), final.Storage))
+	assert.Equal(t, evm.Memory.Data(), final.Memory)
+	assert.Equal(t, common.HexToHash("0x07"), final.Transient[common.HexToHash("0x2")])
+
+	_, err = tracer.State(len(steps) + 1)
+	assert.Error(t, err)
+}
+
+func TestRecorderLastWrite(t *testing.T) {
+	code := gevm.NewProgram().Sstore(0, 1).Sstore(1, 2).Sstore(0, 3).Op(gevm.STOP).Bytes()
+	evm := newEVM(code)
+	r := NewRecorder()
+	evm.Tracer = r
+	evm.Execute()
+
+	n, ok := r.LastWrite(common.Hash{}, len(r.Steps()))
+	assert.True(t, ok)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, &SlotWrite{Old: common.HexToHash("0x01"), New: common.HexToHash("0x03")}, r.Steps()[n].Storage)
+
+	n, ok = r.LastWrite(common.Hash{}, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 2, n)
+
+	_, ok = r.LastWrite(common.HexToHash("0x2"), len(r.Steps()))
+	assert.False(t, ok)
+}
+
+func TestRecorderFailedWrite(t *testing.T) {
+	// SSTORE runs out of gas
+	evm := newEVM(gevm.NewProgram().Sstore(0, 1).Bytes())
+	evm.Gas = 1000
+	r := NewRecorder()
+	evm.Tracer = r
+	assert.Error(t, evm.Execute().Err)
+	assert.Nil(t, r.Steps()[2].Storage)
+}
+
+func TestDebuggerTimeTravel(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	d.Break(BreakSlot, uint256.NewInt(0))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, d.Continue())
+	}
+	assert.Equal(t, 27, d.Steps())
+
+	// The last write happened 10 steps before
+	step, n, ok := d.LastWrite(common.Hash{})
+	assert.True(t, ok)
+	assert.Equal(t, 17, n)
+	assert.Equal(t, common.HexToHash("0x01"), step.Storage.New)
+
+	past, err := d.StateAt(n)
+	assert.NoError(t, err)
+	assert.Equal(t, gevm.SSTORE, past.Op)
+	assert.Equal(t, common.HexToHash("0x02"), past.Storage[common.Hash{}])
+	assert.Equal(t, d.State().Stack, mustState(t, d, 27).Stack)
+
+	_, err = d.StateAt(28)
+	assert.Error(t, err, "the future can't be reconstructed")
+
+	assert.Error(t, d.RunTo(20))
+	assert.NoError(t, d.RunTo(30))
+	assert.Equal(t, 30, d.Steps())
+}
+
+func TestDebuggerPokeIsRecorded(t *testing.T) {
+	d := New(newEVM(gevm.NewProgram().Op(gevm.STOP).Bytes()))
+	d.SetStorage(common.HexToHash("0x3"), common.HexToHash("0x33"))
+	assert.NoError(t, d.Step())
+	assert.Equal(t, common.HexToHash("0x33"), mustState(t, d, 1).Storage[common.HexToHash("0x3")])
+	assert.Empty(t, mustState(t, d, 0).Storage)
+}
+
+func TestDebuggerStackPokeIsRecorded(t *testing.T) {
+	d := New(newEVM(gevm.NewProgram().Push(1).Push(2).Op(gevm.ADD, gevm.STOP).Bytes()))
+	assert.NoError(t, d.Step())
+	assert.NoError(t, d.SetStack(0, uint256.NewInt(7)))
+	assert.NoError(t, d.Step())
+	assert.NoError(t, d.Step())
+
+	// Going back, the poked item is seen from the step it was poked before
+	assert.Equal(t, []uint256.Int{*uint256.NewInt(1)}, mustState(t, d, 1).Stack)
+	assert.Equal(t, []uint256.Int{*uint256.NewInt(7), *uint256.NewInt(2)}, mustState(t, d, 2).Stack)
+	assert.Equal(t, []uint256.Int{*uint256.NewInt(9)}, mustState(t, d, 3).Stack)
+	// The poke isn't part of what the next instruction pushed
+	assert.Empty(t, d.Recorder().Steps()[1].Popped)
+	assert.Equal(t, []uint256.Int{*uint256.NewInt(2)}, d.Recorder().Steps()[1].Pushed)
+}
+
+func mustState(t *testing.T, d *Debugger, n int) *Snapshot {
+	s, err := d.StateAt(n)
+	assert.NoError(t, err)
+	return s
+}
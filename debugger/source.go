@@ -0,0 +1,72 @@
+package debugger
+
+import (
+	"errors"
+
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+// errNoSourceMap is returned when stepping through the source without a source map.
+var errNoSourceMap = errors.New("no source map, see SetSourceMap")
+
+// SetSourceMap sets the source map of the code being debugged, enabling source level stepping.
+func (d *Debugger) SetSourceMap(m *sourcemap.Map) {
+	d.sourceMap = m
+}
+
+// SourceMap returns the source map of the code being debugged, nil if none was set.
+func (d *Debugger) SourceMap() *sourcemap.Map {
+	return d.sourceMap
+}
+
+// Location returns the source location of the instruction execution paused before.
+func (d *Debugger) Location() (sourcemap.Location, bool) {
+	if d.sourceMap == nil {
+		return sourcemap.Location{}, false
+	}
+	return d.sourceMap.Lookup(d.pc)
+}
+
+// StepIn runs until the execution reaches another source line, entering the functions called on the current one.
+func (d *Debugger) StepIn() error {
+	return d.stepSource(func(depth int, moved bool) bool { return moved })
+}
+
+// StepOver runs until the execution reaches another source line of the current function,
+// or of its caller if it returns.
+func (d *Debugger) StepOver() error {
+	return d.stepSource(func(depth int, moved bool) bool { return moved && depth <= 0 })
+}
+
+// StepOut runs until the current function returns to its caller.
+func (d *Debugger) StepOut() error {
+	return d.stepSource(func(depth int, moved bool) bool { return depth < 0 })
+}
+
+// stepSource runs until stop returns true. Before each instruction, stop is given the number of functions entered
+// since the start, negative once the starting function returned, and whether the instruction is on another line.
+// Function calls and returns are the jumps marked as such in the source map.
+func (d *Debugger) stepSource(stop func(depth int, moved bool) bool) error {
+	if d.sourceMap == nil {
+		return errNoSourceMap
+	}
+	start, hasStart := d.Location()
+	depth := 0
+	return d.run(func(pc uint64) bool {
+		// The previous instruction is the one that just executed
+		if e, ok := d.sourceMap.Entry(d.prevPC); ok {
+			switch e.Jump {
+			case sourcemap.JumpIn:
+				depth++
+			case sourcemap.JumpOut:
+				depth--
+			}
+		}
+		loc, ok := d.sourceMap.Lookup(pc)
+		if !ok || loc.Line == 0 {
+			return false
+		}
+		moved := !hasStart || loc.Source != start.Source || loc.Line != start.Line
+		return stop(depth, moved)
+	})
+}
@@ -0,0 +1,65 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/sourcemap"
+)
+
+func newSourceDebugger(t *testing.T) *Debugger {
+	contracts, err := sourcemap.Load("../sourcemap/testdata/Counter.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New(newEVM(contracts[0].DeployedBytecode.Code))
+	d.SetSourceMap(contracts[0].Map)
+	t.Cleanup(d.Close)
+	return d
+}
+
+func line(t *testing.T, d *Debugger) int {
+	loc, ok := d.Location()
+	assert.True(t, ok)
+	return loc.Line
+}
+
+func TestStepIn(t *testing.T) {
+	d := newSourceDebugger(t)
+	assert.Equal(t, 8, line(t, d))
+
+	// Into add, then through its body and back to the caller
+	for _, want := range []int{11, 12, 11, 8, 7} {
+		assert.NoError(t, d.StepIn())
+		assert.Equal(t, want, line(t, d))
+	}
+	assert.NoError(t, d.StepIn())
+	assert.True(t, d.Finished())
+}
+
+func TestStepOver(t *testing.T) {
+	d := newSourceDebugger(t)
+	assert.NoError(t, d.StepOver())
+	assert.Equal(t, 7, line(t, d))
+	assert.Equal(t, uint64(12), d.PC())
+}
+
+func TestStepOut(t *testing.T) {
+	d := newSourceDebugger(t)
+	assert.NoError(t, d.StepIn())
+	assert.NoError(t, d.StepIn())
+	assert.Equal(t, 12, line(t, d))
+
+	assert.NoError(t, d.StepOut())
+	assert.Equal(t, uint64(9), d.PC()) // the JUMPDEST add returns to
+	assert.Equal(t, 8, line(t, d))
+}
+
+func TestStepWithoutSourceMap(t *testing.T) {
+	d := New(newEVM(countdown.Bytes()))
+	defer d.Close()
+	assert.ErrorIs(t, d.StepIn(), errNoSourceMap)
+	_, ok := d.Location()
+	assert.False(t, ok)
+}
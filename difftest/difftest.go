@@ -0,0 +1,381 @@
+// Package difftest runs the same transaction through gevm and through go-ethereum's core/vm,
+// comparing them step by step to find where gevm diverges from the reference implementation.
+package difftest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Env describes a single execution: the code of the receiving contract and the transaction calling it.
+type Env struct {
+	Code     []byte
+	Storage  map[common.Hash]common.Hash // initial storage of the receiver
+	Calldata []byte
+	Gas      uint64 // transaction gas limit, including the intrinsic gas
+	Value    *uint256.Int
+	Sender   common.Address
+	Receiver common.Address
+	Block    *gevm.Block
+	ChainID  uint64
+	GasLimit uint64    // block gas limit
+	Fork     gevm.Fork // rules both EVMs execute with, the latest supported fork if empty
+
+	// Natives are gevm's native contracts, by address. geth runs its own contracts at those addresses, so they should
+	// be precompiles gevm behaves like.
+	Natives map[common.Address]gevm.NativeContract
+}
+
+// Divergence describes the first difference found between gevm and geth.
+type Divergence struct {
+	Step  int         // index of the step that produced the difference, -1 if it can't be attributed to a step
+	PC    uint64      // program counter of that step
+	Op    gevm.Opcode // opcode of that step
+	Field string      // what differs: "pc", "opcode", "gas", "stack", "memory", "halt", "return data", ...
+	Gevm  string
+	Geth  string
+}
+
+// String describes the divergence.
+func (d *Divergence) String() string {
+	if d.Step < 0 {
+		return fmt.Sprintf("%s differs\n  gevm: %s\n  geth: %s", d.Field, d.Gevm, d.Geth)
+	}
+	return fmt.Sprintf("step %d (pc %d, %v): %s differs\n  gevm: %s\n  geth: %s", d.Step, d.PC, d.Op, d.Field, d.Gevm, d.Geth)
+}
+
+// step is a snapshot of the EVM state right before an opcode is executed.
+type step struct {
+	pc     uint64
+	op     gevm.Opcode
+	gas    uint64
+	stack  []uint256.Int
+	memory []byte
+}
+
+// Run executes env in both EVMs and returns the first divergence, or nil if they behaved identically.
+func Run(env *Env) (*Divergence, error) {
+	gevmSteps, gevmRes, gevmState, err := runGevm(env)
+	if err != nil {
+		return nil, fmt.Errorf("gevm: %w", err)
+	}
+	gethSteps, gethRes, gethState, err := runGeth(env)
+	if err != nil {
+		return nil, fmt.Errorf("geth: %w", err)
+	}
+
+	// The state before step i is the result of step i-1, so differences are reported against the previous step
+	for i := 0; i < max(len(gevmSteps), len(gethSteps)); i++ {
+		at := func(field, gevmVal, gethVal string) *Divergence {
+			d := &Divergence{Step: i, Field: field, Gevm: gevmVal, Geth: gethVal}
+			if i > 0 {
+				d.Step, d.PC, d.Op = i-1, gevmSteps[i-1].pc, gevmSteps[i-1].op
+			}
+			return d
+		}
+		if i >= len(gevmSteps) {
+			return at("halt", fmt.Sprintf("halted (%s)", haltReason(gevmRes.Err)), fmt.Sprintf("executes pc %d (%v)", gethSteps[i].pc, gethSteps[i].op)), nil
+		}
+		if i >= len(gethSteps) {
+			return at("halt", fmt.Sprintf("executes pc %d (%v)", gevmSteps[i].pc, gevmSteps[i].op), fmt.Sprintf("halted (%s)", haltReason(gethRes.Err))), nil
+		}
+		g, r := gevmSteps[i], gethSteps[i]
+		switch {
+		case g.pc != r.pc:
+			return at("pc", fmt.Sprint(g.pc), fmt.Sprint(r.pc)), nil
+		case g.op != r.op:
+			return at("opcode", g.op.String(), r.op.String()), nil
+		case g.gas != r.gas:
+			return at("gas", fmt.Sprint(g.gas), fmt.Sprint(r.gas)), nil
+		case !slices.Equal(g.stack, r.stack):
+			return at("stack", stackString(g.stack), stackString(r.stack)), nil
+		case !bytes.Equal(g.memory, r.memory):
+			return at("memory", hexutil.Encode(g.memory), hexutil.Encode(r.memory)), nil
+		}
+	}
+
+	// The effects of the last step only show up in the outcome of the execution
+	last := len(gevmSteps) - 1
+	if last >= 0 && gevmSteps[last].pc >= uint64(len(env.Code)) {
+		last-- // skip the implicit STOP
+	}
+	after := func(step int, field, gevmVal, gethVal string) *Divergence {
+		d := &Divergence{Step: -1, Field: field, Gevm: gevmVal, Geth: gethVal}
+		if step >= 0 {
+			d.Step, d.PC, d.Op = step, gevmSteps[step].pc, gevmSteps[step].op
+		}
+		return d
+	}
+	if haltReason(gevmRes.Err) != haltReason(gethRes.Err) {
+		return after(last, "halt", errString(gevmRes.Err), errString(gethRes.Err)), nil
+	}
+	if !bytes.Equal(gevmRes.ReturnData, gethRes.ReturnData) {
+		return after(last, "return data", hexutil.Encode(gevmRes.ReturnData), hexutil.Encode(gethRes.ReturnData)), nil
+	}
+	if gevmRes.UsedGas != gethRes.UsedGas {
+		return after(last, "gas used", fmt.Sprint(gevmRes.UsedGas), fmt.Sprint(gethRes.UsedGas)), nil
+	}
+	if d := diffLogs(gevmRes.Logs, gethRes.Logs); d != nil {
+		return after(last, d.Field, d.Gevm, d.Geth), nil
+	}
+	// Storage differences are reported against the last SSTORE gevm executed on the slot
+	lastWrite := make(map[common.Hash]int)
+	for i, s := range gevmSteps {
+		if s.op == gevm.SSTORE && len(s.stack) > 0 {
+			lastWrite[s.stack[len(s.stack)-1].Bytes32()] = i
+		}
+	}
+	for _, slot := range writtenSlots(env, gevmSteps, gethSteps) {
+		var gevmVal common.Hash
+		if acc := gevmState[env.Receiver]; acc != nil {
+			gevmVal = acc.Storage[slot]
+		}
+		gethVal := gethState.GetState(env.Receiver, slot)
+		if gevmVal != gethVal {
+			step, ok := lastWrite[slot]
+			if !ok {
+				step = -1
+			}
+			return after(step, fmt.Sprintf("storage slot %v", slot), gevmVal.Hex(), gethVal.Hex()), nil
+		}
+	}
+	return nil, nil
+}
+
+// result is the part of an execution result compared after execution.
+type result struct {
+	Err        error
+	ReturnData []byte
+	UsedGas    uint64
+	Logs       []*types.Log
+}
+
+type gevmTracer struct {
+	steps []step
+}
+
+func (t *gevmTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	t.steps = append(t.steps, step{
+		pc:     pc,
+		op:     op,
+		gas:    evm.Gas,
+		stack:  slices.Clone(evm.Data()),
+		memory: bytes.Clone(evm.Memory.Data()),
+	})
+}
+
+// CaptureEnd records the implicit STOP executed when the code runs out, like geth does.
+// This makes the effects of the last opcode comparable.
+func (t *gevmTracer) CaptureEnd(evm *gevm.EVM) {
+	if evm.PC >= uint64(len(evm.Code)) && !evm.StopFlag && !evm.RevertFlag {
+		t.CaptureState(evm, evm.PC, gevm.STOP)
+	}
+}
+
+func runGevm(env *Env) ([]step, *result, gevm.WorldState, error) {
+	ws := gevm.NewWorldState()
+	ws.GetOrNewAccount(env.Sender).Balance = senderBalance()
+	receiver := ws.GetOrNewAccount(env.Receiver)
+	receiver.Code = env.Code
+	for k, v := range env.Storage {
+		receiver.Storage[k] = v
+	}
+
+	tracer := new(gevmTracer)
+	msg := &gevm.Message{
+		From:     env.Sender,
+		To:       &env.Receiver,
+		Value:    env.Value,
+		GasLimit: env.Gas,
+		GasPrice: env.Block.GasPrice,
+		Data:     env.Calldata,
+	}
+	res, err := gevm.ApplyMessage(ws, msg, env.Block, gevm.ChainConfig{ChainID: env.ChainID, GasLimit: env.GasLimit, Fork: env.Fork, Natives: env.Natives}, tracer)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tracer.steps, &result{Err: res.Err, ReturnData: res.ReturnData, UsedGas: res.UsedGas, Logs: res.Logs}, ws, nil
+}
+
+func runGeth(env *Env) ([]step, *result, *state.StateDB, error) {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, err := state.New(types.EmptyRootHash, db, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	statedb.SetBalance(env.Sender, senderBalance(), tracing.BalanceChangeUnspecified)
+	statedb.SetCode(env.Receiver, env.Code)
+	for k, v := range env.Storage {
+		statedb.SetState(env.Receiver, k, v)
+	}
+	// Commit the initial state, so that SSTORE sees the initial storage as the original values of the slots
+	root, err := statedb.Commit(0, true)
+	if err == nil {
+		statedb, err = state.New(root, db, nil)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var steps []step
+	hooks := &tracing.Hooks{
+		OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			// gevm has no message calls, nested frames can't be compared
+			if depth > 1 {
+				return
+			}
+			steps = append(steps, step{
+				pc:     pc,
+				op:     gevm.Opcode(op),
+				gas:    gas,
+				stack:  slices.Clone(scope.StackData()),
+				memory: bytes.Clone(scope.MemoryData()),
+			})
+		},
+	}
+
+	chainConfig := *params.MergedTestChainConfig
+	chainConfig.ChainID = new(big.Int).SetUint64(env.ChainID)
+	if env.Fork == gevm.Shanghai {
+		chainConfig.CancunTime = nil
+	}
+	gasPrice := new(big.Int)
+	if env.Block.GasPrice != nil {
+		gasPrice = env.Block.GasPrice.ToBig()
+	}
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Coinbase:    env.Block.Coinbase,
+		GasLimit:    env.GasLimit,
+		BlockNumber: new(big.Int).SetUint64(env.Block.Number),
+		Time:        uint64(env.Block.Timestamp.Unix()),
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int).SetUint64(env.Block.BaseFee),
+		BlobBaseFee: big.NewInt(1),
+		Random:      &common.Hash{},
+	}
+	txCtx := vm.TxContext{Origin: env.Sender, GasPrice: gasPrice}
+	evm := vm.NewEVM(blockCtx, txCtx, statedb, &chainConfig, vm.Config{Tracer: hooks})
+
+	value := new(big.Int)
+	if env.Value != nil {
+		value = env.Value.ToBig()
+	}
+	msg := &core.Message{
+		From:      env.Sender,
+		To:        &env.Receiver,
+		Value:     value,
+		GasLimit:  env.Gas,
+		GasPrice:  gasPrice,
+		GasFeeCap: gasPrice,
+		GasTipCap: gasPrice,
+		Data:      env.Calldata,
+	}
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(env.GasLimit))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	logs := statedb.Logs()
+	if res.Failed() {
+		logs = nil
+	}
+	return steps, &result{Err: res.Err, ReturnData: res.ReturnData, UsedGas: res.UsedGas, Logs: logs}, statedb, nil
+}
+
+// haltReason classifies how an execution ended, ignoring the implementation specific error messages.
+func haltReason(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, gevm.ErrExecutionReverted), errors.Is(err, vm.ErrExecutionReverted):
+		return "revert"
+	default:
+		return "exceptional halt"
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return haltReason(err)
+	}
+	return fmt.Sprintf("%s (%v)", haltReason(err), err)
+}
+
+func diffLogs(gevmLogs, gethLogs []*types.Log) *Divergence {
+	if len(gevmLogs) != len(gethLogs) {
+		return &Divergence{Field: "log count", Gevm: fmt.Sprint(len(gevmLogs)), Geth: fmt.Sprint(len(gethLogs))}
+	}
+	for i := range gevmLogs {
+		g, r := gevmLogs[i], gethLogs[i]
+		field := fmt.Sprintf("log %d", i)
+		switch {
+		case g.Address != r.Address:
+			return &Divergence{Field: field + " address", Gevm: g.Address.Hex(), Geth: r.Address.Hex()}
+		case !slices.Equal(g.Topics, r.Topics):
+			return &Divergence{Field: field + " topics", Gevm: fmt.Sprint(g.Topics), Geth: fmt.Sprint(r.Topics)}
+		case !bytes.Equal(g.Data, r.Data):
+			return &Divergence{Field: field + " data", Gevm: hexutil.Encode(g.Data), Geth: hexutil.Encode(r.Data)}
+		}
+	}
+	return nil
+}
+
+// writtenSlots returns the initial storage slots of the receiver and every slot either EVM stored to.
+func writtenSlots(env *Env, traces ...[]step) []common.Hash {
+	var slots []common.Hash
+	seen := make(map[common.Hash]bool)
+	add := func(slot common.Hash) {
+		if !seen[slot] {
+			seen[slot] = true
+			slots = append(slots, slot)
+		}
+	}
+	for slot := range env.Storage {
+		add(slot)
+	}
+	for _, steps := range traces {
+		for _, s := range steps {
+			if s.op == gevm.SSTORE && len(s.stack) > 0 {
+				add(s.stack[len(s.stack)-1].Bytes32())
+			}
+		}
+	}
+	slices.SortFunc(slots, func(a, b common.Hash) int { return bytes.Compare(a[:], b[:]) })
+	return slots
+}
+
+func stackString(stack []uint256.Int) string {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i := len(stack) - 1; i >= 0; i-- {
+		buf.WriteString(stack[i].Hex())
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// senderBalance is large enough for any gas limit and value used in practice.
+func senderBalance() *uint256.Int {
+	return new(uint256.Int).Lsh(uint256.NewInt(1), 128)
+}
@@ -0,0 +1,134 @@
+package difftest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func newEnv(code []byte) *Env {
+	return &Env{
+		Code:     code,
+		Gas:      100000,
+		Sender:   common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"),
+		Receiver: common.HexToAddress("0x1000"),
+		Block:    gevm.NewBlock(common.HexToAddress("0xc0ffee"), 10, 1, 0, 7, time.Unix(1000, 0)),
+		ChainID:  1,
+		GasLimit: 30_000_000,
+		Storage:  map[common.Hash]common.Hash{common.HexToHash("0x09"): common.HexToHash("0x05")},
+	}
+}
+
+func TestRunIdentical(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+	}{
+		{"Add", gevm.NewProgram().Push(1).Push(2).Op(gevm.ADD).Bytes()},
+		{"Store", gevm.NewProgram().Push(1).Push0().Op(gevm.SSTORE).Bytes()},
+		{"Slot beyond 64 bits", gevm.NewProgram().Sstore(uint256.MustFromHex("0x10000000000000002"), 1).Sstore(2, 3).
+			Push(uint256.MustFromHex("0x10000000000000002")).Op(gevm.SLOAD).Bytes()},
+		{"Revert", gevm.NewProgram().Push0().Push0().Op(gevm.REVERT).Bytes()},
+		{"Return", gevm.NewProgram().Mstore(0, 42).Return(0, 32).Bytes()},
+		{"Stack underflow", gevm.NewProgram().Op(gevm.ADD).Bytes()},
+		{"Invalid", gevm.NewProgram().Op(gevm.INVALID).Bytes()},
+		{"Jumpi not taken to an invalid destination", gevm.NewProgram().Push0().Push(0xff).Op(gevm.JUMPI).Push(1).Bytes()},
+		{"Jumpi taken to an invalid destination", gevm.NewProgram().Push(1).Push(0xff).Op(gevm.JUMPI).Bytes()},
+		{"Jump", gevm.NewProgram().Jump("store").Op(gevm.STOP).Label("store").Sstore(0, 1).Op(gevm.STOP).Bytes()},
+		{"Caller and origin", gevm.NewProgram().Op(gevm.CALLER, gevm.ORIGIN).Bytes()},
+		{"Balance", gevm.NewProgram().Op(gevm.CALLER, gevm.BALANCE).Push(0xdead).Op(gevm.BALANCE).Bytes()},
+		{"Log0", gevm.NewProgram().Mstore(0, 1).Push(0x20).Push0().Op(gevm.LOG0).Bytes()},
+		{"Log1", gevm.NewProgram().Push(1).Push(0x40).Push(0x10).Op(gevm.LOG1).Bytes()},
+		{"Log2", gevm.NewProgram().Push(2).Push(1).Push(0x21).Push0().Op(gevm.LOG2).Bytes()},
+		{"Log3", gevm.NewProgram().Push(3).Push(2).Push(1).Push0().Push0().Op(gevm.LOG3).Bytes()},
+		{"Log4", gevm.NewProgram().Push(4).Push(3).Push(2).Push(1).Push(0x100).Push(0x20).Op(gevm.LOG4).Bytes()},
+		{"Keccak256", gevm.NewProgram().Push(0x45).Push(3).Op(gevm.KECCAK256).Bytes()},
+		{"Exp", gevm.NewProgram().Push(0x1234).Push(3).Op(gevm.EXP).Push(uint256.MustFromHex("0x10000000000000002")).Push(2).Op(gevm.EXP).Bytes()},
+		{"Memory expansion", gevm.NewProgram().Mstore(0x400, 1).Push(0x2000).Op(gevm.MLOAD).Push(0x3000).Push(0xff).Op(gevm.MSTORE8).Bytes()},
+		{"Calldatacopy", gevm.NewProgram().Push(0x44).Push0().Push(0x20).Op(gevm.CALLDATACOPY).Bytes()},
+		{"Codecopy", gevm.NewProgram().Push(0x30).Push(1).Push(0x45).Op(gevm.CODECOPY).Bytes()},
+		{"Mcopy", gevm.NewProgram().Mstore(0, 7).Push(0x20).Push0().Push(0x81).Op(gevm.MCOPY).Bytes()},
+		{"Mcopy from beyond the destination", gevm.NewProgram().Push(0x20).Push(0x200).Push0().Op(gevm.MCOPY).Bytes()},
+		{"Mcopy of overlapping ranges", gevm.NewProgram().Mstore(0, common.HexToHash("0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")).
+			Push(0x20).Push0().Push(0x10).Op(gevm.MCOPY).Push(0x20).Push(0x08).Push0().Op(gevm.MCOPY).Push0().Op(gevm.MLOAD).Bytes()},
+		{"Mstore8", gevm.NewProgram().Push0().Push(0x41).Op(gevm.MSTORE8).Push(0x1234).Push(0x42).Op(gevm.MSTORE8, gevm.MSIZE).Bytes()},
+		{"Return beyond memory", gevm.NewProgram().Mstore(0, 1).Return(0x100, 0x21).Bytes()},
+		{"Revert beyond memory", gevm.NewProgram().Revert(0x80, 0x20).Bytes()},
+		{"Extcodesize", gevm.NewProgram().Op(gevm.CALLER, gevm.EXTCODESIZE, gevm.ADDRESS, gevm.EXTCODESIZE).
+			Push(0xdead).Op(gevm.EXTCODESIZE).Push(0xdead).Op(gevm.EXTCODESIZE).Bytes()},
+		{"Extcodehash", gevm.NewProgram().Op(gevm.CALLER, gevm.EXTCODEHASH, gevm.ADDRESS, gevm.EXTCODEHASH).
+			Push(0xdead).Op(gevm.EXTCODEHASH).Push(0xdead).Op(gevm.EXTCODEHASH).Bytes()},
+		{"Extcodecopy", gevm.NewProgram().Push(0x30).Push0().Push(0x11).Op(gevm.CALLER, gevm.EXTCODECOPY).Bytes()},
+		{"Warm and cold slots", gevm.NewProgram().Push(5).Op(gevm.SLOAD).Push(5).Op(gevm.SLOAD).Sstore(5, 1).Sstore(5, 0).Sstore(6, 0).Bytes()},
+		{"Rewritten slots", gevm.NewProgram().Sstore(7, 1).Sstore(7, 2).Sstore(7, 0).Sstore(7, 3).Sstore(8, 0).Bytes()},
+		{"Slot with a value", gevm.NewProgram().Sstore(9, 0).Sstore(9, 1).Sstore(9, 0).Sstore(9, 5).Sstore(9, 6).Sstore(9, 5).Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Run(newEnv(tt.code))
+			assert.NoError(t, err)
+			assert.Nil(t, d)
+		})
+	}
+}
+
+func TestRunIdenticalEnv(t *testing.T) {
+	identity := common.BytesToAddress([]byte{0x04})
+	tests := []struct {
+		name    string
+		code    []byte
+		fork    gevm.Fork
+		natives map[common.Address]gevm.NativeContract
+	}{
+		{
+			name: "Point evaluation precompile is warm in Cancun",
+			code: gevm.NewProgram().Push(0x0a).Op(gevm.BALANCE).Push(0x09).Op(gevm.BALANCE).Bytes(),
+			fork: gevm.Cancun,
+		},
+		{
+			name: "Point evaluation precompile is cold before Cancun",
+			code: gevm.NewProgram().Push(0x0a).Op(gevm.BALANCE).Push(0x09).Op(gevm.BALANCE).Bytes(),
+			fork: gevm.Shanghai,
+		},
+		{
+			// The receiver has no balance, so the call fails in both after charging for the value transfer
+			name:    "Native call with value",
+			code:    gevm.NewProgram().Mstore(0, 0xaa).Call(nil, identity, 1, 0, 0x20, 0, 0x20).Op(gevm.GAS).Bytes(),
+			natives: map[common.Address]gevm.NativeContract{identity: identityContract{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newEnv(tt.code)
+			env.Fork, env.Natives = tt.fork, tt.natives
+			d, err := Run(env)
+			assert.NoError(t, err)
+			assert.Nil(t, d)
+		})
+	}
+}
+
+// identityContract is a native contract returning its input, like the identity precompile.
+type identityContract struct{}
+
+func (identityContract) Run(evm *gevm.EVM, input []byte) ([]byte, error) { return input, nil }
+
+func TestRunDivergence(t *testing.T) {
+	// BLOCKHASH is mocked in gevm
+	code := gevm.NewProgram().Push(1).Push(0).Op(gevm.BLOCKHASH, gevm.STOP).Bytes()
+	d, err := Run(newEnv(code))
+	assert.NoError(t, err)
+	if assert.NotNil(t, d) {
+		assert.Equal(t, 2, d.Step)
+		assert.Equal(t, uint64(4), d.PC)
+		assert.Equal(t, gevm.BLOCKHASH, d.Op)
+		assert.Equal(t, "stack", d.Field)
+	}
+}
@@ -0,0 +1,30 @@
+package gevm
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Gas costs of accessing accounts (EIP-2929).
+const (
+	coldAccountAccessCost uint64 = 2600
+	warmAccessCost        uint64 = 100
+)
+
+// addressAccessCost returns the gas cost of accessing an account, then marks it as accessed.
+// The sender, the executing account, the coinbase (EIP-3651) and the precompiles are always warm.
+func (evm *EVM) addressAccessCost(addr common.Address) uint64 {
+	isCoinbase := evm.Block != nil && addr == evm.Block.Coinbase
+	if evm.warmAddresses[addr] || addr == evm.Sender || addr == evm.Address || isCoinbase || IsPrecompile(addr, evm.Fork) {
+		return warmAccessCost
+	}
+	evm.warmAddresses[addr] = true
+	return coldAccountAccessCost
+}
+
+// IsPrecompile reports whether addr is one of the precompiled contracts of the fork: 0x01 to 0x09, and the point
+// evaluation precompile 0x0a since Cancun (EIP-4844).
+func IsPrecompile(addr common.Address, fork Fork) bool {
+	last := byte(0x0a)
+	if fork == Shanghai {
+		last = 0x09
+	}
+	return addr != (common.Address{}) && addr.Cmp(common.BytesToAddress([]byte{last})) <= 0
+}
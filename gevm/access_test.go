@@ -0,0 +1,125 @@
+package gevm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressAccessCost(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+		contract = common.HexToAddress("0x1000")
+		coinbase = common.HexToAddress("0xc0ffee")
+		other    = common.HexToAddress("0xbeef")
+	)
+	tests := []struct {
+		name string
+		addr common.Address
+		want uint64
+	}{
+		{"Cold", other, coldAccountAccessCost},
+		{"Sender", sender, warmAccessCost},
+		{"Executing account", contract, warmAccessCost},
+		{"Coinbase", coinbase, warmAccessCost},
+		{"Precompile", common.HexToAddress("0x01"), warmAccessCost},
+		{"Last precompile", common.HexToAddress("0x0a"), warmAccessCost},
+		{"Zero address", common.Address{}, coldAccountAccessCost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evm := NewEVM(sender, 100_000, 0, 1, 30_000_000, nil, nil, NewBlock(coinbase, 0, 1, 0, 0, time.Unix(0, 0)))
+			evm.Address = contract
+			assert.Equal(t, tt.want, evm.addressAccessCost(tt.addr))
+			assert.Equal(t, warmAccessCost, evm.addressAccessCost(tt.addr), "accessed accounts are warm")
+		})
+	}
+}
+
+func TestAccessListWarmsAddresses(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+		contract = common.HexToAddress("0x1000")
+		other    = common.HexToAddress("0xbeef")
+	)
+	code := NewProgram().Push(other.Bytes()).Op(BALANCE, STOP).Bytes()
+	gasUsed := func(accessList types.AccessList) uint64 {
+		state := NewWorldState()
+		state.GetOrNewAccount(sender).Balance = uint256.NewInt(1e18)
+		state.GetOrNewAccount(contract).Code = code
+		msg := &Message{From: sender, To: &contract, GasLimit: 100_000, AccessList: accessList}
+		res, err := ApplyMessage(state, msg, NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0)), ChainConfig{ChainID: 1, GasLimit: 30_000_000}, nil)
+		require.NoError(t, err)
+		require.NoError(t, res.Err)
+		return res.UsedGas
+	}
+	cold := gasUsed(nil)
+	assert.Equal(t, 21000+3+coldAccountAccessCost, cold)
+	assert.Equal(t, cold+txAccessListAddress-(coldAccountAccessCost-warmAccessCost), gasUsed(types.AccessList{{Address: other}}))
+}
+
+func TestAccessCosts(t *testing.T) {
+	other := common.HexToAddress("0xbeef")
+	tests := []struct {
+		name    string
+		program *Program
+		want    uint64
+	}{
+		{"Repeated SLOAD of an unset slot", NewProgram().Push(1).Op(SLOAD).Push(1).Op(SLOAD), 3 + 2100 + 3 + 100},
+		{"Repeated EXTCODESIZE", NewProgram().Push(other.Bytes()).Op(EXTCODESIZE).Push(other.Bytes()).Op(EXTCODESIZE), 3 + coldAccountAccessCost + 3 + warmAccessCost},
+		{"Repeated EXTCODEHASH", NewProgram().Push(other.Bytes()).Op(EXTCODEHASH).Push(other.Bytes()).Op(EXTCODEHASH), 3 + coldAccountAccessCost + 3 + warmAccessCost},
+		{"EXTCODEHASH after BALANCE", NewProgram().Push(other.Bytes()).Op(BALANCE).Push(other.Bytes()).Op(EXTCODEHASH), 3 + coldAccountAccessCost + 3 + warmAccessCost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evm := setupEVM()
+			evm.Verbose = false
+			evm.Gas = 100_000
+			evm.Code = tt.program.Bytes()
+			result := evm.Execute()
+			require.NoError(t, result.Err)
+			assert.Equal(t, tt.want, 100_000-evm.Gas)
+		})
+	}
+}
+
+func TestExtcodehash(t *testing.T) {
+	var (
+		withCode = common.HexToAddress("0xc0de")
+		funded   = common.HexToAddress("0xf00d")
+		empty    = common.HexToAddress("0xe")
+	)
+	code := []byte{byte(STOP)}
+	tests := []struct {
+		name string
+		addr common.Address
+		want common.Hash
+	}{
+		{"Account with code", withCode, crypto.Keccak256Hash(code)},
+		{"Account without code", funded, crypto.Keccak256Hash(nil)},
+		{"Empty account", empty, common.Hash{}},
+		{"Missing account", common.HexToAddress("0xbeef"), common.Hash{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evm := setupEVM()
+			evm.Verbose = false
+			evm.Gas = 100_000
+			evm.State = NewWorldState()
+			evm.State.GetOrNewAccount(withCode).Code = code
+			evm.State.GetOrNewAccount(funded).Balance = uint256.NewInt(1)
+			evm.State.GetOrNewAccount(empty)
+			evm.Code = NewProgram().Push(tt.addr.Bytes()).Op(EXTCODEHASH).Bytes()
+			result := evm.Execute()
+			require.NoError(t, result.Err)
+			got := evm.Stack.Peek()
+			assert.Equal(t, tt.want, common.Hash(got.Bytes32()))
+		})
+	}
+}
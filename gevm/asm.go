@@ -0,0 +1,447 @@
+package gevm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/holiman/uint256"
+)
+
+// AsmError is an error found while assembling, located at a line and column of the source (both starting at 1).
+type AsmError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *AsmError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// maxMacroDepth bounds macro expansion, so that recursive macros fail instead of looping forever.
+const maxMacroDepth = 32
+
+type token struct {
+	text      string
+	line, col int
+}
+
+func (t token) errorf(format string, args ...any) *AsmError {
+	return &AsmError{Line: t.line, Col: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+type macro struct {
+	params []string
+	body   []token
+}
+
+// asmItem is an instruction of the program being assembled.
+type asmItem struct {
+	tok      token
+	op       Opcode
+	label    string       // label defined by this item (emitted as a JUMPDEST), or referenced by a PUSH
+	value    *uint256.Int // operand of a PUSH, nil if it's a label reference
+	isPush   bool
+	autoPush bool // the PUSH size is derived from the operand
+	size     int  // size of the PUSH operand
+}
+
+// Assemble translates assembly source into bytecode.
+//
+// The source is a sequence of instructions separated by whitespace, with comments starting with ';' or '//':
+//
+//	#define SLOT 0x20          ; a constant
+//	#macro STORE(slot, value)  ; a macro, expanded where it's invoked as STORE(SLOT, 1)
+//	    PUSH value PUSH slot SSTORE
+//	#end
+//	loop:                      ; a label, emitted as a JUMPDEST
+//	    PUSH loop JUMP         ; pushes the offset of the label
+//
+// Mnemonics are the names returned by Opcode.String, in any case. PUSH picks the smallest PUSH able to hold its operand
+// (PUSH0 for 0), while PUSH1 to PUSH32 push exactly that many bytes. A number, constant or label on its own is pushed as by PUSH.
+// Numbers are decimal or 0x prefixed hex. Errors are reported as *AsmError.
+func Assemble(src string) ([]byte, error) {
+	a := &assembler{
+		constants: make(map[string]*uint256.Int),
+		macros:    make(map[string]*macro),
+		labels:    make(map[string]int),
+	}
+	tokens, err := a.preprocess(tokenize(src))
+	if err != nil {
+		return nil, err
+	}
+	if tokens, err = a.expand(tokens, 0); err != nil {
+		return nil, err
+	}
+	if err := a.parse(tokens); err != nil {
+		return nil, err
+	}
+	return a.emit()
+}
+
+type assembler struct {
+	constants map[string]*uint256.Int
+	macros    map[string]*macro
+	labels    map[string]int // label name to its index in items, and to its offset once laid out
+	items     []*asmItem
+}
+
+// tokenize splits the source into lines of tokens, dropping comments.
+// Parentheses and commas are tokens of their own so that macro invocations don't need spaces.
+func tokenize(src string) [][]token {
+	var lines [][]token
+	for i, line := range strings.Split(src, "\n") {
+		if j := strings.Index(line, ";"); j >= 0 {
+			line = line[:j]
+		}
+		if j := strings.Index(line, "//"); j >= 0 {
+			line = line[:j]
+		}
+		var tokens []token
+		start := -1
+		flush := func(end int) {
+			if start >= 0 {
+				tokens = append(tokens, token{line[start:end], i + 1, start + 1})
+				start = -1
+			}
+		}
+		for j, c := range line {
+			switch {
+			case c == '(' || c == ')' || c == ',':
+				flush(j)
+				tokens = append(tokens, token{string(c), i + 1, j + 1})
+			case c == ' ' || c == '\t' || c == '\r':
+				flush(j)
+			case start < 0:
+				start = j
+			}
+		}
+		flush(len(line))
+		lines = append(lines, tokens)
+	}
+	return lines
+}
+
+// preprocess handles the #define and #macro directives and returns the remaining tokens.
+func (a *assembler) preprocess(lines [][]token) ([]token, error) {
+	var out []token
+	var current *macro // macro whose body is being read
+	var currentTok token
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		directive := line[0]
+		switch {
+		case directive.text == "#end":
+			if current == nil {
+				return nil, directive.errorf("#end without #macro")
+			}
+			current = nil
+		case current != nil && strings.HasPrefix(directive.text, "#"):
+			return nil, directive.errorf("%s inside macro", directive.text)
+		case current != nil:
+			current.body = append(current.body, line...)
+		case directive.text == "#define":
+			if len(line) != 3 {
+				return nil, directive.errorf("#define expects a name and a value")
+			}
+			name := line[1]
+			if err := a.checkName(name); err != nil {
+				return nil, err
+			}
+			value, err := a.number(line[2])
+			if err != nil {
+				return nil, err
+			}
+			a.constants[name.text] = value
+		case directive.text == "#macro":
+			m, err := a.parseMacroHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			a.macros[line[1].text] = m
+			current, currentTok = m, directive
+		case strings.HasPrefix(directive.text, "#"):
+			return nil, directive.errorf("unknown directive %s", directive.text)
+		default:
+			out = append(out, line...)
+		}
+	}
+	if current != nil {
+		return nil, currentTok.errorf("#macro without #end")
+	}
+	return out, nil
+}
+
+// parseMacroHeader parses `#macro NAME(param, ...)`.
+func (a *assembler) parseMacroHeader(line []token) (*macro, error) {
+	if len(line) < 4 || line[2].text != "(" || line[len(line)-1].text != ")" {
+		return nil, line[0].errorf("#macro expects a name and a parameter list, e.g. #macro NAME(a, b)")
+	}
+	if err := a.checkName(line[1]); err != nil {
+		return nil, err
+	}
+	m := &macro{}
+	params := line[3 : len(line)-1]
+	for i, param := range params {
+		if i%2 == 1 {
+			if param.text != "," {
+				return nil, param.errorf("expected ',' between macro parameters")
+			}
+			continue
+		}
+		if !isIdentifier(param.text) {
+			return nil, param.errorf("invalid macro parameter %q", param.text)
+		}
+		m.params = append(m.params, param.text)
+	}
+	if len(params) > 0 && len(params)%2 == 0 {
+		return nil, params[len(params)-1].errorf("expected a macro parameter")
+	}
+	return m, nil
+}
+
+// checkName verifies that a constant or macro name is an identifier not already in use.
+func (a *assembler) checkName(name token) error {
+	if !isIdentifier(name.text) {
+		return name.errorf("invalid name %q", name.text)
+	}
+	if _, ok := opcodeByName[strings.ToUpper(name.text)]; ok || strings.EqualFold(name.text, "PUSH") {
+		return name.errorf("%s is an opcode", name.text)
+	}
+	_, isConst := a.constants[name.text]
+	_, isMacro := a.macros[name.text]
+	if isConst || isMacro {
+		return name.errorf("%s is already defined", name.text)
+	}
+	return nil
+}
+
+// expand replaces macro invocations by the macro bodies, with the parameters substituted by the arguments.
+func (a *assembler) expand(tokens []token, depth int) ([]token, error) {
+	var out []token
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		m, ok := a.macros[tok.text]
+		if !ok {
+			if tok.text == "(" || tok.text == ")" || tok.text == "," {
+				return nil, tok.errorf("unexpected %q", tok.text)
+			}
+			out = append(out, tok)
+			continue
+		}
+		if depth >= maxMacroDepth {
+			return nil, tok.errorf("macro expansion too deep, is %s recursive?", tok.text)
+		}
+
+		// Collect the arguments, one token each
+		if i+1 >= len(tokens) || tokens[i+1].text != "(" {
+			return nil, tok.errorf("macro %s must be invoked with arguments, e.g. %s()", tok.text, tok.text)
+		}
+		var args []token
+		j := i + 2
+		for ; j < len(tokens) && tokens[j].text != ")"; j++ {
+			if (j-i)%2 == 1 {
+				if tokens[j].text != "," {
+					return nil, tokens[j].errorf("expected ',' between macro arguments")
+				}
+				continue
+			}
+			args = append(args, tokens[j])
+		}
+		if j == len(tokens) {
+			return nil, tok.errorf("missing ')' in invocation of %s", tok.text)
+		}
+		if len(args) != len(m.params) {
+			return nil, tok.errorf("macro %s expects %d arguments, got %d", tok.text, len(m.params), len(args))
+		}
+		i = j
+
+		body := make([]token, len(m.body))
+		for k, t := range m.body {
+			body[k] = t
+			for p, param := range m.params {
+				if t.text == param {
+					body[k].text = args[p].text
+				}
+			}
+		}
+		expanded, err := a.expand(body, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, expanded...)
+	}
+	return out, nil
+}
+
+// parse turns the tokens into items, pairing the PUSHes with their operand.
+func (a *assembler) parse(tokens []token) error {
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if name, ok := strings.CutSuffix(tok.text, ":"); ok {
+			if !isIdentifier(name) {
+				return tok.errorf("invalid label %q", name)
+			}
+			if _, ok := a.labels[name]; ok {
+				return tok.errorf("label %s is already defined", name)
+			}
+			a.labels[name] = len(a.items)
+			a.items = append(a.items, &asmItem{tok: tok, op: JUMPDEST, label: name})
+			continue
+		}
+
+		mnemonic := strings.ToUpper(tok.text)
+		op, isOp := opcodeByName[mnemonic]
+		switch {
+		case mnemonic == "PUSH" || op.IsPush():
+			if i+1 >= len(tokens) {
+				return tok.errorf("%s expects an operand", tok.text)
+			}
+			i++
+			item, err := a.operand(tokens[i])
+			if err != nil {
+				return err
+			}
+			if mnemonic != "PUSH" {
+				item.autoPush, item.size = false, op.PushSize()
+				if item.value != nil && item.value.ByteLen() > item.size {
+					return tokens[i].errorf("%s does not fit in %s", tokens[i].text, mnemonic)
+				}
+			}
+			a.items = append(a.items, item)
+		case isOp:
+			a.items = append(a.items, &asmItem{tok: tok, op: op})
+		default:
+			item, err := a.operand(tok)
+			if err != nil {
+				return err
+			}
+			a.items = append(a.items, item)
+		}
+	}
+
+	// Every label reference must point to a label
+	for _, item := range a.items {
+		if item.isPush && item.value == nil {
+			if _, ok := a.labels[item.label]; !ok {
+				return item.tok.errorf("undefined instruction, label or constant %s", item.label)
+			}
+		}
+	}
+	return nil
+}
+
+// operand parses the operand of a PUSH: a number, a constant or a label.
+func (a *assembler) operand(tok token) (*asmItem, error) {
+	item := &asmItem{tok: tok, isPush: true, autoPush: true}
+	switch {
+	case isIdentifier(tok.text):
+		if value, ok := a.constants[tok.text]; ok {
+			item.value = value
+		} else {
+			item.label = tok.text
+		}
+	default:
+		value, err := a.number(tok)
+		if err != nil {
+			return nil, err
+		}
+		item.value = value
+	}
+	return item, nil
+}
+
+// number parses a decimal or hex number, or the value of a constant.
+func (a *assembler) number(tok token) (*uint256.Int, error) {
+	if value, ok := a.constants[tok.text]; ok {
+		return value, nil
+	}
+	n, ok := new(big.Int).SetString(tok.text, 0)
+	if !ok || n.Sign() < 0 {
+		if isIdentifier(tok.text) {
+			return nil, tok.errorf("unknown instruction or constant %s", tok.text)
+		}
+		return nil, tok.errorf("invalid number %s", tok.text)
+	}
+	value, overflow := uint256.FromBig(n)
+	if overflow {
+		return nil, tok.errorf("%s does not fit in 256 bits", tok.text)
+	}
+	return value, nil
+}
+
+// emit lays out the items and encodes them.
+//
+// PUSHes of labels are sized by the offset of the label, which depends on the size of the PUSHes before it,
+// so the layout is repeated until no PUSH grows. PUSHes never shrink, which guarantees that this terminates.
+func (a *assembler) emit() ([]byte, error) {
+	for _, item := range a.items {
+		if item.autoPush {
+			if item.value != nil {
+				item.size = item.value.ByteLen()
+			} else {
+				item.size = 1
+			}
+		}
+	}
+	offsets := make([]int, len(a.items))
+	for changed := true; changed; {
+		changed = false
+		offset := 0
+		for i, item := range a.items {
+			offsets[i] = offset
+			offset++
+			if item.isPush {
+				offset += item.size
+			}
+		}
+		for _, item := range a.items {
+			if item.isPush && item.value == nil {
+				target := uint256.NewInt(uint64(offsets[a.labels[item.label]]))
+				if !item.autoPush && target.ByteLen() > item.size {
+					return nil, item.tok.errorf("offset of label %s does not fit in PUSH%d", item.label, item.size)
+				}
+				if size := target.ByteLen(); item.autoPush && size > item.size {
+					item.size, changed = size, true
+				}
+			}
+		}
+	}
+
+	var code []byte
+	for _, item := range a.items {
+		if !item.isPush {
+			code = append(code, byte(item.op))
+			continue
+		}
+		value := item.value
+		if value == nil {
+			value = uint256.NewInt(uint64(offsets[a.labels[item.label]]))
+		}
+		if item.size == 0 {
+			code = append(code, byte(PUSH0))
+			continue
+		}
+		b := value.Bytes32()
+		code = append(code, byte(PUSH1)+byte(item.size-1))
+		code = append(code, b[32-item.size:]...)
+	}
+	return code, nil
+}
+
+// isIdentifier reports whether s is a valid label, constant, macro or parameter name.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package gevm
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"opcodes", "push1 0x02 PUSH1 2 MUL STOP", "6002600202" + "00"},
+		{"auto push sizing", "PUSH 0 PUSH 1 PUSH 0x0102 PUSH 256 0xffffff", "5f" + "6001" + "610102" + "610100" + "62ffffff"},
+		{"explicit push size", "PUSH4 1 PUSH32 0", "6300000001" + "7f" + "0000000000000000000000000000000000000000000000000000000000000000"},
+		{"comments", "ADD ; the rest is ignored\n// so is this line\nSUB", "0103"},
+		{"constants", "#define A 0x20\n#define B A\nPUSH A B", "6020" + "6020"},
+		{"backward label", "start:\nPUSH start JUMP", "5b" + "6000" + "56"},
+		{"forward label", "end JUMP\nend: STOP", "6003" + "56" + "5b" + "00"},
+		{"macro", "#macro ADDN(n)\nn ADD\n#end\nADDN(1) ADDN(2)", "600101" + "600201"},
+		{"nested macro", "#macro TWICE(op)\nop op\n#end\n#macro TWICE_ADD()\nTWICE(ADD)\n#end\nTWICE_ADD()", "0101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Assemble(tt.src)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, common.Bytes2Hex(code))
+		})
+	}
+}
+
+func TestAssembleLabelSizing(t *testing.T) {
+	// The label is past offset 255, so the jump to it needs a PUSH2, which moves the label by one byte
+	src := "end JUMP\n"
+	for i := 0; i < 254; i++ {
+		src += "STOP\n"
+	}
+	src += "end:"
+	code, err := Assemble(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{byte(PUSH2), 0x01, 0x02, byte(JUMP)}, code[:4])
+	assert.Equal(t, byte(JUMPDEST), code[0x102])
+}
+
+func TestAssembleFiles(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected []byte
+	}{
+		{"store.asm", []byte{0x60, 0x20, 0x5f, 0x55, 0x5f, 0x5f, 0x55, 0x60, 0xa, 0x60, 0x1f, 0x55, 0x60, 0xa, 0x60, 0x2f, 0x55}},
+		{"log1.asm", []byte{0x60, 0x0a, 0x5f, 0x52, 0x60, 0x14, 0x60, 0x20, 0x52, 0x60, 0x1f, 0x60, 0x40, 0x5f, 0xa1, 0x60, 0x0a, 0x5f, 0x52, 0x60, 0x96, 0x60, 0x20, 0x52, 0x60, 0x2a, 0x60, 0x40, 0x5f, 0xa1}},
+		{"loop.asm", []byte{0x60, 0x03, 0x5b, 0x60, 0x01, 0x90, 0x03, 0x80, 0x5f, 0x55, 0x80, 0x60, 0x02, 0x57, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			src, err := os.ReadFile("testdata/asm/" + tt.file)
+			assert.NoError(t, err)
+			code, err := Assemble(string(src))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, code)
+		})
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"unknown instruction", "ADD\n  FOO", "2:3: undefined instruction, label or constant FOO"},
+		{"invalid number", "PUSH 0xzz", "1:6: invalid number 0xzz"},
+		{"number too big", "PUSH1 256", "1:7: 256 does not fit in PUSH1"},
+		{"missing operand", "PUSH", "1:1: PUSH expects an operand"},
+		{"duplicate label", "a:\na:", "2:1: label a is already defined"},
+		{"unknown directive", "#include x", "1:1: unknown directive #include"},
+		{"unterminated macro", "#macro M()\nADD", "1:1: #macro without #end"},
+		{"wrong argument count", "#macro M(a)\na\n#end\nM(1, 2)", "4:1: macro M expects 1 arguments, got 2"},
+		{"recursive macro", "#macro M()\nM()\n#end\nM()", "2:1: macro expansion too deep, is M recursive?"},
+		{"constant named like an opcode", "#define add 1", "1:9: add is an opcode"},
+		{"label offset too big", "PUSH1 end\n" + strings.Repeat("STOP ", 300) + "\nend:", "1:7: offset of label end does not fit in PUSH1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Assemble(tt.src)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
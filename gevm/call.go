@@ -0,0 +1,118 @@
+package gevm
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+const (
+	callValueTransferGas uint64 = 9000  // Paid by a call sending value
+	callNewAccountGas    uint64 = 25000 // Paid by a call sending value to an empty account (EIP-161)
+)
+
+var (
+	ErrUnsupportedCall       = errors.New("message calls are only supported to native contracts")
+	ErrReturnDataOutOfBounds = errors.New("return data out of bounds")
+	ErrNativeValue           = errors.New("value sent to a native contract")
+)
+
+// NativeContract is a contract implemented in Go, registered in EVM.Natives.
+// Calls to it run it with the EVM of the caller, so it can read and change the execution.
+//
+// Returning ErrExecutionReverted reverts the call with the returned output as the revert data. Any other error fails
+// the call without return data.
+type NativeContract interface {
+	Run(evm *EVM, input []byte) ([]byte, error)
+}
+
+// call pops the arguments of CALL and calls a native contract.
+func call(evm *EVM) {
+	gas := evm.Stack.Pop() // native contracts don't use any, it's only reported to the tracer
+	addrU256 := evm.Stack.Pop()
+	value := evm.Stack.Pop()
+	evm.callNative(CALL, &gas, common.Address(addrU256.Bytes20()), &value)
+}
+
+// staticcall pops the arguments of STATICCALL and calls a native contract.
+func staticcall(evm *EVM) {
+	gas := evm.Stack.Pop() // native contracts don't use any, it's only reported to the tracer
+	addrU256 := evm.Stack.Pop()
+	evm.callNative(STATICCALL, &gas, common.Address(addrU256.Bytes20()), new(uint256.Int))
+}
+
+// callNative pops the input and output ranges of a call, runs the native contract at addr with the input,
+// and pushes 1 if it succeeded or 0 if it failed. Calling any other account panics with ErrUnsupportedCall, as the
+// EVM runs a single call frame. Sending value is charged for, as it is for other calls, but fails the call.
+// A tracer implementing NativeCallTracer is passed the call.
+func (evm *EVM) callNative(op Opcode, gas *uint256.Int, addr common.Address, value *uint256.Int) {
+	argsOffsetU256, argsSizeU256 := evm.Stack.Pop(), evm.Stack.Pop()
+	retOffsetU256, retSizeU256 := evm.Stack.Pop(), evm.Stack.Pop()
+	argsOffset, argsSize := argsOffsetU256.Uint64(), argsSizeU256.Uint64()
+	retOffset, retSize := retOffsetU256.Uint64(), retSizeU256.Uint64()
+
+	native, ok := evm.Natives[addr]
+	if !ok {
+		panic(ErrUnsupportedCall)
+	}
+
+	memExpansionCost := evm.Memory.expand(argsOffset, argsSize)
+	memExpansionCost += evm.Memory.expand(retOffset, retSize)
+	dynamicGas := evm.addressAccessCost(addr) + memExpansionCost
+	if !value.IsZero() {
+		dynamicGas += callValueTransferGas
+		if acc, ok := evm.State[addr]; !ok || acc.Empty() {
+			dynamicGas += callNewAccountGas
+		}
+	}
+	evm.deductGas(dynamicGas)
+	// All but one 64th of the gas left can be passed to a call (EIP-150), and a call sending value gets a stipend on top
+	callGas := evm.Gas - evm.Gas/64
+	if gas.IsUint64() && gas.Uint64() < callGas {
+		callGas = gas.Uint64()
+	}
+	if !value.IsZero() {
+		callGas += callStipend
+	}
+
+	success := uint256.NewInt(0)
+	evm.returnDataBuffer = nil
+	input := common.CopyBytes(evm.Memory.Access(argsOffset, argsSize))
+	err := ErrNativeValue
+	if value.IsZero() {
+		var output []byte
+		output, err = native.Run(evm, input)
+		if err == nil || errors.Is(err, ErrExecutionReverted) {
+			evm.returnDataBuffer = output
+		}
+		if err == nil {
+			success.SetOne()
+		}
+	}
+	// Native contracts use no gas, so the callee gives back all of it, including the stipend it got for free
+	if !value.IsZero() {
+		evm.Gas += callStipend
+	}
+	if t, ok := evm.Tracer.(NativeCallTracer); ok {
+		t.CaptureNativeCall(evm, NativeCall{
+			Op: op, To: addr, Input: input, Output: common.CopyBytes(evm.returnDataBuffer),
+			Value: value.Clone(), Gas: callGas, Err: err,
+		})
+	}
+	output := evm.returnDataBuffer
+	if uint64(len(output)) > retSize {
+		output = output[:retSize]
+	}
+	evm.Memory.Store(retOffset, output)
+	evm.Stack.Push(success)
+	evm.PC++
+}
+
+// externalCode returns the code of an account in the world state, nil without one.
+func (evm *EVM) externalCode(addr common.Address) []byte {
+	if acc, ok := evm.State[addr]; ok {
+		return acc.Code
+	}
+	return nil
+}
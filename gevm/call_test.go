@@ -0,0 +1,164 @@
+package gevm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nativeFunc adapts a function to the NativeContract interface.
+type nativeFunc func(evm *EVM, input []byte) ([]byte, error)
+
+func (f nativeFunc) Run(evm *EVM, input []byte) ([]byte, error) { return f(evm, input) }
+
+func TestCallNative(t *testing.T) {
+	native := common.HexToAddress("0xc0de")
+	natives := map[common.Address]NativeContract{
+		native: nativeFunc(func(evm *EVM, input []byte) ([]byte, error) {
+			switch {
+			case len(input) == 0:
+				return nil, errors.New("no input")
+			case input[0] == 0xff:
+				return []byte{0xde, 0xad}, ErrExecutionReverted
+			}
+			return append(input, 0x01), nil
+		}),
+	}
+
+	tests := []struct {
+		name      string
+		program   *Program
+		wantErr   string
+		wantStack []uint64
+		wantMem   []byte
+	}{
+		{
+			name:      "Call",
+			program:   NewProgram().Mstore(0, 0xaa).Call(nil, native, 0, 0x1f, 1, 0x20, 2).Op(RETURNDATASIZE),
+			wantStack: []uint64{1, 2},
+			wantMem:   common.RightPadBytes(append(common.LeftPadBytes([]byte{0xaa}, 32), 0xaa, 0x01), 64), // memory grows by words
+		},
+		{
+			name:      "Output larger than the output range",
+			program:   NewProgram().Mstore(0, 0xaa).StaticCall(nil, native, 0x1f, 1, 0x3f, 1).Op(RETURNDATASIZE),
+			wantStack: []uint64{1, 2},
+			wantMem:   append(common.LeftPadBytes([]byte{0xaa}, 32), common.LeftPadBytes([]byte{0xaa}, 32)...),
+		},
+		{
+			name:      "Revert data",
+			program:   NewProgram().Mstore(0, 0xff).StaticCall(nil, native, 0x1f, 1, 0, 0).Op(RETURNDATASIZE).Push(2).Push0().Push0().Op(RETURNDATACOPY),
+			wantStack: []uint64{0, 2},
+			wantMem:   append([]byte{0xde, 0xad}, common.LeftPadBytes([]byte{0xff}, 30)...),
+		},
+		{
+			name:      "Failure",
+			program:   NewProgram().StaticCall(nil, native, 0, 0, 0, 0).Op(RETURNDATASIZE),
+			wantStack: []uint64{0, 0},
+			wantMem:   []byte{},
+		},
+		{
+			name:      "Value",
+			program:   NewProgram().Mstore(0, 0xaa).Call(nil, native, 1, 0x1f, 1, 0, 0).Op(RETURNDATASIZE),
+			wantStack: []uint64{0, 0},
+			wantMem:   common.LeftPadBytes([]byte{0xaa}, 32),
+		},
+		{
+			name:    "Not a native contract",
+			program: NewProgram().StaticCall(nil, common.HexToAddress("0xbeef"), 0, 0, 0, 0),
+			wantErr: ErrUnsupportedCall.Error(),
+		},
+		{
+			name:    "Return data out of bounds",
+			program: NewProgram().Mstore(0, 0xaa).StaticCall(nil, native, 0x1f, 1, 0, 0).Push(3).Push0().Push0().Op(RETURNDATACOPY),
+			wantErr: ErrReturnDataOutOfBounds.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evm := setupEVM()
+			evm.Verbose = false
+			evm.Gas = 100_000
+			evm.Natives = natives
+			evm.Code = tt.program.Bytes()
+			result := evm.Execute()
+
+			if tt.wantErr != "" {
+				require.Error(t, result.Err)
+				assert.Contains(t, result.Err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, result.Err)
+			var stack []uint64
+			for _, v := range evm.Stack.data {
+				stack = append(stack, v.Uint64())
+			}
+			assert.Equal(t, tt.wantStack, stack)
+			assert.Equal(t, tt.wantMem, evm.Memory.Data())
+		})
+	}
+}
+
+func TestCallGas(t *testing.T) {
+	native := common.HexToAddress("0xc0de")
+	evm := setupEVM()
+	evm.Verbose = false
+	evm.Gas = 100_000
+	evm.Natives = map[common.Address]NativeContract{native: nativeFunc(func(*EVM, []byte) ([]byte, error) { return nil, nil })}
+	// Two calls, the first to a cold address, expanding the memory to one word
+	evm.Code = NewProgram().StaticCall(0, native, 0, 0, 0, 1).StaticCall(0, native, 0, 0, 0, 1).Bytes()
+	result := evm.Execute()
+	require.NoError(t, result.Err)
+	pushes := uint64(2 * 6 * 3)
+	assert.Equal(t, pushes+coldAccountAccessCost+3+warmAccessCost, result.UsedGas)
+}
+
+func TestCallValueGas(t *testing.T) {
+	native := common.HexToAddress("0xc0de")
+	evm := setupEVM()
+	evm.Verbose = false
+	evm.Gas = 100_000
+	evm.Natives = map[common.Address]NativeContract{native: nativeFunc(func(*EVM, []byte) ([]byte, error) { return nil, nil })}
+	evm.Code = NewProgram().Call(0, native, 1, 0, 0, 0, 0).Bytes()
+	result := evm.Execute()
+	require.NoError(t, result.Err)
+	// The value transfer to an empty account, minus the stipend the native contract doesn't use
+	pushes := uint64(7 * 3)
+	assert.Equal(t, pushes+coldAccountAccessCost+callValueTransferGas+callNewAccountGas-callStipend, result.UsedGas)
+}
+
+func TestWorldStateInstructions(t *testing.T) {
+	addr := common.HexToAddress("0xbeef")
+	state := NewWorldState()
+	state[addr] = &Account{Balance: uint256.NewInt(42), Code: []byte{0x60, 0x01, 0x00}}
+
+	evm := setupEVM()
+	evm.Verbose = false
+	evm.Gas = 100_000
+	evm.Sender = common.HexToAddress("0xca11e5")
+	evm.Origin = common.HexToAddress("0x0e1c")
+	evm.State = state
+	evm.Code = NewProgram().Push(addr).Op(BALANCE).Push(addr).Op(EXTCODESIZE).Push(0x10).Op(BALANCE, CALLER, ORIGIN).
+		Push(4).Push(1).Push0().Push(addr).Op(EXTCODECOPY).Bytes()
+	result := evm.Execute()
+	require.NoError(t, result.Err)
+
+	var stack []uint64
+	for _, v := range evm.Stack.data {
+		stack = append(stack, v.Uint64())
+	}
+	assert.Equal(t, []uint64{42, 3, 0, 0xca11e5, 0x0e1c}, stack)
+	assert.Equal(t, append([]byte{0x01, 0x00, 0, 0}, make([]byte, 28)...), evm.Memory.Data())
+
+	// Without a world state, the sender is also the origin
+	evm = setupEVM()
+	evm.Verbose = false
+	evm.Sender = common.HexToAddress("0xca11e5")
+	evm.Code = NewProgram().Op(ORIGIN).Bytes()
+	evm.Execute()
+	assert.Equal(t, uint64(0xca11e5), evm.Stack.data[0].Uint64())
+}
@@ -21,55 +21,79 @@ func calcMemoryGasCost(size uint64) uint64 {
 	return (3 * words) + (words*words)/512
 }
 
-// calcSstoreGasCost calculates the gas cost for the SSTORE operation in the EVM.
+// calcSstoreGasCost calculates the gas cost for the SSTORE operation in the EVM, and updates the refund counter.
 //
-// This is a bit simplified compared to the actual implementation in Ethereum clients.
-func calcSstoreGasCost(evm *EVM, slot int, newValue common.Hash) (gasCost uint64) {
+// The cost depends on the current value of the slot, and on its original value at the start of the execution:
+// rewriting a slot already written in the same execution is cheap (EIP-2200), and so is resetting it to its original
+// value, which refunds most of what the first write cost (EIP-3529).
+func calcSstoreGasCost(evm *EVM, slot common.Hash, newValue common.Hash) (gasCost uint64) {
 	// Load the current value stored at the specified slot.
 	currentValue, isWarm := evm.Storage.Get(slot)
 
-	// Determine the access cost (cold vs warm)
-	accessCost := uint64(2100)
-	if isWarm {
-		accessCost = 100
+	// Accessing a cold slot costs extra (EIP-2929)
+	var accessCost uint64
+	if !isWarm {
+		accessCost = 2100
 	}
 
 	// If the current value is the same as the new value, it's a no-op.
 	if currentValue == newValue {
-		return accessCost
+		return accessCost + 100
 	}
 
-	// Calculate the dynamic gas cost
-	var dynamicCost uint64
-	if currentValue == (common.Hash{}) && newValue != (common.Hash{}) {
-		// Zero to non-zero
-		dynamicCost = 20000
-	} else if currentValue != (common.Hash{}) && newValue == (common.Hash{}) {
-		// Non-zero to zero
-		dynamicCost = 2900
-		evm.addRefund(4800)
-	} else {
-		// Non-zero to non-zero
-		dynamicCost = 2900
+	// First write to the slot in this execution
+	originalValue := evm.Storage.Original(slot)
+	if originalValue == currentValue {
+		if originalValue == (common.Hash{}) {
+			// Zero to non-zero
+			return accessCost + 20000
+		}
+		if newValue == (common.Hash{}) {
+			// Non-zero to zero
+			evm.addRefund(4800)
+		}
+		return accessCost + 2900
 	}
 
-	return accessCost + dynamicCost
+	// The slot was already written, adjust the refunds of the earlier writes
+	if originalValue != (common.Hash{}) {
+		if currentValue == (common.Hash{}) {
+			evm.subRefund(4800) // the slot was cleared and is set again
+		} else if newValue == (common.Hash{}) {
+			evm.addRefund(4800)
+		}
+	}
+	if originalValue == newValue {
+		// Back to the original value
+		if originalValue == (common.Hash{}) {
+			evm.addRefund(20000 - 100)
+		} else {
+			evm.addRefund(2900 - 100)
+		}
+	}
+	return accessCost + 100
 }
 
+// calcLogGasCost calculates the gas cost of LOG0 to LOG4: 375, plus 375 per topic and 8 per byte of data.
 func calcLogGasCost(topicCount, size, memExpansionCost uint64) uint64 {
 	staticGas := uint64(375)
-	return staticGas*topicCount + 8*size + memExpansionCost
+	return staticGas + staticGas*topicCount + 8*size + memExpansionCost
 }
 
 // getData returns a slice from the data based on the start and size and pads
 // up to size with zero's. This function is overflow safe.
+//
+// The result ends up in memory, so size is bounded by MaxMemorySize.
 func getData(data []byte, start uint64, size uint64) []byte {
+	if size > MaxMemorySize {
+		panic(ErrMemoryLimit)
+	}
 	length := uint64(len(data))
 	if start > length {
 		start = length
 	}
 	end := start + size
-	if end > length {
+	if end < start || end > length {
 		end = length
 	}
 	return common.RightPadBytes(data[start:end], int(size))
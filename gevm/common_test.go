@@ -70,11 +70,11 @@ func TestCalcLogGasCost(t *testing.T) {
 		sizeInMem        uint64
 		want             uint64
 	}{
-		{sizeInMem: 0, topicCount: 1, memExpansionCost: 0, want: 375},
-		{sizeInMem: 1, topicCount: 1, memExpansionCost: 3, want: 386},
-		{sizeInMem: 64, topicCount: 1, memExpansionCost: 6, want: 893},
-		{sizeInMem: 1024, topicCount: 1, memExpansionCost: 98, want: 8665},
-		{sizeInMem: 2048, topicCount: 1, memExpansionCost: 2195456, want: 2212215},
+		{sizeInMem: 0, topicCount: 1, memExpansionCost: 0, want: 750},
+		{sizeInMem: 1, topicCount: 1, memExpansionCost: 3, want: 761},
+		{sizeInMem: 64, topicCount: 1, memExpansionCost: 6, want: 1268},
+		{sizeInMem: 1024, topicCount: 1, memExpansionCost: 98, want: 9040},
+		{sizeInMem: 2048, topicCount: 1, memExpansionCost: 2195456, want: 2212590},
 	}
 
 	for _, tt := range tests {
@@ -144,3 +144,16 @@ func TestGetData(t *testing.T) {
 		})
 	}
 }
+
+func FuzzGetData(f *testing.F) {
+	f.Add([]byte{1, 2, 3, 4, 5}, uint64(1), uint64(3))
+	f.Add([]byte{1, 2, 3, 4, 5}, uint64(3), uint64(0xffffffffffffffff)) // start + size overflows
+	f.Add([]byte{}, uint64(1<<63), uint64(32))
+
+	f.Fuzz(func(t *testing.T, data []byte, start, size uint64) {
+		noRuntimePanic(t, func() {
+			got := getData(data, start, size)
+			assert.Equal(t, int(size), len(got))
+		})
+	})
+}
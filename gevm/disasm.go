@@ -0,0 +1,78 @@
+package gevm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Instruction is a single instruction decoded from bytecode.
+type Instruction struct {
+	PC        uint64
+	Op        Opcode
+	Immediate []byte // operand of a PUSH, shorter than Op.PushSize() if Truncated
+	Truncated bool   // the operand of a PUSH runs past the end of the code
+}
+
+// Unknown reports whether the instruction's byte is not a known opcode.
+func (ins Instruction) Unknown() bool {
+	return !ins.Op.IsDefined()
+}
+
+// String formats the instruction as its PC, mnemonic and immediate, e.g. "0x0002: PUSH1 0x40".
+func (ins Instruction) String() string {
+	s := fmt.Sprintf("0x%04x: %v", ins.PC, ins.Op)
+	if ins.Op.IsPush() {
+		s += " 0x" + hex.EncodeToString(ins.Immediate)
+	}
+	if ins.Truncated {
+		s += fmt.Sprintf(" (truncated, %d of %d bytes)", len(ins.Immediate), ins.Op.PushSize())
+	}
+	return s
+}
+
+// Disassemble decodes bytecode into instructions.
+// The Solidity metadata appended to contract code is not decoded, see SplitMetadata.
+func Disassemble(code []byte) []Instruction {
+	code, _ = SplitMetadata(code)
+
+	var instructions []Instruction
+	for pc := 0; pc < len(code); pc++ {
+		ins := Instruction{PC: uint64(pc), Op: Opcode(code[pc])}
+		if size := ins.Op.PushSize(); size > 0 {
+			end := min(pc+1+size, len(code))
+			ins.Immediate = code[pc+1 : end]
+			ins.Truncated = len(ins.Immediate) < size
+			pc = end - 1
+		}
+		instructions = append(instructions, ins)
+	}
+	return instructions
+}
+
+// SplitMetadata splits the CBOR encoded metadata Solidity appends to contract code from the code itself.
+//
+// The metadata is a CBOR map, with keys such as "ipfs" and "solc", followed by its length as a big-endian uint16.
+// If code doesn't end with metadata, it is returned unchanged and metadata is nil.
+func SplitMetadata(code []byte) (runtime, metadata []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	length := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	start := len(code) - 2 - length
+	if length == 0 || start < 0 {
+		return code, nil
+	}
+	cbor := code[start : len(code)-2]
+	// A map with 1 to 5 entries, holding at least one of the keys solc emits
+	if cbor[0] < 0xa1 || cbor[0] > 0xa5 {
+		return code, nil
+	}
+	for _, key := range []string{"ipfs", "bzzr0", "bzzr1", "solc", "experimental"} {
+		if bytes.Contains(cbor, append([]byte{0x60 + byte(len(key))}, key...)) {
+			return code[:start], code[start:]
+		}
+	}
+	return code, nil
+}
@@ -0,0 +1,72 @@
+package gevm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []byte
+		expected []string
+	}{
+		{
+			name:     "push and add",
+			code:     []byte{0x60, 0x01, 0x61, 0x01, 0x02, 0x01, 0x5f},
+			expected: []string{"0x0000: PUSH1 0x01", "0x0002: PUSH2 0x0102", "0x0005: ADD", "0x0006: PUSH0"},
+		},
+		{
+			name:     "unknown opcode",
+			code:     []byte{0x0c, 0x00},
+			expected: []string{"0x0000: UNKNOWN_OPCODE(0xc)", "0x0001: STOP"},
+		},
+		{
+			name:     "truncated push",
+			code:     []byte{0x00, 0x63, 0xaa, 0xbb},
+			expected: []string{"0x0000: STOP", "0x0001: PUSH4 0xaabb (truncated, 2 of 4 bytes)"},
+		},
+		{
+			name:     "truncated push without operand",
+			code:     []byte{0x7f},
+			expected: []string{"0x0000: PUSH32 0x (truncated, 0 of 32 bytes)"},
+		},
+		{
+			name: "solidity metadata",
+			code: common.FromHex("6080604052fe" +
+				"a2646970667358221220171a252a9a14f80264325dafaebbdce726557b26ef8b20cf9ab8596851e5c44164736f6c63430008190033"),
+			expected: []string{"0x0000: PUSH1 0x80", "0x0002: PUSH1 0x40", "0x0004: MSTORE", "0x0005: INVALID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ins := range Disassemble(tt.code) {
+				got = append(got, ins.String())
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestInstructionUnknown(t *testing.T) {
+	instructions := Disassemble([]byte{0x0c, 0x01})
+	assert.True(t, instructions[0].Unknown())
+	assert.False(t, instructions[1].Unknown())
+}
+
+func TestSplitMetadata(t *testing.T) {
+	metadata := common.FromHex("a164736f6c6343000819000a")
+	runtime, got := SplitMetadata(append([]byte{0x00}, metadata...))
+	assert.Equal(t, []byte{0x00}, runtime)
+	assert.Equal(t, metadata, got)
+
+	// A trailing length that doesn't point to CBOR is left alone
+	code := []byte{0x60, 0x00, 0x00, 0x01}
+	runtime, got = SplitMetadata(code)
+	assert.Equal(t, code, runtime)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,115 @@
+package gevm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+)
+
+// callStipend is the gas SSTORE requires to be left (EIP-2200), and the gas a call transferring value gives its callee.
+const callStipend uint64 = 2300
+
+// RevertError is the error of an execution that reverted, with its revert data.
+type RevertError struct {
+	Data   []byte
+	Reason string // described by the caller, e.g. with abi.DecodeRevert; "" leaves the error as execution reverted
+}
+
+// NewRevertError creates the error of an execution that reverted with data. Decoding the data into a reason is left to
+// the caller, which knows the ABI of the contract.
+func NewRevertError(data []byte) *RevertError {
+	return &RevertError{Data: data}
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return ErrExecutionReverted.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrExecutionReverted, e.Reason)
+}
+
+func (e *RevertError) Unwrap() error {
+	return ErrExecutionReverted
+}
+
+// EstimateGas returns the lowest gas limit msg executes successfully with, searching between its intrinsic gas and
+// its gas limit, or the block gas limit if it has none. The gas limit is further capped by what the sender can pay.
+// The state isn't modified.
+//
+// The gas limit needed is higher than the gas used: refunds are only given back at the end, SSTORE requires
+// some gas to be left, and calls can only forward 63/64 of the gas left (EIP-150). A guess accounting for these is
+// tried first, then the limit is found by binary search.
+//
+// If execution reverts even with the highest gas limit, the error is a *RevertError with the revert data.
+func EstimateGas(state WorldState, msg *Message, block *Block, chainConfig ChainConfig) (uint64, error) {
+	hi := msg.GasLimit
+	if hi == 0 || hi > chainConfig.GasLimit {
+		hi = chainConfig.GasLimit
+	}
+	if msg.GasPrice != nil && !msg.GasPrice.IsZero() {
+		balance := new(uint256.Int)
+		if acc, ok := state[msg.From]; ok {
+			balance.Set(acc.Balance)
+		}
+		if msg.Value != nil {
+			if balance.Lt(msg.Value) {
+				return 0, fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, msg.From, balance, msg.Value)
+			}
+			balance.Sub(balance, msg.Value)
+		}
+		if allowance := new(uint256.Int).Div(balance, msg.GasPrice); allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+
+	run := func(gas uint64) (*ExecutionResult, error) {
+		cpy := *msg
+		cpy.GasLimit = gas
+		return ApplyMessage(state.Copy(), &cpy, block, chainConfig, nil)
+	}
+	intrinsicGas := IntrinsicGas(msg.Data, msg.AccessList, msg.To == nil)
+
+	// A transfer to an account without code needs only the intrinsic gas
+	if msg.To != nil && hi >= intrinsicGas {
+		if acc, ok := state[*msg.To]; !ok || len(acc.Code) == 0 {
+			if res, err := run(intrinsicGas); err == nil && !res.Failed() {
+				return intrinsicGas, nil
+			}
+		}
+	}
+
+	res, err := run(hi)
+	switch {
+	case errors.Is(err, ErrIntrinsicGas):
+		return 0, fmt.Errorf("gas required exceeds allowance (%d): %w", hi, err)
+	case err != nil:
+		return 0, err
+	case errors.Is(res.Err, ErrExecutionReverted):
+		return 0, NewRevertError(res.ReturnData)
+	case res.Failed():
+		return 0, fmt.Errorf("gas required exceeds allowance (%d): %w", hi, res.Err)
+	}
+
+	lo := intrinsicGas - 1 // highest gas limit known to fail
+	if guess := (res.UsedGas + res.Refund + callStipend) * 64 / 63; guess < hi {
+		if res, err := run(guess); err == nil && !res.Failed() {
+			hi = guess
+		} else {
+			lo = guess
+		}
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		res, err := run(mid)
+		if err != nil && !errors.Is(err, ErrIntrinsicGas) {
+			return 0, err
+		}
+		if err != nil || res.Failed() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
@@ -0,0 +1,129 @@
+package gevm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateGas(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+		contract = common.HexToAddress("0x1000")
+	)
+	// Error("no")
+	reason := common.FromHex("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"6e6f000000000000000000000000000000000000000000000000000000000000")
+	tests := []struct {
+		name     string
+		code     []byte
+		slot0    common.Hash
+		gasPrice uint64
+		gasLimit uint64
+		want     uint64 // 0 if an error is expected
+		wantErr  string
+	}{
+		{
+			name: "Plain transfer",
+			want: 21000,
+		},
+		{
+			name: "Store",
+			code: NewProgram().Sstore(0, 1).Op(STOP).Bytes(),
+			want: 21000 + 3 + 3 + 22100,
+		},
+		{
+			// The refund is only given back at the end, the gas limit must cover the gas used before it
+			name:  "Clear with refund",
+			code:  NewProgram().Sstore(0, 0).Op(STOP).Bytes(),
+			slot0: common.HexToHash("0x01"),
+		},
+		{
+			// Reverts below 50000 gas left
+			name: "Gas check",
+			code: NewProgram().Push(50000).Op(GAS, LT).JumpI("low").Op(STOP).
+				Label("low").Revert(0, 0).Bytes(),
+		},
+		{
+			name:    "Always reverts",
+			code:    NewProgram().Mstore(0, reason[:32]).Mstore(32, reason[32:64]).Mstore(64, reason[64:96]).Mstore(96, reason[96:]).Revert(0, len(reason)).Bytes(),
+			wantErr: "execution reverted",
+		},
+		{
+			name:    "Infinite loop",
+			code:    NewProgram().Label("loop").Jump("loop").Bytes(),
+			wantErr: "gas required exceeds allowance (30000000)",
+		},
+		{
+			name:     "Capped by the gas limit",
+			code:     NewProgram().Sstore(0, 1).Op(STOP).Bytes(),
+			gasLimit: 30000,
+			wantErr:  "gas required exceeds allowance (30000)",
+		},
+		{
+			name:     "Capped by the balance",
+			code:     NewProgram().Sstore(0, 1).Op(STOP).Bytes(),
+			gasPrice: 1e14, // the balance of 1 ether pays for 10000 gas
+			wantErr:  "gas required exceeds allowance (10000)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewWorldState()
+			state.GetOrNewAccount(sender).Balance = uint256.NewInt(1e18)
+			if tt.code != nil {
+				acc := state.GetOrNewAccount(contract)
+				acc.Code = tt.code
+				if tt.slot0 != (common.Hash{}) {
+					acc.Storage[common.Hash{}] = tt.slot0
+				}
+			}
+			block := NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+			chainConfig := ChainConfig{ChainID: 1, GasLimit: 30_000_000}
+			msg := &Message{From: sender, To: &contract, GasLimit: tt.gasLimit, GasPrice: uint256.NewInt(tt.gasPrice)}
+			before := state.Copy()
+
+			gas, err := EstimateGas(state, msg, block, chainConfig)
+			assert.Equal(t, before, state, "the state must not change")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want != 0 {
+				assert.Equal(t, tt.want, gas)
+			}
+
+			// The estimate is the lowest gas limit execution succeeds with
+			apply := func(gas uint64) error {
+				cpy := *msg
+				cpy.GasLimit = gas
+				res, err := ApplyMessage(state.Copy(), &cpy, block, chainConfig, nil)
+				if err != nil {
+					return err
+				}
+				return res.Err
+			}
+			assert.NoError(t, apply(gas))
+			assert.Error(t, apply(gas-1))
+		})
+	}
+}
+
+func TestRevertError(t *testing.T) {
+	err := NewRevertError([]byte{0xde, 0xad})
+	assert.True(t, errors.Is(err, ErrExecutionReverted))
+	assert.Equal(t, "execution reverted", err.Error())
+	assert.Equal(t, []byte{0xde, 0xad}, err.Data)
+
+	err.Reason = "Panic(0x11): arithmetic underflow or overflow"
+	assert.Equal(t, "execution reverted: Panic(0x11): arithmetic underflow or overflow", err.Error())
+}
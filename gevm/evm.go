@@ -1,24 +1,13 @@
 package gevm
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-)
-
-// dynamicGasMap maps opcodes to their dynamic gas costs.
-type dynamicGasMap map[Opcode]uint64
-
-// DynamicGas returns the gas cost for a given opcode.
-func (d dynamicGasMap) DynamicGas(op Opcode) (bool, uint64) {
-	gas, hasDyGas := d[op]
-	return hasDyGas, gas
-}
-
-var (
-	dgMap = make(dynamicGasMap)
+	"github.com/holiman/uint256"
 )
 
 // ExecutionRuntime represents the execution runtime during EVM execution.
@@ -32,6 +21,10 @@ type ExecutionRuntime struct {
 	ReturnData []byte
 	LogRecord  *LogRecord
 	Block      *Block
+	Verbose    bool   // print the EVM state after every step and a summary at the end of execution
+	Tracer     Tracer // optional, notified before every step
+
+	returnDataBuffer []byte // data returned by the last call, read by RETURNDATASIZE and RETURNDATACOPY (EIP-211)
 }
 
 // ExecutionEnvironment encapsulates the EVM execution data environment.
@@ -40,25 +33,39 @@ type ExecutionEnvironment struct {
 	Memory    *Memory
 	Storage   *Storage
 	Transient *TransientStorage
+
+	// State holds the other accounts, read by BALANCE, EXTCODESIZE and EXTCODECOPY. Without it, they return mocked values.
+	// The storage of the executing account is Storage, not its storage in State.
+	State WorldState
+
+	warmAddresses map[common.Address]bool // addresses accessed so far, besides the ones always warm (EIP-2929)
 }
 
 // TransactionContext holds transaction-specific information during EVM execution.
 type TransactionContext struct {
-	Sender   common.Address
-	Value    uint64
+	Sender   common.Address // caller of the code being executed
+	Origin   common.Address // sender of the transaction, Sender if zero
+	Address  common.Address // address of the account whose code is being executed
+	Value    *uint256.Int   // value sent with the call, zero if nil
 	Calldata []byte
+	TxHash   common.Hash // hash of the transaction, recorded in the logs
+	TxIndex  uint        // position of the transaction in its block
 }
 
 // ChainConfig stores network configuration parameters.
 type ChainConfig struct {
 	ChainID  uint64
 	GasLimit uint64
+	Fork     Fork // rules to execute with, defaults to the latest supported fork
+
+	// Natives are the contracts implemented in Go that CALL and STATICCALL can call, by address
+	Natives map[common.Address]NativeContract
 }
 
 // Block represents a block.
 type Block struct {
 	Coinbase  common.Address
-	GasPrice  uint64
+	GasPrice  *uint256.Int // gas price of the transaction being executed, zero if nil
 	Number    uint64
 	Timestamp time.Time
 	BaseFee   uint64
@@ -68,7 +75,7 @@ type Block struct {
 func NewBlock(coinbase common.Address, gasPrice, number, difficulty, baseFee uint64, timeStamp time.Time) *Block {
 	return &Block{
 		Coinbase:  coinbase,
-		GasPrice:  gasPrice,
+		GasPrice:  uint256.NewInt(gasPrice),
 		Number:    number,
 		Timestamp: timeStamp,
 		BaseFee:   baseFee,
@@ -85,7 +92,7 @@ type EVM struct {
 
 func (evm *EVM) deductGas(gas uint64) {
 	if evm.Gas < gas || evm.Gas <= 0 {
-		panic(fmt.Errorf("out of gas: tried to consume %d gas, but only %d gas remaining", gas, evm.Gas))
+		panic(fmt.Errorf("%w: tried to consume %d gas, but only %d gas remaining", ErrOutOfGas, gas, evm.Gas))
 	}
 	evm.Gas -= gas // deduct gas
 }
@@ -99,10 +106,12 @@ func (evm *EVM) continueExecution() bool {
 }
 
 func (evm *EVM) Run() {
-	fmt.Println("#### Trace ####")
+	if evm.Verbose {
+		fmt.Println("#### Trace ####")
+	}
 
 	// Initialize the jump table containing all opcode implementations
-	jumpTable := NewJumpTable()
+	jumpTable := NewForkJumpTable(evm.Fork)
 	var totalGasUsed uint64
 
 	// Main execution loop
@@ -112,35 +121,89 @@ func (evm *EVM) Run() {
 		opcode := evm.Code[currentPC]
 		op := Opcode(opcode)
 
+		if evm.Tracer != nil {
+			evm.Tracer.CaptureState(evm, currentPC, op)
+		}
+
 		// Execute the opcode if it exists in the jump table
+		gasBefore := evm.Gas
 		if opFunc, exists := jumpTable[op]; exists {
 			opFunc(evm)
 		} else {
 			// Handle unknown opcodes
-			fmt.Printf("Unknown opcode: %#x\n", opcode)
+			if evm.Verbose {
+				fmt.Printf("Unknown opcode: %#x\n", opcode)
+			}
 			return
 		}
 
-		// Calculate gas cost for the operation
-		var gCost uint64
-		if has, cost := dgMap.DynamicGas(op); has {
-			// Use dynamic gas cost if available
-			gCost = cost
-		} else {
-			// Use static gas cost defined for the opcode
-			gCost = op.Gas()
-		}
+		// The gas cost of the operation, static and dynamic, is what it deducted
+		gCost := gasBefore - evm.Gas
 		totalGasUsed += gCost // accumulate total gas used
 
 		// Log the current EVM state after executing the opcode
-		logEVMState(evm, op, gCost, currentPC)
+		if evm.Verbose {
+			logEVMState(evm, op, gCost, currentPC)
+		}
 	}
 
 	// Total gas consumed
 	totalGasUsed -= evm.Refund // minus refund, if any
 
 	// Log emitted logs and some stats
-	LogEVMLogs(totalGasUsed, evm)
+	if evm.Verbose {
+		LogEVMLogs(totalGasUsed, evm)
+	}
+}
+
+// Execute runs the EVM like Run, but recovers from the panics that halt execution
+// and reports how execution ended. Failing with anything but a revert consumes all the remaining gas.
+func (evm *EVM) Execute() *ExecutionResult {
+	startGas := evm.Gas
+	err := evm.execute()
+	if err != nil && !errors.Is(err, ErrExecutionReverted) {
+		evm.Gas = 0
+	}
+	if evm.Tracer != nil {
+		evm.Tracer.CaptureEnd(evm)
+	}
+
+	result := &ExecutionResult{
+		UsedGas:    startGas - evm.Gas,
+		Refund:     evm.Refund,
+		Err:        err,
+		ReturnData: evm.ReturnData,
+	}
+	if err == nil {
+		for _, log := range *evm.LogRecord {
+			result.Logs = append(result.Logs, log.toGeth())
+		}
+	}
+	return result
+}
+
+// execute runs the EVM and converts the ways execution can halt into an error.
+func (evm *EVM) execute() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e // keep the sentinel errors matchable with errors.Is
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	evm.Run()
+	switch {
+	case evm.RevertFlag:
+		return ErrExecutionReverted
+	case evm.StopFlag && Opcode(evm.Code[evm.PC]) == INVALID:
+		return ErrInvalidOpcode
+	case evm.continueExecution():
+		// Run returned early without halting, which only happens on an unknown opcode
+		return fmt.Errorf("%w: %#x", ErrInvalidOpcode, evm.Code[evm.PC])
+	}
+	return nil
 }
 
 func (evm *EVM) addRefund(refund uint64) {
@@ -158,7 +221,7 @@ func NewEVM(sender common.Address, gas, value, chainID, gasLimit uint64, code, c
 	return &EVM{
 		ExecutionRuntime: ExecutionRuntime{
 			PC:         0,
-			Code:       []byte{},
+			Code:       code,
 			Gas:        gas,
 			Refund:     0,
 			StopFlag:   false,
@@ -166,21 +229,24 @@ func NewEVM(sender common.Address, gas, value, chainID, gasLimit uint64, code, c
 			ReturnData: []byte{},
 			LogRecord:  NewLogRecord(),
 			Block:      blockInfo,
+			Verbose:    true,
 		},
 		ExecutionEnvironment: ExecutionEnvironment{
 			Stack:     NewStack(),
 			Memory:    NewMemory(),
 			Storage:   NewStorage(),
 			Transient: NewTransientStorage(),
+
+			warmAddresses: make(map[common.Address]bool),
 		},
 		TransactionContext: TransactionContext{
-			Sender:   common.Address{},
-			Value:    0,
-			Calldata: []byte{},
+			Sender:   sender,
+			Value:    uint256.NewInt(value),
+			Calldata: calldata,
 		},
 		ChainConfig: ChainConfig{
-			ChainID:  1,
-			GasLimit: gas,
+			ChainID:  chainID,
+			GasLimit: gasLimit,
 		},
 	}
 }
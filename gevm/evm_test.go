@@ -0,0 +1,42 @@
+package gevm
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// noRuntimePanic fails the test if f panics with a Go runtime error (index out of range, nil dereference, ...).
+// The EVM halts by panicking with its own errors (out of gas, stack underflow, ...), those are expected.
+func noRuntimePanic(t *testing.T, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(runtime.Error); ok {
+				t.Fatalf("runtime panic: %v\n%s", err, debug.Stack())
+			}
+		}
+	}()
+	f()
+}
+
+// FuzzRun runs arbitrary bytecode with arbitrary calldata and gas.
+// The seed corpus in testdata/fuzz/FuzzRun holds programs that used to crash the host process.
+func FuzzRun(f *testing.F) {
+	f.Add([]byte{0x60, 0x01, 0x60, 0x02, 0x01}, []byte{}, uint64(100000))                             // PUSH1 1, PUSH1 2, ADD
+	f.Add([]byte{0x5f, 0x35, 0x5f, 0x55}, []byte{0x2a}, uint64(100000))                               // SSTORE(0, CALLDATALOAD(0))
+	f.Add([]byte{0x60, 0x20, 0x5f, 0x5f, 0x37, 0x60, 0x20, 0x5f, 0xf3}, []byte{0x01}, uint64(100000)) // CALLDATACOPY then RETURN
+
+	f.Fuzz(func(t *testing.T, code, calldata []byte, gas uint64) {
+		// Cap the gas so that loops terminate in a reasonable time
+		gas %= 10_000_000
+
+		block := NewBlock(common.Address{}, 1, 1, 0, 1, time.Unix(0, 0))
+		evm := NewEVM(common.Address{}, gas, 0, 1, 30_000_000, code, calldata, block)
+		evm.Verbose = false
+		noRuntimePanic(t, evm.Run)
+	})
+}
@@ -0,0 +1,28 @@
+package gevm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fork is a network upgrade whose opcodes gevm implements.
+// The zero value behaves like the latest supported fork.
+type Fork string
+
+const (
+	Shanghai Fork = "Shanghai" // PUSH0 (EIP-3855)
+	Cancun   Fork = "Cancun"   // TLOAD and TSTORE (EIP-1153), MCOPY (EIP-5656)
+)
+
+// Forks lists the supported forks, oldest first.
+var Forks = []Fork{Shanghai, Cancun}
+
+// ParseFork returns the fork with the given name, ignoring case.
+func ParseFork(name string) (Fork, error) {
+	for _, fork := range Forks {
+		if strings.EqualFold(name, string(fork)) {
+			return fork, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported fork %q, supported forks are %v", name, Forks)
+}
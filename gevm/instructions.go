@@ -234,70 +234,67 @@ func keccak256(evm *EVM) {
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
 
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	value := evm.Memory.Access(offset, size)
 	hash := crypto.Keccak256(value)
 	evm.Stack.Push(uint256.NewInt(0).SetBytes(hash))
 
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
-
 	wordSize := toWordSize(size)
 	staticGas := uint64(30)
 	dynamicGas := 6*wordSize + totalMemExpansionCost
 
 	evm.PC++
 	evm.deductGas(staticGas + dynamicGas)
-	dgMap[KECCAK256] = dynamicGas
 }
 
 // Ethereum environment (calldata, code, others) operations
 func address(evm *EVM) {
-	evm.Stack.Push(uint256.MustFromHex(evm.Sender.Hex()))
+	evm.Stack.Push(new(uint256.Int).SetBytes(evm.Address.Bytes()))
 	evm.PC++
 	evm.deductGas(2)
 }
 
-// This is a mocked version and doesn't behave exactly as it would in a real EVM.
-//
-// balance pushes a mocked balance value onto the stack.
+// balance pushes the balance of an account from the world state, or a mocked balance without one.
+// Its gas cost depends on whether the address was accessed before.
 func balance(evm *EVM) {
-	_ = evm.Stack.Pop() // Pop the address from the stack, though it's not used here
-	evm.Stack.Push(uint256.MustFromDecimal("99999999999"))
+	addrU256 := evm.Stack.Pop()
+	addr := common.Address(addrU256.Bytes20())
+	bal := uint256.MustFromDecimal("99999999999")
+	if evm.State != nil {
+		bal = new(uint256.Int)
+		if acc, ok := evm.State[addr]; ok {
+			bal.Set(acc.Balance)
+		}
+	}
+	evm.Stack.Push(bal)
 	evm.PC++
-	evm.deductGas(2600) // '2600' here represents "address access cost" and can be 100 for warm access, but we are assuming cold access since this is a mocked version.
+	evm.deductGas(evm.addressAccessCost(addr))
 }
 
-// This is a mocked version and doesn't behave exactly as it would in a real EVM.
-//
-// origin pushes a mocked address (evm.Sender) of the transaction origin onto the stack.
+// origin pushes the address of the transaction sender onto the stack.
 func origin(evm *EVM) {
-	// We're using evm.Sender because this is a mocked version, but evm.sender may not always be the same as tx.origin in real world cases.
-	evm.Stack.Push(uint256.MustFromHex(evm.Sender.Hex()))
+	origin := evm.Origin
+	if origin == (common.Address{}) {
+		origin = evm.Sender
+	}
+	evm.Stack.Push(new(uint256.Int).SetBytes(origin.Bytes()))
 	evm.PC++
 	evm.deductGas(2)
 }
 
-// This is a mocked version and doesn't behave exactly as it would in a real EVM.
-//
-// caller pushes a mocked address of the current transaction caller in a transaction call chain.
+// caller pushes the address of the caller of the code being executed onto the stack.
 func caller(evm *EVM) {
-	evm.Stack.Push(uint256.MustFromHex("0xBc73e0231621D6274671839f9dF8EE7E2C8A6f93"))
+	evm.Stack.Push(new(uint256.Int).SetBytes(evm.Sender.Bytes()))
 	evm.PC++
 	evm.deductGas(2)
 }
 
 func callvalue(evm *EVM) {
-	evm.Stack.Push(uint256.NewInt(evm.Value))
+	value := new(uint256.Int)
+	if evm.Value != nil {
+		value.Set(evm.Value)
+	}
+	evm.Stack.Push(value)
 	evm.PC++
 	evm.deductGas(2)
 }
@@ -332,11 +329,10 @@ func calldatacopy(evm *EVM) {
 
 	wordSize := toWordSize(size)
 	staticGas := uint64(3)
-	dynamicGas := staticGas*wordSize + memExpansionCost
+	dynamicGas := staticGas + staticGas*wordSize + memExpansionCost
 
 	evm.PC++
 	evm.deductGas(dynamicGas)
-	dgMap[CALLDATACOPY] = dynamicGas
 }
 
 func codesize(evm *EVM) {
@@ -358,16 +354,18 @@ func codecopy(evm *EVM) {
 
 	wordSize := toWordSize(size)
 	staticGas := uint64(3)
-	dynamicGas := staticGas*wordSize + memExpansionCost
+	dynamicGas := staticGas + staticGas*wordSize + memExpansionCost
 
 	evm.PC++
 	evm.deductGas(dynamicGas)
-	dgMap[CODECOPY] = dynamicGas
 }
 
-// gasprice pushes a mocked gas price (0) onto the stack.
+// gasprice pushes the gas price of the transaction onto the stack.
 func gasprice(evm *EVM) {
-	gasPrice := uint256.NewInt(evm.Block.GasPrice)
+	gasPrice := new(uint256.Int)
+	if evm.Block.GasPrice != nil {
+		gasPrice.Set(evm.Block.GasPrice)
+	}
 	evm.Stack.Push(gasPrice)
 	evm.PC++
 	evm.deductGas(2)
@@ -381,64 +379,81 @@ func gas(evm *EVM) {
 	evm.PC++
 }
 
-// This is a mocked version and doesn't behave exactly as it would in a real EVM.
-//
-// extcodesize pushes a mocked code size (0) of an external account onto the stack.
+// extcodesize pushes the size of the code of an account from the world state, or 0 without one.
 func extcodesize(evm *EVM) {
-	_ = evm.Stack.Pop()               // Pop external address off the stack
-	evm.Stack.Push(uint256.NewInt(0)) // push 0x0
+	addrU256 := evm.Stack.Pop()
+	addr := common.Address(addrU256.Bytes20())
+	evm.Stack.Push(uint256.NewInt(uint64(len(evm.externalCode(addr)))))
 	evm.PC++
-	evm.deductGas(2)
+	evm.deductGas(evm.addressAccessCost(addr))
 }
 
-// This is a mocked version and doesn't behave exactly as it would in a real EVM.
-//
-// extcodecopy copies a zero-length mocked byte slice to memory.
+// extcodehash pushes the hash of the code of an account from the world state onto the stack, or 0 if the account
+// doesn't exist or is empty.
+func extcodehash(evm *EVM) {
+	addrU256 := evm.Stack.Pop()
+	addr := common.Address(addrU256.Bytes20())
+	hash := new(uint256.Int)
+	if acc, ok := evm.State[addr]; ok && !acc.Empty() {
+		hash.SetBytes(crypto.Keccak256(acc.Code))
+	}
+	evm.Stack.Push(hash)
+	evm.PC++
+	evm.deductGas(evm.addressAccessCost(addr))
+}
+
+// extcodecopy copies the code of an account from the world state to memory. Without a world state, nothing is copied.
 func extcodecopy(evm *EVM) {
-	_ = evm.Stack.Pop() // Pop external address off the stack
+	addrU256 := evm.Stack.Pop()
 	destMemOffsetU256 := evm.Stack.Pop()
-	_ = evm.Stack.Pop() // Pop offset of the external code to copy. This isn't used.
+	offsetU256 := evm.Stack.Pop()
 	sizeU256 := evm.Stack.Pop()
 
 	destMemOffset, size := destMemOffsetU256.Uint64(), sizeU256.Uint64()
 
-	extCodeCopy := []byte{}                                          // mocked (no external code)
-	memExpansionCost := evm.Memory.Store(destMemOffset, extCodeCopy) // extCode has zero length
+	extCodeCopy := []byte{} // mocked (no external code)
+	if evm.State != nil {
+		extCodeCopy = getData(evm.externalCode(common.Address(addrU256.Bytes20())), offsetU256.Uint64(), size)
+	}
+	memExpansionCost := evm.Memory.Store(destMemOffset, extCodeCopy)
 
 	wordSize := toWordSize(size)
-	dynamicGas := 3*wordSize + memExpansionCost + 2600 // '2600' here represents "address access cost" and can be 100 for warm access, but we are assuming cold access since this is a mocked version.
+	dynamicGas := 3*wordSize + memExpansionCost + evm.addressAccessCost(common.Address(addrU256.Bytes20()))
 
 	evm.PC++
 	evm.deductGas(dynamicGas)
-	dgMap[EXTCODECOPY] = dynamicGas
 }
 
-// returndatasize pushes a mocked (zero-value) length of the return data onto the stack.
+// returndatasize pushes the size of the data returned by the last call onto the stack.
 func returndatasize(evm *EVM) {
-	returnDataSize := uint256.NewInt(uint64(len(evm.ReturnData)))
+	returnDataSize := uint256.NewInt(uint64(len(evm.returnDataBuffer)))
 	evm.Stack.Push(returnDataSize)
 	evm.PC++
 	evm.deductGas(2)
 }
 
-// returndatacopy copies a zero-length mocked return data byte slice to memory
+// returndatacopy copies the data returned by the last call to memory. Reading past its end halts the execution.
 func returndatacopy(evm *EVM) {
 	destMemOffsetU256 := evm.Stack.Pop()
-	_ = evm.Stack.Pop() // Pop offset of the return data to copy. This isn't used.
+	offsetU256 := evm.Stack.Pop()
 	sizeU256 := evm.Stack.Pop()
 
 	destMemOffset, size := destMemOffsetU256.Uint64(), sizeU256.Uint64()
 
-	retDataCopy := evm.ReturnData
+	end, overflow := new(uint256.Int).AddOverflow(&offsetU256, &sizeU256)
+	if overflow || !end.IsUint64() || end.Uint64() > uint64(len(evm.returnDataBuffer)) {
+		panic(ErrReturnDataOutOfBounds)
+	}
+
+	retDataCopy := evm.returnDataBuffer[offsetU256.Uint64():end.Uint64()]
 	memExpansionCost := evm.Memory.Store(destMemOffset, retDataCopy)
 
 	wordSize := toWordSize(size)
 	staticGas := uint64(3)
-	dynamicGas := staticGas*wordSize + memExpansionCost
+	dynamicGas := staticGas + staticGas*wordSize + memExpansionCost
 
 	evm.PC++
 	evm.deductGas(dynamicGas)
-	dgMap[RETURNDATACOPY] = dynamicGas
 }
 
 // blockhash pushes a mocked hash of the current block onto the stack.
@@ -462,7 +477,7 @@ func blockhash(evm *EVM) {
 
 // coinbase pushes a block’s beneficiary address onto the stack.
 func coinbase(evm *EVM) {
-	coinBase := uint256.NewInt(0).SetBytes32(evm.Block.Coinbase[:])
+	coinBase := uint256.NewInt(0).SetBytes(evm.Block.Coinbase.Bytes())
 	evm.Stack.Push(coinBase)
 	evm.PC++
 	evm.deductGas(2)
@@ -585,7 +600,6 @@ func mload(evm *EVM) {
 
 	evm.PC++
 	evm.deductGas(dynamicGas)
-	dgMap[MLOAD] = dynamicGas
 }
 
 func mstore(evm *EVM) {
@@ -602,20 +616,18 @@ func mstore(evm *EVM) {
 
 	evm.deductGas(dynamicGas)
 	evm.PC++
-	dgMap[MSTORE] = dynamicGas
 }
 
 func mstore8(evm *EVM) {
 	offsetU256 := evm.Stack.Pop()
 	valueU256 := evm.Stack.Pop()
 
-	memExpansionCost := evm.Memory.Store(offsetU256.Uint64(), valueU256.Bytes()) // valueU256.Bytes() would be just a slice of one byte
+	memExpansionCost := evm.Memory.Store(offsetU256.Uint64(), []byte{byte(valueU256.Uint64())}) // only the lowest byte is stored
 	staticGas := uint64(3)
 	dynamicGas := staticGas + memExpansionCost
 
 	evm.deductGas(dynamicGas)
 	evm.PC++
-	dgMap[MSTORE8] = dynamicGas
 }
 
 func msize(evm *EVM) {
@@ -631,22 +643,24 @@ func mcopy(evm *EVM) {
 
 	destMemOffset, offset, size := destMemOffsetU256.Uint64(), offsetU256.Uint64(), sizeU256.Uint64()
 
-	memCopy := getData(evm.Memory.data, offset, size)
-	memExpansionCost := evm.Memory.Store(destMemOffset, memCopy)
+	// Both the source and the destination expand the memory
+	memExpansionCost := evm.Memory.expand(max(destMemOffset, offset), size)
 
 	wordSize := toWordSize(size)
 	staticGas := uint64(3)
-	dynamicGas := staticGas*wordSize + memExpansionCost
+	dynamicGas := staticGas + staticGas*wordSize + memExpansionCost
 
 	evm.deductGas(dynamicGas)
+	if size > 0 {
+		copy(evm.Memory.data[destMemOffset:destMemOffset+size], evm.Memory.data[offset:offset+size]) // the ranges may overlap
+	}
 	evm.PC++
-	dgMap[MCOPY] = dynamicGas
 }
 
 // Storage operations
 func sload(evm *EVM) {
 	slotU256 := evm.Stack.Pop()
-	v, isWarm := evm.Storage.Load(int(slotU256.Uint64()))
+	v, isWarm := evm.Storage.Load(slotU256.Bytes32())
 
 	valueU256 := uint256.NewInt(0).SetBytes32(v[:])
 	evm.Stack.Push(valueU256)
@@ -659,29 +673,31 @@ func sload(evm *EVM) {
 		dynamicGas = 2100
 	}
 	evm.deductGas(dynamicGas)
-	dgMap[SLOAD] = dynamicGas
 }
 
 func sstore(evm *EVM) {
 	slotU256 := evm.Stack.Pop()
 	valueU256 := evm.Stack.Pop()
 
-	slot := int(slotU256.Uint64())
+	slot := common.Hash(slotU256.Bytes32())
 	newValue := common.BytesToHash(valueU256.Bytes())
 
+	// SSTORE needs more gas left than a call stipend, so that a contract called with one can't write storage (EIP-2200)
+	if evm.Gas <= 2300 {
+		panic(fmt.Errorf("%w: SSTORE needs more than 2300 gas left", ErrOutOfGas))
+	}
 	gasCost := calcSstoreGasCost(evm, slot, newValue)
 
 	evm.deductGas(gasCost)
 	evm.Storage.Store(slot, newValue)
 
 	evm.PC++
-	dgMap[SSTORE] = gasCost
 }
 
 // Transient storage operations
 func tload(evm *EVM) {
 	slotU256 := evm.Stack.Pop()
-	v := evm.Transient.Load(int(slotU256.Uint64()))
+	v := evm.Transient.Load(slotU256.Bytes32())
 	valueU256 := uint256.NewInt(0).SetBytes32(v[:])
 
 	evm.deductGas(100)
@@ -695,7 +711,7 @@ func tstore(evm *EVM) {
 	v := common.BytesToHash(valueU256.Bytes())
 
 	evm.deductGas(100)
-	evm.Transient.Store(int(slotU256.Uint64()), v)
+	evm.Transient.Store(slotU256.Bytes32(), v)
 	evm.PC++
 }
 
@@ -703,8 +719,8 @@ func tstore(evm *EVM) {
 func jump(evm *EVM) {
 	newPCIndexU256 := evm.Stack.Pop()
 	newPCIndex := newPCIndexU256.Uint64()
-	if uint64(evm.Code[newPCIndex]) != uint64(JUMPDEST) {
-		panic("Invalid jump destination")
+	if !newPCIndexU256.IsUint64() || newPCIndex >= uint64(len(evm.Code)) || uint64(evm.Code[newPCIndex]) != uint64(JUMPDEST) {
+		panic(ErrInvalidJump)
 	}
 	evm.PC = newPCIndex
 	evm.deductGas(8)
@@ -716,15 +732,16 @@ func jumpi(evm *EVM) {
 
 	newPCIndex := newPCIndexU256.Uint64()
 
-	if uint64(evm.Code[newPCIndex]) != uint64(JUMPDEST) {
-		panic("Invalid jump destination")
-	}
-
-	if !valueU256.IsZero() {
-		evm.PC = newPCIndex
-	} else {
+	// The destination is only checked if the jump is taken
+	if valueU256.IsZero() {
 		evm.PC++
+		evm.deductGas(10)
+		return
 	}
+	if !newPCIndexU256.IsUint64() || newPCIndex >= uint64(len(evm.Code)) || uint64(evm.Code[newPCIndex]) != uint64(JUMPDEST) {
+		panic(ErrInvalidJump)
+	}
+	evm.PC = newPCIndex
 	evm.deductGas(10)
 }
 
@@ -749,6 +766,7 @@ func revert(evm *EVM) {
 	destMemOffsetU256, sizeU256 := evm.Stack.Pop(), evm.Stack.Pop()
 
 	destMemOffset, size := destMemOffsetU256.Uint64(), sizeU256.Uint64()
+	evm.deductGas(evm.Memory.expand(destMemOffset, size))
 	evm.ReturnData = evm.Memory.Access(destMemOffset, size)
 
 	evm.RevertFlag = true
@@ -759,6 +777,7 @@ func _return(evm *EVM) {
 	destMemOffsetU256, sizeU256 := evm.Stack.Pop(), evm.Stack.Pop()
 
 	destMemOffset, size := destMemOffsetU256.Uint64(), sizeU256.Uint64()
+	evm.deductGas(evm.Memory.expand(destMemOffset, size))
 	evm.ReturnData = evm.Memory.Access(destMemOffset, size)
 	evm.StopFlag = true
 	// evm.PC++
@@ -769,53 +788,27 @@ func log0(evm *EVM) {
 	offsetU256, sizeU256 := evm.Stack.Pop(), evm.Stack.Pop()
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	data := evm.Memory.Access(offset, size)
-	evm.LogRecord.AddLog([]common.Hash{}, data) // change []common.Hash{} to nil?
-
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
+	evm.addLog([]common.Hash{}, data)
 
 	evm.PC++
 	dynamicGas := calcLogGasCost(0, size, totalMemExpansionCost)
 	evm.deductGas(dynamicGas)
-	dgMap[LOG0] = dynamicGas
 }
 
 func log1(evm *EVM) {
 	offsetU256, sizeU256, topicU256 := evm.Stack.Pop(), evm.Stack.Pop(), evm.Stack.Pop()
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	data := evm.Memory.Access(offset, size)
 	topic := common.BytesToHash(topicU256.Bytes())
-	evm.LogRecord.AddLog([]common.Hash{topic}, data)
-
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
+	evm.addLog([]common.Hash{topic}, data)
 
 	evm.PC++
 	dynamicGas := calcLogGasCost(1, size, totalMemExpansionCost)
 	evm.deductGas(dynamicGas)
-	dgMap[LOG1] = dynamicGas
 }
 
 func log2(evm *EVM) {
@@ -823,27 +816,14 @@ func log2(evm *EVM) {
 	topic1U256, topic2U256 := evm.Stack.Pop(), evm.Stack.Pop()
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	data := evm.Memory.Access(offset, size)
 	topic1, topic2 := common.BytesToHash(topic1U256.Bytes()), common.BytesToHash(topic2U256.Bytes())
-	evm.LogRecord.AddLog([]common.Hash{topic1, topic2}, data)
-
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
+	evm.addLog([]common.Hash{topic1, topic2}, data)
 
 	evm.PC++
 	dynamicGas := calcLogGasCost(2, size, totalMemExpansionCost)
 	evm.deductGas(dynamicGas)
-	dgMap[LOG2] = dynamicGas
 }
 
 func log3(evm *EVM) {
@@ -851,27 +831,14 @@ func log3(evm *EVM) {
 	topic1U256, topic2U256, topic3U256 := evm.Stack.Pop(), evm.Stack.Pop(), evm.Stack.Pop()
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	data := evm.Memory.Access(offset, size)
 	topic1, topic2, topic3 := common.BytesToHash(topic1U256.Bytes()), common.BytesToHash(topic2U256.Bytes()), common.BytesToHash(topic3U256.Bytes())
-	evm.LogRecord.AddLog([]common.Hash{topic1, topic2, topic3}, data)
-
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
+	evm.addLog([]common.Hash{topic1, topic2, topic3}, data)
 
 	evm.PC++
 	dynamicGas := calcLogGasCost(3, size, totalMemExpansionCost)
 	evm.deductGas(dynamicGas)
-	dgMap[LOG3] = dynamicGas
 }
 
 func log4(evm *EVM) {
@@ -879,27 +846,14 @@ func log4(evm *EVM) {
 	topic1U256, topic2U256, topic3U256, topic4U256 := evm.Stack.Pop(), evm.Stack.Pop(), evm.Stack.Pop(), evm.Stack.Pop()
 
 	offset, size := offsetU256.Uint64(), sizeU256.Uint64()
+	totalMemExpansionCost := evm.Memory.expand(offset, size)
 	data := evm.Memory.Access(offset, size)
 	topic1, topic2, topic3, topic4 := common.BytesToHash(topic1U256.Bytes()), common.BytesToHash(topic2U256.Bytes()), common.BytesToHash(topic3U256.Bytes()), common.BytesToHash(topic4U256.Bytes())
-	evm.LogRecord.AddLog([]common.Hash{topic1, topic2, topic3, topic4}, data)
-
-	// Gas cost calculations
-	currentMemSize := uint64(evm.Memory.Len())
-	currentMemCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + size
-
-	var memExpansionSize uint64
-	if currentMemSize < newMemSize {
-		memExpansionSize = newMemSize - currentMemSize
-	}
-
-	newMemCost := calcMemoryGasCost(currentMemSize + memExpansionSize)
-	totalMemExpansionCost := newMemCost - currentMemCost
+	evm.addLog([]common.Hash{topic1, topic2, topic3, topic4}, data)
 
 	evm.PC++
-	dynamicGas := calcLogGasCost(3, size, totalMemExpansionCost)
+	dynamicGas := calcLogGasCost(4, size, totalMemExpansionCost)
 	evm.deductGas(dynamicGas)
-	dgMap[LOG4] = dynamicGas
 }
 
 // This is used in jump_table.go
@@ -1,6 +1,7 @@
 package gevm
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
@@ -105,3 +106,129 @@ func TestEVMOperations(t *testing.T) {
 		})
 	}
 }
+
+// Programs run from start to end, built with Program instead of raw bytecode
+func TestRunPrograms(t *testing.T) {
+	tests := []struct {
+		name       string
+		program    *Program
+		wantErr    error
+		wantStack  []uint64
+		wantSlots  map[common.Hash]uint64
+		wantRefund uint64
+		wantLogs   int
+		wantReturn []byte
+	}{
+		{
+			name:      "Add",
+			program:   NewProgram().Push(0x42).Push(0xff).Op(ADD),
+			wantStack: []uint64{0x141},
+		},
+		{
+			name:      "Mul",
+			program:   NewProgram().Push(2).Push(2).Op(MUL, STOP),
+			wantStack: []uint64{4},
+		},
+		{
+			name:      "Revert",
+			program:   NewProgram().Push(0x1f).Push(1).Op(ADD).Revert(0, 0).Push(0x20),
+			wantErr:   ErrExecutionReverted,
+			wantStack: []uint64{0x20},
+		},
+		{
+			name:       "Store and clear a slot",
+			program:    NewProgram().Push(0x20).Push0().Op(SSTORE).Push0().Push0().Op(SSTORE).Sstore(0x1f, 0xa).Sstore(0x2f, 0xa),
+			wantSlots:  map[common.Hash]uint64{{}: 0, common.HexToHash("0x1f"): 0xa, common.HexToHash("0x2f"): 0xa},
+			wantRefund: 19_900, // the slots are reset to their original zero
+		},
+		{
+			name: "Log1",
+			program: NewProgram().
+				Mstore(0, 10).Mstore(0x20, 20).Push(0x1f).Push(0x40).Push0().Op(LOG1).
+				Mstore(0, 10).Mstore(0x20, 150).Push(0x2a).Push(0x40).Push0().Op(LOG1),
+			wantLogs: 2,
+		},
+		{
+			name: "Loop",
+			program: NewProgram().Push(3).
+				Label("loop").Push(1).Op(SWAP1, SUB, DUP1).Push0().Op(SSTORE, DUP1).JumpI("loop").
+				Mstore(0, 0x2a).Return(0x1f, 1),
+			wantStack:  []uint64{0},
+			wantSlots:  map[common.Hash]uint64{{}: 0},
+			wantRefund: 19_900, // the slots are reset to their original zero
+			wantReturn: []byte{0x2a},
+		},
+		{
+			name:      "Slots wider than 64 bits",
+			program:   NewProgram().Sstore(1, 0xa).Sstore(common.HexToHash("0x010000000000000001"), 0xb).Push(1).Op(SLOAD),
+			wantStack: []uint64{0xa},
+			wantSlots: map[common.Hash]uint64{common.HexToHash("0x1"): 0xa, common.HexToHash("0x010000000000000001"): 0xb},
+		},
+		{
+			name:    "Stack underflow",
+			program: NewProgram().Push(1).Op(ADD),
+			wantErr: ErrStackUnderflow,
+		},
+		{
+			name:    "Invalid jump",
+			program: NewProgram().Push(3).Op(JUMP),
+			wantErr: ErrInvalidJump,
+		},
+		{
+			name:      "Jumpi not taken to an invalid destination",
+			program:   NewProgram().Push0().Push(0xff).Op(JUMPI).Push(1),
+			wantStack: []uint64{1},
+		},
+		{
+			name:    "Jumpi taken to an invalid destination",
+			program: NewProgram().Push(1).Push(0xff).Op(JUMPI),
+			wantErr: ErrInvalidJump,
+		},
+		{
+			name:    "Out of gas",
+			program: NewProgram().Label("loop").Jump("loop"),
+			wantErr: ErrOutOfGas,
+		},
+		{
+			name:    "Memory limit",
+			program: NewProgram().Push(MaxMemorySize).Op(MLOAD),
+			wantErr: ErrMemoryLimit,
+		},
+		{
+			name:    "Return data out of bounds",
+			program: NewProgram().Push(1).Push0().Push0().Op(RETURNDATACOPY),
+			wantErr: ErrReturnDataOutOfBounds,
+		},
+		{
+			name:    "Call to a non-native account",
+			program: NewProgram().Call(nil, common.HexToAddress("0xbeef"), 0, 0, 0, 0, 0),
+			wantErr: ErrUnsupportedCall,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evm := setupEVM()
+			evm.Verbose = false
+			evm.Gas = 100_000
+			evm.Code = tt.program.Bytes()
+			result := evm.Execute()
+
+			assert.ErrorIs(t, result.Err, tt.wantErr)
+			var stack []uint64
+			for _, v := range evm.Stack.data {
+				stack = append(stack, v.Uint64())
+			}
+			assert.Equal(t, tt.wantStack, stack)
+			for slot, want := range tt.wantSlots {
+				got, _ := evm.Storage.Get(slot)
+				assert.Equal(t, common.BigToHash(new(big.Int).SetUint64(want)), got, "slot %v", slot)
+			}
+			assert.Equal(t, tt.wantRefund, result.Refund)
+			assert.Len(t, result.Logs, tt.wantLogs)
+			if tt.wantReturn != nil {
+				assert.Equal(t, tt.wantReturn, result.ReturnData)
+			}
+		})
+	}
+}
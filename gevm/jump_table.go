@@ -47,6 +47,7 @@ func NewJumpTable() JumpTable {
 		GAS:            gas,
 		EXTCODESIZE:    extcodesize,
 		EXTCODECOPY:    extcodecopy,
+		EXTCODEHASH:    extcodehash,
 		RETURNDATASIZE: returndatasize,
 		RETURNDATACOPY: returndatacopy,
 		BLOCKHASH:      blockhash,
@@ -79,6 +80,8 @@ func NewJumpTable() JumpTable {
 		LOG2:           log2,
 		LOG3:           log3,
 		LOG4:           log4,
+		CALL:           call,
+		STATICCALL:     staticcall,
 		// SELFBALANCE:    selfbalance,
 		// SELFDESTRUCT: selfdestruct,
 	}
@@ -106,3 +109,14 @@ func NewJumpTable() JumpTable {
 
 	return jumpTable
 }
+
+// NewForkJumpTable returns the jump table of the given fork, without the opcodes introduced by later forks.
+func NewForkJumpTable(fork Fork) JumpTable {
+	jumpTable := NewJumpTable()
+	if fork == Shanghai {
+		delete(jumpTable, TLOAD)
+		delete(jumpTable, TSTORE)
+		delete(jumpTable, MCOPY)
+	}
+	return jumpTable
+}
@@ -5,3 +5,29 @@ import (
 )
 
 func TestNewJumpTable(t *testing.T) {}
+
+func TestNewForkJumpTable(t *testing.T) {
+	cancun := NewForkJumpTable(Cancun)
+	shanghai := NewForkJumpTable(Shanghai)
+	for _, op := range []Opcode{TLOAD, TSTORE, MCOPY} {
+		if _, ok := cancun[op]; !ok {
+			t.Errorf("%v missing from the Cancun jump table", op)
+		}
+		if _, ok := shanghai[op]; ok {
+			t.Errorf("%v present in the Shanghai jump table", op)
+		}
+	}
+	if _, ok := shanghai[PUSH0]; !ok {
+		t.Error("PUSH0 missing from the Shanghai jump table")
+	}
+}
+
+func TestParseFork(t *testing.T) {
+	fork, err := ParseFork("cancun")
+	if err != nil || fork != Cancun {
+		t.Errorf("ParseFork(cancun) = %v, %v", fork, err)
+	}
+	if _, err := ParseFork("Frontier"); err == nil {
+		t.Error("ParseFork(Frontier) should fail")
+	}
+}
@@ -1,33 +1,63 @@
 package gevm
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
-//lint:ignore U1000 ignore unused fields
+// Log is an event emitted by a LOG instruction, with the context it was emitted in.
 type Log struct {
-	topics []common.Hash
-	data   []byte
+	Address     common.Address `json:"address"` // contract that emitted the log
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     uint           `json:"transactionIndex"`
+	Index       uint           `json:"logIndex"` // position of the log in the execution
+}
+
+func (l *Log) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  Address: %s\n", l.Address.Hex()))
+	sb.WriteString("  Topics:\n")
+	for j, topic := range l.Topics {
+		sb.WriteString(fmt.Sprintf("    Topic %d: %s\n", j, topic.Hex()))
+	}
+	sb.WriteString(fmt.Sprintf("  Data: %x\n", []byte(l.Data)))
+	return sb.String()
+}
+
+// toGeth converts the log to go-ethereum's type.
+func (l *Log) toGeth() *types.Log {
+	return &types.Log{
+		Address:     l.Address,
+		Topics:      l.Topics,
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		TxHash:      l.TxHash,
+		TxIndex:     l.TxIndex,
+		Index:       l.Index,
+	}
 }
 
 type LogRecord []Log
 
-func (l *LogRecord) AddLog(topics []common.Hash, data []byte) {
-	*l = append(*l, Log{topics: topics, data: data})
+// AddLog appends a log, setting its index.
+func (l *LogRecord) AddLog(log Log) {
+	log.Index = uint(len(*l))
+	*l = append(*l, log)
 }
 
 func (l *LogRecord) String() string {
 	var sb strings.Builder
 	for i, log := range *l {
 		sb.WriteString(fmt.Sprintf("Log %d:\n", i))
-		sb.WriteString("  Topics:\n")
-		for j, topic := range log.topics {
-			sb.WriteString(fmt.Sprintf("    Topic %d: %s\n", j, topic.Hex()))
-		}
-		sb.WriteString(fmt.Sprintf("  Data: %x\n", log.data))
+		sb.WriteString(log.String())
 	}
 	return sb.String()
 }
@@ -35,3 +65,18 @@ func (l *LogRecord) String() string {
 func NewLogRecord() *LogRecord {
 	return new(LogRecord)
 }
+
+// addLog records a log emitted by the executing contract.
+func (evm *EVM) addLog(topics []common.Hash, data []byte) {
+	log := Log{
+		Address: evm.Address,
+		Topics:  topics,
+		Data:    bytes.Clone(data), // data is a slice of the memory, which later instructions may change
+		TxHash:  evm.TxHash,
+		TxIndex: evm.TxIndex,
+	}
+	if evm.Block != nil {
+		log.BlockNumber = evm.Block.Number
+	}
+	evm.LogRecord.AddLog(log)
+}
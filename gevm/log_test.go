@@ -1,13 +1,16 @@
 package gevm
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLogRecord(t *testing.T) {
+	addr := common.HexToAddress("0xc0ffee")
 	tests := []struct {
 		name       string
 		operations func(l *LogRecord) string
@@ -18,19 +21,19 @@ func TestLogRecord(t *testing.T) {
 			operations: func(l *LogRecord) string {
 				topics := []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")}
 				data := []byte{0x01, 0x02, 0x03}
-				l.AddLog(topics, data)
+				l.AddLog(Log{Address: addr, Topics: topics, Data: data})
 				return l.String()
 			},
-			expected: "Log 0:\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000001\n    Topic 1: 0x0000000000000000000000000000000000000000000000000000000000000002\n  Data: 010203\n",
+			expected: "Log 0:\n  Address: 0x0000000000000000000000000000000000C0FFEE\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000001\n    Topic 1: 0x0000000000000000000000000000000000000000000000000000000000000002\n  Data: 010203\n",
 		},
 		{
 			name: "Add multiple logs and String",
 			operations: func(l *LogRecord) string {
-				l.AddLog([]common.Hash{common.HexToHash("0x1")}, []byte{0x01})
-				l.AddLog([]common.Hash{common.HexToHash("0x2")}, []byte{0x02})
+				l.AddLog(Log{Address: addr, Topics: []common.Hash{common.HexToHash("0x1")}, Data: []byte{0x01}})
+				l.AddLog(Log{Address: addr, Topics: []common.Hash{common.HexToHash("0x2")}, Data: []byte{0x02}})
 				return l.String()
 			},
-			expected: "Log 0:\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000001\n  Data: 01\nLog 1:\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000002\n  Data: 02\n",
+			expected: "Log 0:\n  Address: 0x0000000000000000000000000000000000C0FFEE\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000001\n  Data: 01\nLog 1:\n  Address: 0x0000000000000000000000000000000000C0FFEE\n  Topics:\n    Topic 0: 0x0000000000000000000000000000000000000000000000000000000000000002\n  Data: 02\n",
 		},
 	}
 
@@ -42,3 +45,54 @@ func TestLogRecord(t *testing.T) {
 		})
 	}
 }
+
+func TestLogContext(t *testing.T) {
+	// Two LOG1 of the byte 0xff in memory, with topics 0xaa and 0xbb
+	code := common.FromHex("60ff5f5360aa60015fa160bb60015fa1")
+	block := &Block{Number: 7}
+	evm := NewEVM(common.Address{}, 100_000, 0, 1, 30_000_000, code, nil, block)
+	evm.Verbose = false
+	evm.Address = common.HexToAddress("0xc0ffee")
+	evm.TxHash, evm.TxIndex = common.HexToHash("0x1234"), 3
+
+	result := evm.Execute()
+	require.NoError(t, result.Err)
+	require.Len(t, *evm.LogRecord, 2)
+	for i, log := range *evm.LogRecord {
+		assert.Equal(t, evm.Address, log.Address)
+		assert.Equal(t, uint64(7), log.BlockNumber)
+		assert.Equal(t, evm.TxHash, log.TxHash)
+		assert.Equal(t, uint(3), log.TxIndex)
+		assert.Equal(t, uint(i), log.Index)
+		assert.Equal(t, []byte{0xff}, []byte(log.Data))
+	}
+	assert.Equal(t, common.HexToHash("0xbb"), (*evm.LogRecord)[1].Topics[0])
+
+	require.Len(t, result.Logs, 2)
+	assert.Equal(t, evm.Address, result.Logs[1].Address)
+	assert.Equal(t, evm.TxHash, result.Logs[1].TxHash)
+	assert.Equal(t, uint(1), result.Logs[1].Index)
+
+	data, err := json.Marshal((*evm.LogRecord)[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"address": "0x0000000000000000000000000000000000c0ffee",
+		"topics": ["0x00000000000000000000000000000000000000000000000000000000000000aa"],
+		"data": "0xff",
+		"blockNumber": 7,
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000001234",
+		"transactionIndex": 3,
+		"logIndex": 0
+	}`, string(data))
+}
+
+func TestLogDataIsCopied(t *testing.T) {
+	// LOG0 of a byte of memory, then overwrite it
+	code := NewProgram().Mstore(0, 0xaa).Push(1).Push(0x1f).Op(LOG0).Mstore(0, 0xbb).Bytes()
+	evm := NewEVM(common.Address{}, 100_000, 0, 1, 30_000_000, code, nil, &Block{})
+	evm.Verbose = false
+
+	result := evm.Execute()
+	require.NoError(t, result.Err)
+	assert.Equal(t, []byte{0xaa}, result.Logs[0].Data)
+}
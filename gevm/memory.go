@@ -1,13 +1,31 @@
 package gevm
 
+import "errors"
+
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// MaxMemorySize is the largest the memory is allowed to grow.
+// Expanding the memory to this size costs over 2 billion gas, far more than any block allows,
+// so an execution always runs out of gas before reaching it.
+const MaxMemorySize = 1 << 25 // 32 MiB
+
 type Memory struct {
 	data []byte
 }
 
+// checkMemoryLimit panics if accessing size bytes at offset would grow the memory past MaxMemorySize.
+// It must be called before any allocation, so that huge offsets can't make the host run out of memory.
+func checkMemoryLimit(offset, size uint64) {
+	if offset > MaxMemorySize || size > MaxMemorySize-offset {
+		panic(ErrMemoryLimit)
+	}
+}
+
 func (mem *Memory) Access(offset, size uint64) (cpy []byte) {
 	if size == 0 {
 		return nil
 	}
+	checkMemoryLimit(offset, size)
 
 	if mem.Len() < int(offset+size) {
 		cpy = make([]byte, offset+size)
@@ -24,58 +42,46 @@ func (mem *Memory) Load(offset uint64) []byte {
 }
 
 func (mem *Memory) Store(offset uint64, value []byte) (expansionCost uint64) {
-	// Current memory size and cost
-	currentMemSize := uint64(mem.Len())
-	currentCost := calcMemoryGasCost(currentMemSize)
-
-	// New memory size needed to store value
-	newMemSize := offset + uint64(len(value))
-
-	// Handle initial allocation separately
-	if currentMemSize == 0 {
-		mem.data = make([]byte, 32)
-		copy(mem.data, value[:])
-		return calcMemoryGasCost(32)
+	// Storing nothing doesn't touch the memory, whatever the offset
+	if len(value) == 0 {
+		return 0
 	}
+	checkMemoryLimit(offset, uint64(len(value)))
 
-	if currentMemSize < newMemSize {
-		expansionSize := newMemSize - currentMemSize
-		if expansionSize > 0 {
-			mem.data = append(mem.data, make([]byte, expansionSize)...)
-		}
-		newCost := calcMemoryGasCost(uint64(mem.Len()))
-		expansionCost = newCost - currentCost
-	}
-
-	copy(mem.data[offset:newMemSize], value)
+	expansionCost = mem.grow(offset + uint64(len(value)))
+	copy(mem.data[offset:], value)
 	return expansionCost
 }
 
 func (mem *Memory) Store32(offset uint64, value []byte) (expansionCost uint64) {
-	// Current memory size and cost
-	currentMemSize := uint64(mem.Len())
-	currentCost := calcMemoryGasCost(currentMemSize)
-	newMemSize := offset + 32
-
-	// Handle initial allocation separately
-	if currentMemSize == 0 {
-		mem.data = make([]byte, 32)
-		copy(mem.data, value[:])
-		return calcMemoryGasCost(32)
-	}
-
-	if currentMemSize < newMemSize {
-		expansionSize := newMemSize - currentMemSize
-		if expansionSize > 0 {
-			mem.data = append(mem.data, make([]byte, expansionSize)...)
-		}
-		newCost := calcMemoryGasCost(uint64(mem.Len()))
-		expansionCost = newCost - currentCost
-	}
+	checkMemoryLimit(offset, 32)
 
+	expansionCost = mem.grow(offset + 32)
 	copy(mem.data[offset:offset+32], value)
 	return expansionCost
 }
+
+// expand grows the memory to cover size bytes at offset, like an access to them would, returning the expansion cost.
+func (mem *Memory) expand(offset, size uint64) (expansionCost uint64) {
+	if size == 0 {
+		return 0
+	}
+	checkMemoryLimit(offset, size)
+	return mem.grow(offset + size)
+}
+
+// grow resizes the memory to hold at least size bytes, returning the expansion cost.
+// Memory is always a whole number of 32-byte words, as MSIZE and the gas cost see it.
+func (mem *Memory) grow(size uint64) (expansionCost uint64) {
+	currentMemSize := uint64(mem.Len())
+	if currentMemSize >= size {
+		return 0
+	}
+	newMemSize := toWordSize(size) * 32
+	mem.data = append(mem.data, make([]byte, newMemSize-currentMemSize)...)
+	return calcMemoryGasCost(newMemSize) - calcMemoryGasCost(currentMemSize)
+}
+
 func (mem *Memory) Data() []byte {
 	return mem.data
 }
@@ -104,3 +104,25 @@ func TestMemory(t *testing.T) {
 		})
 	}
 }
+
+func FuzzMemory(f *testing.F) {
+	f.Add(uint64(0), uint64(32), []byte{0x01, 0x02})
+	f.Add(uint64(64), uint64(4), []byte{0x01, 0x02, 0x03, 0x04})
+	f.Add(uint64(1<<63), uint64(32), []byte{0x01})          // huge offset
+	f.Add(uint64(0xffffffffffffffe0), uint64(64), []byte{}) // offset + size overflows
+
+	f.Fuzz(func(t *testing.T, offset, size uint64, value []byte) {
+		mem := NewMemory()
+		noRuntimePanic(t, func() {
+			mem.Store(offset, value)
+			// Whatever was stored must be read back
+			if got := mem.Access(offset, uint64(len(value))); len(value) > 0 {
+				assert.Equal(t, value, got)
+			}
+		})
+		noRuntimePanic(t, func() { mem.Store32(offset, common.LeftPadBytes(value, 32)[:32]) })
+		noRuntimePanic(t, func() { mem.Access(offset, size) })
+		noRuntimePanic(t, func() { mem.Load(offset) })
+		assert.LessOrEqual(t, mem.Len(), MaxMemorySize)
+	})
+}
@@ -1,6 +1,9 @@
 package gevm
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Opcode byte
 
@@ -218,6 +221,81 @@ const (
 	SELFDESTRUCT Opcode = 0xFF
 )
 
+// IsPush reports whether op is one of PUSH1 to PUSH32, the opcodes followed by an immediate operand.
+func (op Opcode) IsPush() bool {
+	return op >= PUSH1 && op <= PUSH32
+}
+
+// PushSize returns the size in bytes of the immediate operand of a PUSH opcode, 0 for other opcodes.
+func (op Opcode) PushSize() int {
+	if !op.IsPush() {
+		return 0
+	}
+	return int(op-PUSH1) + 1
+}
+
+// IsDefined reports whether op is a known opcode, whether gevm implements it or not.
+func (op Opcode) IsDefined() bool {
+	_, ok := opcodeByName[op.String()]
+	return ok
+}
+
+// StackIO returns the number of stack items op pops and pushes. Unknown opcodes neither pop nor push.
+func (op Opcode) StackIO() (pops, pushes int) {
+	switch {
+	case op >= PUSH0 && op <= PUSH32:
+		return 0, 1
+	case op >= DUP1 && op <= DUP16:
+		n := int(op-DUP1) + 1
+		return n, n + 1
+	case op >= SWAP1 && op <= SWAP16:
+		n := int(op-SWAP1) + 2
+		return n, n
+	case op >= LOG0 && op <= LOG4:
+		return int(op-LOG0) + 2, 0
+	}
+	switch op {
+	case ADD, MUL, SUB, DIV, SDIV, MOD, SMOD, EXP, SIGNEXTEND, LT, GT, SLT, SGT, EQ, AND, OR, XOR, BYTE, SHL, SHR, SAR, KECCAK256:
+		return 2, 1
+	case ADDMOD, MULMOD:
+		return 3, 1
+	case ISZERO, NOT, BALANCE, CALLDATALOAD, EXTCODESIZE, EXTCODEHASH, BLOCKHASH, MLOAD, SLOAD, TLOAD:
+		return 1, 1
+	case ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE, GASPRICE, RETURNDATASIZE, COINBASE, TIMESTAMP, NUMBER,
+		PREVRANDAO, GASLIMIT, CHAINID, SELFBALANCE, BASEFEE, PC, MSIZE, GAS:
+		return 0, 1
+	case CALLDATACOPY, CODECOPY, RETURNDATACOPY, MCOPY:
+		return 3, 0
+	case EXTCODECOPY:
+		return 4, 0
+	case POP, JUMP, SELFDESTRUCT:
+		return 1, 0
+	case JUMPI, MSTORE, MSTORE8, SSTORE, TSTORE, RETURN, REVERT:
+		return 2, 0
+	case CREATE:
+		return 3, 1
+	case CREATE2:
+		return 4, 1
+	case CALL, CALLCODE:
+		return 7, 1
+	case DELEGATECALL, STATICCALL:
+		return 6, 1
+	default:
+		return 0, 0
+	}
+}
+
+// opcodeByName maps the names returned by Opcode.String to their opcode.
+var opcodeByName = func() map[string]Opcode {
+	m := make(map[string]Opcode)
+	for i := 0; i < 256; i++ {
+		if name := Opcode(i).String(); !strings.HasPrefix(name, "UNKNOWN_OPCODE") {
+			m[name] = Opcode(i)
+		}
+	}
+	return m
+}()
+
 func (op Opcode) String() string {
 	switch op {
 	case STOP:
@@ -519,6 +597,7 @@ func (op Opcode) String() string {
 	}
 }
 
+// Gas returns the static gas cost of op. Opcodes with a dynamic cost, such as memory expansion, may charge more when executed.
 func (op Opcode) Gas() uint64 {
 	switch op {
 	case STOP:
@@ -531,26 +610,24 @@ func (op Opcode) Gas() uint64 {
 		return 8
 	case EXP:
 		return 10
-	case KECCAK256: // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+	case KECCAK256:
 		return 30
-	case ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE, GASPRICE, RETURNDATASIZE, EXTCODESIZE, BLOCKHASH, COINBASE, TIMESTAMP, NUMBER, PREVRANDAO, GASLIMIT, CHAINID, SELFBALANCE, BASEFEE:
+	case ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE, GASPRICE, RETURNDATASIZE, BLOCKHASH, COINBASE, TIMESTAMP, NUMBER, PREVRANDAO, GASLIMIT, CHAINID, SELFBALANCE, BASEFEE:
 		return 2
-	case BALANCE:
-		return 2600
-	case CALLDATALOAD, CALLDATACOPY, CODECOPY, RETURNDATACOPY, EXTCODECOPY:
+	case BALANCE, EXTCODESIZE, EXTCODECOPY, EXTCODEHASH:
+		return warmAccessCost // accessing a cold account costs more (EIP-2929)
+	case CALLDATALOAD, CALLDATACOPY, CODECOPY, RETURNDATACOPY:
 		return 3
-	case EXTCODEHASH:
-		return 400
 	case POP:
 		return 2
-	case MLOAD, MSTORE: // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+	case MLOAD, MSTORE:
 		return 3
 	case MSTORE8:
-		return 3 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 3
 	case SLOAD:
 		return 800
 	case SSTORE:
-		return 21000 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 21000
 	case TLOAD:
 		return 100
 	case TSTORE:
@@ -582,29 +659,28 @@ func (op Opcode) Gas() uint64 {
 	case CREATE:
 		return 32000
 	case CALL:
-		return 700 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return warmAccessCost // accessing a cold account costs more (EIP-2929)
 	case CALLCODE:
-		return 700 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 700
 	case RETURN:
-		return 0 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 0
 	case DELEGATECALL:
-		return 700 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 700
 	case CREATE2:
 		return 32000
 	case STATICCALL:
-		return 700 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return warmAccessCost // accessing a cold account costs more (EIP-2929)
 	case REVERT:
 		return 0
 	case INVALID:
 		return 0
 	case SELFDESTRUCT:
-		return 5000 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 5000
 	case PUSH0:
 		return 2
 	case MCOPY:
-		return 3 // If supported, the actual gas cost will be stored in the dgMap (dynamic gas map) in evm.go
+		return 3
 	default:
 		return 0
 	}
-	return 0
 }
@@ -41,3 +41,55 @@ func TestOpcodeGas(t *testing.T) {
 		})
 	}
 }
+
+func TestOpcodePushSize(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		expected int
+	}{
+		{PUSH0, 0},
+		{PUSH1, 1},
+		{PUSH20, 20},
+		{PUSH32, 32},
+		{DUP1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.op.PushSize())
+			assert.Equal(t, tt.expected > 0, tt.op.IsPush())
+		})
+	}
+}
+
+func TestOpcodeStackIO(t *testing.T) {
+	tests := []struct {
+		op     Opcode
+		pops   int
+		pushes int
+	}{
+		{STOP, 0, 0},
+		{ADD, 2, 1},
+		{ADDMOD, 3, 1},
+		{ISZERO, 1, 1},
+		{PUSH0, 0, 1},
+		{PUSH32, 0, 1},
+		{DUP1, 1, 2},
+		{DUP16, 16, 17},
+		{SWAP1, 2, 2},
+		{SWAP16, 17, 17},
+		{LOG4, 6, 0},
+		{CALL, 7, 1},
+		{STATICCALL, 6, 1},
+		{JUMPI, 2, 0},
+		{Opcode(0x0c), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			pops, pushes := tt.op.StackIO()
+			assert.Equal(t, tt.pops, pops)
+			assert.Equal(t, tt.pushes, pushes)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package gevm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// Program builds bytecode instruction by instruction, as a typed alternative to Assemble:
+//
+//	code := NewProgram().Push(0x20).Push0().Op(SSTORE).Label("loop").JumpI("loop").Bytes()
+//
+// Values passed to Push and to the helpers can be an int, uint64, *uint256.Int, []byte, common.Address or common.Hash.
+// Misuse, such as pushing an unsupported type or jumping to an undefined label, panics, as programs are built by tests.
+type Program struct {
+	code   []byte
+	labels map[string]int // label name to the offset of its JUMPDEST
+	jumps  map[int]string // offset of a PUSH2 operand to the label it must be patched with
+}
+
+// NewProgram creates an empty program.
+func NewProgram() *Program {
+	return &Program{
+		labels: make(map[string]int),
+		jumps:  make(map[int]string),
+	}
+}
+
+// Op appends opcodes without operands.
+func (p *Program) Op(ops ...Opcode) *Program {
+	for _, op := range ops {
+		p.code = append(p.code, byte(op))
+	}
+	return p
+}
+
+// Push appends the smallest PUSH holding value. Zero is pushed with PUSH1, use Push0 for PUSH0.
+func (p *Program) Push(value any) *Program {
+	b := toBytes(value)
+	if len(b) > 32 {
+		panic(fmt.Sprintf("cannot push %d bytes", len(b)))
+	}
+	// Drop leading zeros, but keep at least one byte
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+	p.code = append(p.code, byte(PUSH1)+byte(len(b)-1))
+	p.code = append(p.code, b...)
+	return p
+}
+
+// Push0 appends a PUSH0.
+func (p *Program) Push0() *Program {
+	return p.Op(PUSH0)
+}
+
+// Label appends a JUMPDEST that Jump and JumpI can refer to by name.
+func (p *Program) Label(name string) *Program {
+	if _, ok := p.labels[name]; ok {
+		panic(fmt.Sprintf("label %s is already defined", name))
+	}
+	p.labels[name] = len(p.code)
+	return p.Op(JUMPDEST)
+}
+
+// PushLabel appends a PUSH2 of the offset of a label, which may be defined later.
+func (p *Program) PushLabel(name string) *Program {
+	p.jumps[len(p.code)+1] = name
+	p.code = append(p.code, byte(PUSH2), 0, 0)
+	return p
+}
+
+// Jump appends a jump to a label.
+func (p *Program) Jump(label string) *Program {
+	return p.PushLabel(label).Op(JUMP)
+}
+
+// JumpI appends a jump to a label, taken if the value on top of the stack is not zero.
+func (p *Program) JumpI(label string) *Program {
+	return p.PushLabel(label).Op(JUMPI)
+}
+
+// Mstore appends the instructions storing a 32-byte value in memory.
+func (p *Program) Mstore(offset, value any) *Program {
+	return p.Push(value).Push(offset).Op(MSTORE)
+}
+
+// MstoreBytes appends the instructions storing data in memory at offset, right padded with zeros to whole words.
+func (p *Program) MstoreBytes(offset int, data []byte) *Program {
+	padded := common.RightPadBytes(data, int(toWordSize(uint64(len(data))))*32)
+	for i := 0; i < len(padded); i += 32 {
+		p.Mstore(offset+i, padded[i:i+32])
+	}
+	return p
+}
+
+// Sstore appends the instructions storing a value in a storage slot.
+func (p *Program) Sstore(slot, value any) *Program {
+	return p.Push(value).Push(slot).Op(SSTORE)
+}
+
+// Return appends the instructions returning a slice of memory.
+func (p *Program) Return(offset, size any) *Program {
+	return p.Push(size).Push(offset).Op(RETURN)
+}
+
+// Revert appends the instructions reverting with a slice of memory as revert data.
+func (p *Program) Revert(offset, size any) *Program {
+	return p.Push(size).Push(offset).Op(REVERT)
+}
+
+// Call appends the instructions of a CALL. A nil gas forwards all the remaining gas.
+func (p *Program) Call(gas any, address common.Address, value, inOffset, inSize, outOffset, outSize any) *Program {
+	p.Push(outSize).Push(outOffset).Push(inSize).Push(inOffset).Push(value).Push(address)
+	return p.pushGas(gas).Op(CALL)
+}
+
+// StaticCall appends the instructions of a STATICCALL. A nil gas forwards all the remaining gas.
+func (p *Program) StaticCall(gas any, address common.Address, inOffset, inSize, outOffset, outSize any) *Program {
+	p.Push(outSize).Push(outOffset).Push(inSize).Push(inOffset).Push(address)
+	return p.pushGas(gas).Op(STATICCALL)
+}
+
+func (p *Program) pushGas(gas any) *Program {
+	if gas == nil {
+		return p.Op(GAS)
+	}
+	return p.Push(gas)
+}
+
+// Size returns the size of the program so far, which is also the offset of the next instruction.
+func (p *Program) Size() int {
+	return len(p.code)
+}
+
+// Bytes returns the bytecode, with the label references resolved.
+func (p *Program) Bytes() []byte {
+	code := append([]byte(nil), p.code...)
+	for at, name := range p.jumps {
+		offset, ok := p.labels[name]
+		if !ok {
+			panic(fmt.Sprintf("undefined label %s", name))
+		}
+		code[at], code[at+1] = byte(offset>>8), byte(offset)
+	}
+	return code
+}
+
+// Hex returns the bytecode hex encoded, without the 0x prefix.
+func (p *Program) Hex() string {
+	return common.Bytes2Hex(p.Bytes())
+}
+
+// toBytes converts a value accepted by Program.Push to its big-endian bytes.
+func toBytes(value any) []byte {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			panic(fmt.Sprintf("cannot push negative value %d", v))
+		}
+		return uint256.NewInt(uint64(v)).Bytes()
+	case uint64:
+		return uint256.NewInt(v).Bytes()
+	case *uint256.Int:
+		return v.Bytes()
+	case []byte:
+		return v
+	case common.Address:
+		return v.Bytes()
+	case common.Hash:
+		return v.Bytes()
+	default:
+		panic(fmt.Sprintf("cannot push value of type %T", value))
+	}
+}
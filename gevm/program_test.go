@@ -0,0 +1,49 @@
+package gevm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  *Program
+		expected []byte
+	}{
+		{"push sizing", NewProgram().Push(0).Push(0x20).Push(uint64(0x0102)).Push(uint256.NewInt(0x10000)), []byte{0x60, 0x00, 0x60, 0x20, 0x61, 0x01, 0x02, 0x62, 0x01, 0x00, 0x00}},
+		{"push bytes", NewProgram().Push([]byte{0x00, 0xff}).Push(common.HexToHash("0x01")), []byte{0x60, 0xff, 0x60, 0x01}},
+		{"store", NewProgram().Push(0x20).Push0().Op(SSTORE), []byte{0x60, 0x20, 0x5f, 0x55}},
+		{"backward jump", NewProgram().Label("loop").JumpI("loop"), []byte{0x5b, 0x61, 0x00, 0x00, 0x57}},
+		{"forward jump", NewProgram().Jump("end").Op(STOP).Label("end"), []byte{0x61, 0x00, 0x05, 0x56, 0x00, 0x5b}},
+		{"mstore and return", NewProgram().Mstore(0, 42).Return(0, 32), []byte{0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}},
+		{"mstore bytes", NewProgram().MstoreBytes(0x20, []byte{0xab}), append(append([]byte{0x7f, 0xab}, make([]byte, 31)...), 0x60, 0x20, 0x52)},
+		{"mstore no bytes", NewProgram().MstoreBytes(0x20, nil), nil},
+		{"revert", NewProgram().Revert(0, 0), []byte{0x60, 0x00, 0x60, 0x00, 0xfd}},
+		{"call", NewProgram().Call(nil, common.HexToAddress("0xaa"), 1, 0, 4, 0, 32), []byte{0x60, 0x20, 0x60, 0x00, 0x60, 0x04, 0x60, 0x00, 0x60, 0x01, 0x60, 0xaa, 0x5a, 0xf1}},
+		{"staticcall", NewProgram().StaticCall(100, common.HexToAddress("0xaa"), 0, 0, 0, 0), []byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0xaa, 0x60, 0x64, 0xfa}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.program.Bytes())
+		})
+	}
+}
+
+func TestProgramMatchesAssembler(t *testing.T) {
+	code, err := Assemble("PUSH2 end JUMP STOP end: 1 PUSH1 0 SSTORE")
+	assert.NoError(t, err)
+	assert.Equal(t, code, NewProgram().Jump("end").Op(STOP).Label("end").Sstore(0, 1).Bytes())
+}
+
+func TestProgramPanics(t *testing.T) {
+	assert.Panics(t, func() { NewProgram().Jump("missing").Bytes() })
+	assert.Panics(t, func() { NewProgram().Label("a").Label("a") })
+	assert.Panics(t, func() { NewProgram().Push(-1) })
+	assert.Panics(t, func() { NewProgram().Push("0x01") })
+	assert.Panics(t, func() { NewProgram().Push(make([]byte, 33)) })
+}
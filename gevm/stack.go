@@ -21,14 +21,14 @@ type Stack struct {
 
 func (st *Stack) Push(value *uint256.Int) {
 	if len(st.data) == MAX_STACK_SIZE {
-		panic(ErrStackOverflow.Error())
+		panic(ErrStackOverflow)
 	}
 	st.data = append(st.data, *value)
 }
 
 func (st *Stack) Pop() uint256.Int {
 	if len(st.data) == 0 {
-		panic(ErrStackUnderflow.Error())
+		panic(ErrStackUnderflow)
 	}
 	ret := st.data[len(st.data)-1]
 	st.data = (st.data)[:len(st.data)-1]
@@ -37,12 +37,25 @@ func (st *Stack) Pop() uint256.Int {
 
 func (st *Stack) Peek() uint256.Int {
 	if len(st.data) == 0 {
-		panic(ErrStackUnderflow.Error())
+		panic(ErrStackUnderflow)
 	}
 	ret := st.data[len(st.data)-1]
 	return ret
 }
 
+// Len returns the number of items on the stack.
+func (st *Stack) Len() int {
+	return len(st.data)
+}
+
+// Back returns the n-th item from the top of the stack (0 being the top), which can be modified in place.
+func (st *Stack) Back(n int) *uint256.Int {
+	if n < 0 || n >= len(st.data) {
+		panic(ErrStackUnderflow)
+	}
+	return &st.data[len(st.data)-1-n]
+}
+
 func (st Stack) ToString() string {
 	var d string
 	if len(st.data) == 0 {
@@ -44,12 +44,22 @@ func TestStack(t *testing.T) {
 			},
 			expected: "[0x3, 0x2, 0x1]",
 		},
+		{
+			name: "Back",
+			operations: func(st *Stack) interface{} {
+				st.Push(uint256.NewInt(1))
+				st.Push(uint256.NewInt(2))
+				st.Back(1).SetUint64(3)
+				return []uint64{st.Back(0).Uint64(), st.Back(1).Uint64(), uint64(st.Len())}
+			},
+			expected: []uint64{2, 3, 2},
+		},
 		{
 			name: "Stack Underflow on Pop",
 			operations: func(st *Stack) interface{} {
 				defer func() { // We can either use this 'recover' approach or assert.Panics(...)
 					if r := recover(); r != nil {
-						assert.Equal(t, ErrStackUnderflow.Error(), r)
+						assert.Equal(t, ErrStackUnderflow, r)
 					}
 				}()
 				return st.Pop()
@@ -61,7 +71,7 @@ func TestStack(t *testing.T) {
 			operations: func(st *Stack) interface{} {
 				defer func() { // We can either use this 'recover' approach or assert.Panics(...)
 					if r := recover(); r != nil {
-						assert.Equal(t, ErrStackUnderflow.Error(), r)
+						assert.Equal(t, ErrStackUnderflow, r)
 					}
 				}()
 				return st.Peek()
@@ -73,7 +83,7 @@ func TestStack(t *testing.T) {
 			operations: func(st *Stack) interface{} {
 				defer func() { // We can either use this 'recover' approach or assert.Panics(...)
 					if r := recover(); r != nil {
-						assert.Equal(t, ErrStackOverflow.Error(), r)
+						assert.Equal(t, ErrStackOverflow, r)
 					}
 				}()
 				for i := 0; i <= MAX_STACK_SIZE; i++ { // MAX_STACK_SIZE+1 items to cause a panic
@@ -95,3 +105,31 @@ func TestStack(t *testing.T) {
 		})
 	}
 }
+
+// FuzzStack interprets each input byte as a stack operation: PUSH, POP, PEEK, DUP1-16 or SWAP1-16.
+func FuzzStack(f *testing.F) {
+	f.Add([]byte{0x00, 0x00, 0x90, 0x80})
+	f.Add([]byte{0x50, 0x50})       // pop an empty stack
+	f.Add([]byte{0x8f, 0x9f, 0x00}) // DUP16 and SWAP16 on a short stack
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		evm := setupEVM()
+		for i, op := range ops {
+			noRuntimePanic(t, func() {
+				switch {
+				case op >= 0x80 && op <= 0x8f:
+					dupN(evm, op-0x80+1)
+				case op >= 0x90 && op <= 0x9f:
+					swapN(evm, op-0x90+1)
+				case op == 0x50:
+					evm.Stack.Pop()
+				case op == 0x51:
+					evm.Stack.Peek()
+				default:
+					evm.Stack.Push(uint256.NewInt(uint64(i)))
+				}
+			})
+		}
+		assert.LessOrEqual(t, len(evm.Stack.data), MAX_STACK_SIZE)
+	})
+}
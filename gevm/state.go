@@ -0,0 +1,79 @@
+package gevm
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// Account represents an account in the world state.
+type Account struct {
+	Balance *uint256.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+}
+
+// NewAccount creates a new empty account.
+func NewAccount() *Account {
+	return &Account{
+		Balance: uint256.NewInt(0),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// Empty reports whether the account is empty as defined by EIP-161 (zero nonce, zero balance and no code).
+func (a *Account) Empty() bool {
+	return a.Nonce == 0 && a.Balance.IsZero() && len(a.Code) == 0
+}
+
+// Copy returns a deep copy of the account.
+func (a *Account) Copy() *Account {
+	cpy := &Account{
+		Balance: new(uint256.Int).Set(a.Balance),
+		Nonce:   a.Nonce,
+		Code:    bytes.Clone(a.Code),
+		Storage: make(map[common.Hash]common.Hash, len(a.Storage)),
+	}
+	for k, v := range a.Storage {
+		cpy.Storage[k] = v
+	}
+	return cpy
+}
+
+// WorldState maps addresses to their accounts.
+//
+// Unlike the execution environment's Storage, it outlives a single EVM execution,
+// so it can hold the pre and post state of a transaction.
+type WorldState map[common.Address]*Account
+
+// GetOrNewAccount returns the account at addr, creating an empty one if it doesn't exist.
+func (ws WorldState) GetOrNewAccount(addr common.Address) *Account {
+	if acc, ok := ws[addr]; ok {
+		return acc
+	}
+	acc := NewAccount()
+	ws[addr] = acc
+	return acc
+}
+
+// Exist reports whether an account exists at addr.
+func (ws WorldState) Exist(addr common.Address) bool {
+	_, ok := ws[addr]
+	return ok
+}
+
+// Copy returns a deep copy of the world state.
+func (ws WorldState) Copy() WorldState {
+	cpy := make(WorldState, len(ws))
+	for addr, acc := range ws {
+		cpy[addr] = acc.Copy()
+	}
+	return cpy
+}
+
+// NewWorldState creates a new, empty world state.
+func NewWorldState() WorldState {
+	return make(WorldState)
+}
@@ -0,0 +1,295 @@
+package gevm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// Transaction validation errors. These make a transaction invalid, meaning it can't be included in a block at all.
+var (
+	ErrNonceTooLow          = errors.New("nonce too low")
+	ErrNonceTooHigh         = errors.New("nonce too high")
+	ErrGasLimitReached      = errors.New("gas limit reached")
+	ErrInsufficientFunds    = errors.New("insufficient funds for gas * price + value")
+	ErrIntrinsicGas         = errors.New("intrinsic gas too low")
+	ErrFeeCapTooLow         = errors.New("gas price less than block base fee")
+	ErrMaxInitCodeSizeExeed = errors.New("max initcode size exceeded")
+)
+
+// Execution errors. These don't invalidate a transaction, they only cause its execution to fail.
+var (
+	ErrExecutionReverted     = errors.New("execution reverted")
+	ErrInvalidOpcode         = errors.New("invalid opcode")
+	ErrOutOfGas              = errors.New("out of gas")
+	ErrInvalidJump           = errors.New("invalid jump destination")
+	ErrContractAddrCollision = errors.New("contract address collision")
+	ErrCodeStoreOutOfGas     = errors.New("contract creation code storage out of gas")
+	ErrMaxCodeSizeExceeded   = errors.New("max code size exceeded")
+	ErrInvalidCode           = errors.New("invalid code: must not begin with 0xef")
+)
+
+const (
+	txGas                 uint64 = 21000 // Per transaction not creating a contract
+	txGasContractCreation uint64 = 53000 // Per transaction that creates a contract
+	txDataZeroGas         uint64 = 4     // Per byte of data attached to a transaction that equals zero
+	txDataNonZeroGas      uint64 = 16    // Per byte of data attached to a transaction that is not equal to zero (EIP-2028)
+	txAccessListAddress   uint64 = 2400  // Per address specified in an EIP-2930 access list
+	txAccessListSlot      uint64 = 1900  // Per storage key specified in an EIP-2930 access list
+	initCodeWordGas       uint64 = 2     // Per word of init code (EIP-3860)
+	createDataGas         uint64 = 200   // Per byte of deployed contract code
+	refundQuotient        uint64 = 5     // Max refund is gas used / refundQuotient (EIP-3529)
+
+	MaxCodeSize     = 24576           // Maximum bytecode size of a contract (EIP-170)
+	MaxInitCodeSize = 2 * MaxCodeSize // Maximum initcode size of a contract creation (EIP-3860)
+)
+
+// Message is a transaction reduced to the fields needed to execute it.
+type Message struct {
+	From       common.Address
+	To         *common.Address // nil means contract creation
+	Nonce      uint64
+	Value      *uint256.Int
+	GasLimit   uint64
+	GasPrice   *uint256.Int // effective gas price paid per unit of gas
+	Data       []byte
+	AccessList types.AccessList
+	TxHash     common.Hash // hash of the transaction, recorded in the logs
+	TxIndex    uint        // position of the transaction in its block
+}
+
+// ExecutionResult holds the outcome of applying a message to the world state, or of executing code with EVM.Execute.
+type ExecutionResult struct {
+	UsedGas         uint64 // total gas used, including the intrinsic gas and after refunds (only the gas used by the code for EVM.Execute)
+	Refund          uint64 // refund that was applied
+	Err             error  // execution error, nil if execution succeeded
+	ReturnData      []byte // returned data, or revert data if execution reverted
+	Logs            []*types.Log
+	ContractAddress common.Address // address of the created contract, if any
+}
+
+// Failed reports whether execution of the message failed.
+func (r *ExecutionResult) Failed() bool {
+	return r.Err != nil
+}
+
+// IntrinsicGas computes the gas a message is charged before any code runs.
+func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation bool) uint64 {
+	gas := txGas
+	if isContractCreation {
+		gas = txGasContractCreation
+	}
+	for _, b := range data {
+		if b == 0 {
+			gas += txDataZeroGas
+		} else {
+			gas += txDataNonZeroGas
+		}
+	}
+	if isContractCreation {
+		gas += initCodeWordGas * toWordSize(uint64(len(data)))
+	}
+	gas += uint64(len(accessList)) * txAccessListAddress
+	gas += uint64(accessList.StorageKeys()) * txAccessListSlot
+	return gas
+}
+
+// ApplyMessage executes a message against the world state and the given block.
+//
+// The returned error is non-nil only if the message is invalid, in which case the state is left untouched.
+// Failures during execution (reverts, out of gas, invalid opcodes, ...) are reported in ExecutionResult.Err.
+//
+// Only a single call frame is executed, as the EVM only supports calls to the native contracts of the chain config.
+// The tracer is optional and is attached to the EVM running the message's code.
+func ApplyMessage(state WorldState, msg *Message, block *Block, chainConfig ChainConfig, tracer Tracer) (*ExecutionResult, error) {
+	value := msg.Value
+	if value == nil {
+		value = new(uint256.Int)
+	}
+	gasPrice := msg.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(uint256.Int)
+	}
+	isCreate := msg.To == nil
+
+	// Validate the message
+	sender, ok := state[msg.From]
+	if !ok {
+		sender = NewAccount()
+	}
+	if msg.Nonce < sender.Nonce {
+		return nil, fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooLow, msg.From, msg.Nonce, sender.Nonce)
+	}
+	if msg.Nonce > sender.Nonce {
+		return nil, fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooHigh, msg.From, msg.Nonce, sender.Nonce)
+	}
+	if chainConfig.GasLimit < msg.GasLimit {
+		return nil, fmt.Errorf("%w: block gas limit %d, tx gas limit %d", ErrGasLimitReached, chainConfig.GasLimit, msg.GasLimit)
+	}
+	if gasPrice.Lt(uint256.NewInt(block.BaseFee)) {
+		return nil, fmt.Errorf("%w: gas price %v, base fee %d", ErrFeeCapTooLow, gasPrice, block.BaseFee)
+	}
+	gasCost := new(uint256.Int).Mul(uint256.NewInt(msg.GasLimit), gasPrice)
+	if sender.Balance.Lt(new(uint256.Int).Add(gasCost, value)) {
+		return nil, fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, msg.From, sender.Balance, new(uint256.Int).Add(gasCost, value))
+	}
+	if isCreate && len(msg.Data) > MaxInitCodeSize {
+		return nil, fmt.Errorf("%w: code size %d limit %d", ErrMaxInitCodeSizeExeed, len(msg.Data), MaxInitCodeSize)
+	}
+	intrinsicGas := IntrinsicGas(msg.Data, msg.AccessList, isCreate)
+	if msg.GasLimit < intrinsicGas {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, msg.GasLimit, intrinsicGas)
+	}
+
+	// Buy gas and bump the sender's nonce
+	state[msg.From] = sender
+	sender.Balance.Sub(sender.Balance, gasCost)
+	sender.Nonce++
+
+	result := &ExecutionResult{}
+	gasLeft := msg.GasLimit - intrinsicGas
+	snapshot := state.Copy()
+
+	var to common.Address
+	if isCreate {
+		to = crypto.CreateAddress(msg.From, msg.Nonce)
+		result.ContractAddress = to
+	} else {
+		to = *msg.To
+	}
+
+	if isCreate && state.Exist(to) && (state[to].Nonce != 0 || len(state[to].Code) != 0) {
+		result.Err = ErrContractAddrCollision
+		gasLeft = 0
+	} else {
+		receiver := state.GetOrNewAccount(to)
+		if isCreate {
+			receiver.Nonce = 1
+		}
+		state[msg.From].Balance.Sub(state[msg.From].Balance, value)
+		receiver.Balance.Add(receiver.Balance, value)
+
+		code, calldata := receiver.Code, msg.Data
+		if isCreate {
+			code, calldata = msg.Data, nil
+		}
+		if len(code) > 0 {
+			// GASPRICE reads the gas price from the block
+			blockCtx := *block
+			blockCtx.GasPrice = gasPrice.Clone()
+
+			evm := NewEVM(msg.From, gasLeft, 0, chainConfig.ChainID, chainConfig.GasLimit, code, calldata, &blockCtx)
+			evm.Value = value.Clone()
+			evm.Verbose = false
+			evm.Fork = chainConfig.Fork
+			evm.Natives = chainConfig.Natives
+			evm.Tracer = tracer
+			evm.Address = to
+			evm.Origin = msg.From
+			evm.State = state
+			evm.TxHash, evm.TxIndex = msg.TxHash, msg.TxIndex
+			loadStorage(evm.Storage, receiver.Storage)
+			for _, tuple := range msg.AccessList {
+				evm.warmAddresses[tuple.Address] = true
+				if tuple.Address != to {
+					continue
+				}
+				for _, key := range tuple.StorageKeys {
+					evm.Storage.cache[key] = true
+				}
+			}
+
+			execResult := evm.Execute()
+			result.Err = execResult.Err
+			result.ReturnData = execResult.ReturnData
+			gasLeft = evm.Gas
+
+			if result.Err == nil && isCreate {
+				result.Err = deployCode(receiver, evm.ReturnData, &gasLeft)
+			}
+			switch {
+			case result.Err == nil:
+				storeStorage(receiver.Storage, evm.Storage)
+				result.Refund = execResult.Refund
+				result.Logs = execResult.Logs
+			case errors.Is(result.Err, ErrExecutionReverted):
+				restoreState(state, snapshot)
+			default:
+				restoreState(state, snapshot)
+				gasLeft = 0
+			}
+		}
+		if result.Err != nil && isCreate {
+			result.ContractAddress = common.Address{}
+		}
+	}
+
+	// Apply the refund, capped at a fifth of the gas used (EIP-3529)
+	gasUsed := msg.GasLimit - gasLeft
+	result.Refund = min(result.Refund, gasUsed/refundQuotient)
+	gasLeft += result.Refund
+	result.UsedGas = msg.GasLimit - gasLeft
+
+	// Return the remaining gas to the sender and pay the priority fee to the coinbase
+	sender = state.GetOrNewAccount(msg.From)
+	sender.Balance.Add(sender.Balance, new(uint256.Int).Mul(uint256.NewInt(gasLeft), gasPrice))
+	tip := new(uint256.Int).Sub(gasPrice, uint256.NewInt(block.BaseFee))
+	coinbase := state.GetOrNewAccount(block.Coinbase)
+	coinbase.Balance.Add(coinbase.Balance, new(uint256.Int).Mul(uint256.NewInt(result.UsedGas), tip))
+
+	// Remove the touched accounts that ended up empty (EIP-161)
+	for _, addr := range []common.Address{msg.From, to, block.Coinbase} {
+		if acc, ok := state[addr]; ok && acc.Empty() {
+			delete(state, addr)
+		}
+	}
+	return result, nil
+}
+
+// deployCode charges for and stores the code returned by a contract creation.
+func deployCode(acc *Account, code []byte, gasLeft *uint64) error {
+	if len(code) > MaxCodeSize {
+		return ErrMaxCodeSizeExceeded
+	}
+	if len(code) > 0 && code[0] == 0xEF {
+		return ErrInvalidCode
+	}
+	gas := uint64(len(code)) * createDataGas
+	if *gasLeft < gas {
+		return ErrCodeStoreOutOfGas
+	}
+	*gasLeft -= gas
+	acc.Code = code
+	return nil
+}
+
+func restoreState(state, snapshot WorldState) {
+	for addr := range state {
+		delete(state, addr)
+	}
+	for addr, acc := range snapshot {
+		state[addr] = acc
+	}
+}
+
+// loadStorage copies an account's storage into the EVM storage.
+func loadStorage(dst *Storage, src map[common.Hash]common.Hash) {
+	for key, value := range src {
+		dst.data[key] = value
+	}
+}
+
+// storeStorage writes the EVM storage back to an account's storage, dropping zeroed slots.
+func storeStorage(dst map[common.Hash]common.Hash, src *Storage) {
+	for key, value := range src.data {
+		if value == (common.Hash{}) {
+			delete(dst, key)
+		} else {
+			dst[key] = value
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gevm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+var errAny = errors.New("any error")
+
+func TestApplyMessage(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+		contract = common.HexToAddress("0x1000")
+		coinbase = common.HexToAddress("0xc0ffee")
+	)
+	tests := []struct {
+		name      string
+		code      []byte
+		gasLimit  uint64
+		nonce     uint64
+		wantErr   error // invalid message error
+		wantExec  error // execution error
+		wantSlot0 common.Hash
+		wantGas   uint64
+	}{
+		{
+			name:     "Plain transfer",
+			gasLimit: 21000,
+			wantGas:  21000,
+		},
+		{
+			name:      "Store",
+			code:      NewProgram().Push(1).Push0().Op(SSTORE, STOP).Bytes(),
+			gasLimit:  100000,
+			wantSlot0: common.HexToHash("0x01"),
+			wantGas:   21000 + 3 + 2 + 22100,
+		},
+		{
+			name:     "Revert discards storage",
+			code:     NewProgram().Push(1).Push0().Op(SSTORE).Push0().Push0().Op(REVERT).Bytes(),
+			gasLimit: 100000,
+			wantExec: ErrExecutionReverted,
+			wantGas:  21000 + 3 + 2 + 22100 + 2 + 2,
+		},
+		{
+			name:     "Out of gas consumes all gas",
+			code:     NewProgram().Push(1).Push0().Op(SSTORE, STOP).Bytes(),
+			gasLimit: 30000,
+			wantExec: errAny,
+			wantGas:  30000,
+		},
+		{
+			name:     "Intrinsic gas too low",
+			gasLimit: 20000,
+			wantErr:  ErrIntrinsicGas,
+		},
+		{
+			name:     "Nonce too high",
+			gasLimit: 21000,
+			nonce:    1,
+			wantErr:  ErrNonceTooHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewWorldState()
+			state.GetOrNewAccount(sender).Balance = uint256.NewInt(1e18)
+			state.GetOrNewAccount(contract).Code = tt.code
+			block := NewBlock(coinbase, 0, 1, 0, 1, time.Unix(1000, 0))
+			msg := &Message{
+				From:     sender,
+				To:       &contract,
+				Nonce:    tt.nonce,
+				Value:    uint256.NewInt(1),
+				GasLimit: tt.gasLimit,
+				GasPrice: uint256.NewInt(2),
+			}
+
+			res, err := ApplyMessage(state, msg, block, ChainConfig{ChainID: 1, GasLimit: 30_000_000}, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, uint64(0), state[sender].Nonce, "state must be untouched")
+				return
+			}
+			assert.NoError(t, err)
+			switch tt.wantExec {
+			case nil:
+				assert.NoError(t, res.Err)
+			case errAny:
+				assert.Error(t, res.Err)
+			default:
+				assert.ErrorIs(t, res.Err, tt.wantExec)
+			}
+			assert.Equal(t, tt.wantGas, res.UsedGas)
+			assert.Equal(t, uint64(1), state[sender].Nonce)
+			assert.Equal(t, tt.wantSlot0, state[contract].Storage[common.Hash{}])
+
+			// The sender pays for the gas and the value, the coinbase receives the priority fee
+			wantBalance := 1e18 - res.UsedGas*2
+			if res.Err == nil {
+				wantBalance--
+			}
+			assert.Equal(t, wantBalance, state[sender].Balance.Uint64())
+			assert.Equal(t, res.UsedGas, state[coinbase].Balance.Uint64())
+		})
+	}
+}
+
+func TestIntrinsicGas(t *testing.T) {
+	assert.Equal(t, uint64(21000), IntrinsicGas(nil, nil, false))
+	assert.Equal(t, uint64(21000+4+16), IntrinsicGas([]byte{0x00, 0x01}, nil, false))
+	assert.Equal(t, uint64(53000+16+2), IntrinsicGas([]byte{0x01}, nil, true))
+}
+
+func TestApplyMessageLargeValues(t *testing.T) {
+	sender, contract := common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"), common.HexToAddress("0x1000")
+	state := NewWorldState()
+	state.GetOrNewAccount(sender).Balance = new(uint256.Int).Lsh(uint256.NewInt(1), 128)
+	state.GetOrNewAccount(contract).Code = NewProgram().Op(CALLVALUE).Push0().Op(SSTORE).Op(GASPRICE).Push(1).Op(SSTORE, STOP).Bytes()
+	// Both above 2^64, which a uint64 would truncate
+	value := new(uint256.Int).AddUint64(new(uint256.Int).Lsh(uint256.NewInt(1), 64), 1)
+	gasPrice := new(uint256.Int).AddUint64(new(uint256.Int).Lsh(uint256.NewInt(1), 64), 2)
+	msg := &Message{From: sender, To: &contract, Value: value, GasLimit: 100000, GasPrice: gasPrice}
+
+	res, err := ApplyMessage(state, msg, NewBlock(common.Address{}, 0, 1, 0, 1, time.Unix(1000, 0)), ChainConfig{ChainID: 1, GasLimit: 30_000_000}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Err)
+	assert.Equal(t, common.Hash(value.Bytes32()), state[contract].Storage[common.Hash{}])
+	assert.Equal(t, common.Hash(gasPrice.Bytes32()), state[contract].Storage[common.HexToHash("0x01")])
+}
@@ -1,44 +1,66 @@
 package gevm
 
 import (
+	"maps"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// Storage holds the storage of the executing account, keyed by the full 256-bit slot.
 type Storage struct {
-	data  map[int]common.Hash
-	cache map[int]bool // slot keys cache for warm storage access
+	data     map[common.Hash]common.Hash
+	cache    map[common.Hash]bool        // slot keys cache for warm storage access
+	original map[common.Hash]common.Hash // values of the slots before their first Store, for the SSTORE gas cost
 }
 
-func (s *Storage) Load(key int) (value common.Hash, isWarm bool) {
+func (s *Storage) Load(key common.Hash) (value common.Hash, isWarm bool) {
 	isWarm = s.cache[key]
 	if !isWarm {
 		s.cache[key] = true
 	}
-	value, ok := s.data[key]
-	if !ok {
-		return common.Hash{}, false
-	}
-	return value, ok
+	return s.data[key], isWarm
 }
 
-func (s *Storage) Store(key int, value common.Hash) (isWarm bool) {
+func (s *Storage) Store(key common.Hash, value common.Hash) (isWarm bool) {
 	isWarm = s.cache[key]
 	if !isWarm {
 		s.cache[key] = true
 	}
+	if _, ok := s.original[key]; !ok {
+		s.original[key] = s.data[key]
+	}
 	s.data[key] = value
 	return isWarm
 }
 
 // Get does the same thing as Load, except that it doesn't mark the storage slot as 'warm'.
 // It is used in the 'calcSstoreGasCost' function in 'common.go'
-func (s *Storage) Get(slot int) (value common.Hash, isWarm bool) {
+func (s *Storage) Get(slot common.Hash) (value common.Hash, isWarm bool) {
 	return s.data[slot], s.cache[slot]
 }
 
+// Original returns the value of a slot at the start of the execution, before any Store to it.
+func (s *Storage) Original(slot common.Hash) common.Hash {
+	if value, ok := s.original[slot]; ok {
+		return value
+	}
+	return s.data[slot]
+}
+
+// Set writes a slot without marking it as 'warm', so that it can be changed without affecting gas costs.
+func (s *Storage) Set(slot common.Hash, value common.Hash) {
+	s.data[slot] = value
+}
+
+// Slots returns a copy of the stored slots.
+func (s *Storage) Slots() map[common.Hash]common.Hash {
+	return maps.Clone(s.data)
+}
+
 func NewStorage() *Storage {
 	return &Storage{
-		cache: make(map[int]bool),
-		data:  make(map[int]common.Hash),
+		cache:    make(map[common.Hash]bool),
+		data:     make(map[common.Hash]common.Hash),
+		original: make(map[common.Hash]common.Hash),
 	}
 }
@@ -10,17 +10,17 @@ import (
 func TestStorage(t *testing.T) {
 	tests := []struct {
 		name     string
-		slot     int
+		slot     common.Hash
 		value    common.Hash
-		testFunc func(storage *Storage, slot int, value common.Hash) (any, any) // I use (any, any) and not (common.Hash, bool) because the last test case returns (bool, bool)
+		testFunc func(storage *Storage, slot common.Hash, value common.Hash) (any, any) // I use (any, any) and not (common.Hash, bool) because the last test case returns (bool, bool)
 		want     any
 		want2    any
 	}{
 		{
 			name:  "TestStorage_Load",
 			value: common.HexToHash("0x20"),
-			slot:  1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot:  common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				storage.Store(slot, value)
 				loadedValue, isWarm := storage.Load(slot)
 				return loadedValue, isWarm
@@ -30,8 +30,8 @@ func TestStorage(t *testing.T) {
 		},
 		{
 			name: "TestStorage_Load_NonExistentKey",
-			slot: 1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot: common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				loadedValue, isWarm := storage.Load(slot)
 				return loadedValue, isWarm
 			},
@@ -41,8 +41,8 @@ func TestStorage(t *testing.T) {
 		{
 			name:  "TestStorage_Store",
 			value: common.HexToHash("0x20"),
-			slot:  1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot:  common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				isWarm := storage.Store(slot, value)
 				storedValue, _ := storage.data[slot]
 				return storedValue, isWarm
@@ -53,8 +53,8 @@ func TestStorage(t *testing.T) {
 		{
 			name:  "TestStorage_Get",
 			value: common.HexToHash("0x20"),
-			slot:  1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot:  common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				storage.Store(slot, value)
 				storedValue, isWarm := storage.Get(slot)
 				return storedValue, isWarm
@@ -64,8 +64,8 @@ func TestStorage(t *testing.T) {
 		},
 		{
 			name: "TestStorage_Get_NonExistentKey",
-			slot: 1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot: common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				storedValue, isWarm := storage.Get(slot)
 				return storedValue, isWarm
 			},
@@ -74,8 +74,8 @@ func TestStorage(t *testing.T) {
 		},
 		{
 			name: "TestStorage_Load_WarmsUpKey",
-			slot: 1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot: common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				_ = common.HexToHash("0x20")
 				initialWarm := storage.cache[slot]
 				storage.Load(slot)
@@ -88,8 +88,8 @@ func TestStorage(t *testing.T) {
 		{
 			name:  "TestStorage_Store_WarmsUpKey",
 			value: common.HexToHash("0x20"),
-			slot:  1,
-			testFunc: func(storage *Storage, slot int, value common.Hash) (any, any) {
+			slot:  common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
 				initialWarm := storage.cache[slot]
 				storage.Store(slot, value)
 				finalWarm := storage.cache[slot]
@@ -98,6 +98,17 @@ func TestStorage(t *testing.T) {
 			want:  false,
 			want2: true,
 		},
+		{
+			name:  "TestStorage_Set_DoesNotWarmUpKey",
+			value: common.HexToHash("0x20"),
+			slot:  common.HexToHash("0x1"),
+			testFunc: func(storage *Storage, slot common.Hash, value common.Hash) (any, any) {
+				storage.Set(slot, value)
+				return storage.Slots()[slot], storage.cache[slot]
+			},
+			want:  common.HexToHash("0x20"),
+			want2: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,10 +125,11 @@ func TestStorage(t *testing.T) {
 		})
 	}
 }
+
 func TestCalcSstoreGasCost(t *testing.T) {
 	tests := []struct {
 		name           string
-		slot           int
+		slot           common.Hash
 		newValue       common.Hash
 		setup          func(evm *EVM) // Setup function to initialize the EVM storage
 		expectedGas    uint64
@@ -125,17 +137,17 @@ func TestCalcSstoreGasCost(t *testing.T) {
 	}{
 		{
 			name:     "No-Op",
-			slot:     1,
+			slot:     common.HexToHash("0x1"),
 			newValue: common.HexToHash("0x1"),
 			setup: func(evm *EVM) {
-				evm.Storage.Store(1, common.HexToHash("0x1"))
+				evm.Storage.Store(common.HexToHash("0x1"), common.HexToHash("0x1"))
 			},
 			expectedGas:    100,
 			expectedRefund: 0,
 		},
 		{
 			name:           "New Slot Creation - Zero to Non-Zero",
-			slot:           2,
+			slot:           common.HexToHash("0x2"),
 			newValue:       common.HexToHash("0x1"),
 			setup:          func(evm *EVM) {},
 			expectedGas:    22_100,
@@ -143,24 +155,67 @@ func TestCalcSstoreGasCost(t *testing.T) {
 		},
 		{
 			name:     "Slot Deletion - Non-Zero to Zero",
-			slot:     3,
+			slot:     common.HexToHash("0x3"),
 			newValue: common.Hash{},
 			setup: func(evm *EVM) {
-				evm.Storage.Store(3, common.HexToHash("0x1"))
+				evm.Storage.Set(common.HexToHash("0x3"), common.HexToHash("0x1"))
+				evm.Storage.Load(common.HexToHash("0x3"))
 			},
-			expectedGas:    3000,
+			expectedGas:    2900,
 			expectedRefund: 4800,
 		},
 		{
 			name:     "Slot Update - Non-Zero to Non-Zero",
-			slot:     4,
+			slot:     common.HexToHash("0x4"),
+			newValue: common.HexToHash("0x2"),
+			setup: func(evm *EVM) {
+				evm.Storage.Set(common.HexToHash("0x4"), common.HexToHash("0x1"))
+				evm.Storage.Load(common.HexToHash("0x4"))
+			},
+			expectedGas:    2900,
+			expectedRefund: 0,
+		},
+		{
+			name:     "Cold Slot Deletion",
+			slot:     common.HexToHash("0x5"),
+			newValue: common.Hash{},
+			setup: func(evm *EVM) {
+				evm.Storage.Set(common.HexToHash("0x5"), common.HexToHash("0x1"))
+			},
+			expectedGas:    5000,
+			expectedRefund: 4800,
+		},
+		{
+			name:     "Rewrite of a Written Slot",
+			slot:     common.HexToHash("0x6"),
 			newValue: common.HexToHash("0x2"),
 			setup: func(evm *EVM) {
-				evm.Storage.Store(4, common.HexToHash("0x1"))
+				evm.Storage.Store(common.HexToHash("0x6"), common.HexToHash("0x1"))
 			},
-			expectedGas:    3000,
+			expectedGas:    100,
 			expectedRefund: 0,
 		},
+		{
+			name:     "Reset to the Original Zero",
+			slot:     common.HexToHash("0x7"),
+			newValue: common.Hash{},
+			setup: func(evm *EVM) {
+				evm.Storage.Store(common.HexToHash("0x7"), common.HexToHash("0x1"))
+			},
+			expectedGas:    100,
+			expectedRefund: 19_900,
+		},
+		{
+			name:     "Reset to the Original Non-Zero",
+			slot:     common.HexToHash("0x8"),
+			newValue: common.HexToHash("0x1"),
+			setup: func(evm *EVM) {
+				evm.Storage.Set(common.HexToHash("0x8"), common.HexToHash("0x1"))
+				evm.Storage.Store(common.HexToHash("0x8"), common.HexToHash("0x2"))
+			},
+			expectedGas:    100,
+			expectedRefund: 2800,
+		},
 	}
 
 	for _, tt := range tests {
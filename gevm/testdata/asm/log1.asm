@@ -0,0 +1,9 @@
+; Emits two LOG1, each with 64 bytes of data
+#macro EMIT(a, b, topic)
+    a 0 MSTORE        ; memory[0:32] = a
+    b 0x20 MSTORE     ; memory[32:64] = b
+    topic 0x40 0 LOG1
+#end
+
+EMIT(10, 20, 0x1f)
+EMIT(10, 150, 0x2a)
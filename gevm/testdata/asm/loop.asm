@@ -0,0 +1,7 @@
+; Counts down from 3 to 0, storing the counter in slot 0
+3
+loop:
+    1 SWAP1 SUB   ; counter - 1
+    DUP1 0 SSTORE
+    DUP1 loop JUMPI
+STOP
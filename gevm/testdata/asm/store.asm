@@ -0,0 +1,13 @@
+; Stores to three slots, then zeroes slot 0 for a gas refund
+#define VALUE 0xa
+
+#macro STORE(slot, value)
+    PUSH1 value
+    PUSH1 slot
+    SSTORE
+#end
+
+PUSH1 0x20 PUSH0 SSTORE ; slot 0 = 0x20
+PUSH0 PUSH0 SSTORE      ; slot 0 = 0, refunded
+STORE(0x1f, VALUE)
+STORE(0x2f, VALUE)
@@ -0,0 +1,36 @@
+package gevm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// Tracer is notified of every step the EVM executes. It can be set on the EVM's ExecutionRuntime
+// or passed to ApplyMessage. The gas an instruction consumed is the difference of the gas left
+// between its CaptureState and the next call.
+type Tracer interface {
+	// CaptureState is called before the opcode at pc is executed, so the EVM state it sees
+	// (stack, memory, gas, ...) is the result of all the previous steps.
+	CaptureState(evm *EVM, pc uint64, op Opcode)
+
+	// CaptureEnd is called by Execute once execution halts, however it halts. The gas left is final:
+	// an execution failing other than by reverting has consumed all its gas.
+	CaptureEnd(evm *EVM)
+}
+
+// NativeCall is a CALL or STATICCALL of a native contract.
+type NativeCall struct {
+	Op     Opcode
+	To     common.Address
+	Input  []byte
+	Output []byte // returned data or revert data
+	Value  *uint256.Int
+	Gas    uint64 // gas passed to the call
+	Err    error  // nil if the call succeeded
+}
+
+// NativeCallTracer is implemented by the tracers that want the calls of native contracts. The EVM passes them to its
+// tracer once the native contract returned.
+type NativeCallTracer interface {
+	CaptureNativeCall(evm *EVM, call NativeCall)
+}
@@ -1,30 +1,37 @@
 package gevm
 
 import (
+	"maps"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type TransientStorage struct {
-	data map[int]common.Hash
+	data map[common.Hash]common.Hash
 }
 
-func (s *TransientStorage) Load(key int) common.Hash {
+func (s *TransientStorage) Load(key common.Hash) common.Hash {
 	if _, ok := s.data[key]; !ok {
 		return common.Hash{}
 	}
 	return s.data[key]
 }
 
-func (s *TransientStorage) Store(key int, value common.Hash) {
+func (s *TransientStorage) Store(key common.Hash, value common.Hash) {
 	s.data[key] = value
 }
 
+// Slots returns a copy of the stored slots.
+func (s *TransientStorage) Slots() map[common.Hash]common.Hash {
+	return maps.Clone(s.data)
+}
+
 func (s *TransientStorage) Clear() {
 	*s = *NewTransientStorage()
 }
 
 func NewTransientStorage() *TransientStorage {
 	return &TransientStorage{
-		data: make(map[int]common.Hash),
+		data: make(map[common.Hash]common.Hash),
 	}
 }
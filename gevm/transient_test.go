@@ -10,16 +10,16 @@ import (
 func TestTransientStorage(t *testing.T) {
 	tests := []struct {
 		name     string
-		slot     int
+		slot     common.Hash
 		value    common.Hash
-		testFunc func(ts *TransientStorage, slot int, value common.Hash) any // I use ( any) and not (common.Hash, bool) because the last test case returns (bool, bool)
+		testFunc func(ts *TransientStorage, slot common.Hash, value common.Hash) any // I use ( any) and not (common.Hash, bool) because the last test case returns (bool, bool)
 		want     any
 	}{
 		{
 			name:  "TestTransientStorage_Load",
-			slot:  0,
+			slot:  common.HexToHash("0x0"),
 			value: common.HexToHash("0x20"),
-			testFunc: func(ts *TransientStorage, slot int, value common.Hash) any {
+			testFunc: func(ts *TransientStorage, slot common.Hash, value common.Hash) any {
 				ts.Store(slot, value)
 				loadedValue := ts.Load(slot)
 				return loadedValue
@@ -28,8 +28,8 @@ func TestTransientStorage(t *testing.T) {
 		},
 		{
 			name: "TestTransientStorage_Load_NonExistentKey",
-			slot: 5,
-			testFunc: func(ts *TransientStorage, slot int, value common.Hash) any {
+			slot: common.HexToHash("0x5"),
+			testFunc: func(ts *TransientStorage, slot common.Hash, value common.Hash) any {
 				loadedValue := ts.Load(slot)
 				return loadedValue
 			},
@@ -37,9 +37,9 @@ func TestTransientStorage(t *testing.T) {
 		},
 		{
 			name:  "TestTransientStorage_Store",
-			slot:  256,
+			slot:  common.HexToHash("0x100"),
 			value: common.HexToHash("0xa"),
-			testFunc: func(ts *TransientStorage, slot int, value common.Hash) any {
+			testFunc: func(ts *TransientStorage, slot common.Hash, value common.Hash) any {
 				ts.Store(slot, value)
 				storedValue := ts.data[slot]
 				return storedValue
@@ -48,9 +48,9 @@ func TestTransientStorage(t *testing.T) {
 		},
 		{
 			name:  "TestTransientStorage_Clear",
-			slot:  0,
+			slot:  common.HexToHash("0x0"),
 			value: common.HexToHash("0x20"),
-			testFunc: func(ts *TransientStorage, slot int, value common.Hash) any {
+			testFunc: func(ts *TransientStorage, slot common.Hash, value common.Hash) any {
 				ts.Store(slot, value)
 				// Clear the storage
 				ts.Clear()
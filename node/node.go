@@ -0,0 +1,438 @@
+// Package node is a development node: it keeps a chain of blocks executed by gevm and serves it over the Ethereum
+// JSON-RPC API, so tools pointed at a local devnet can use gevm instead.
+//
+// Every transaction sent is mined right away in a block of its own (automine), so its receipt is available as soon as
+// it is sent. A Node keeps the state after every block, to answer queries about past blocks.
+package node
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/tracers"
+)
+
+// Config describes the chain of a node.
+type Config struct {
+	ChainID  uint64
+	GasLimit uint64 // block gas limit
+	BaseFee  uint64 // base fee of every block
+	Coinbase common.Address
+	Fork     gevm.Fork
+	Alloc    gevm.WorldState // state of the genesis block
+
+	Mined func(b *Block) // optional, called after a block is mined
+}
+
+// Block is a mined block with the receipts of its transactions.
+type Block struct {
+	Header       *types.Header
+	Transactions types.Transactions
+	Receipts     types.Receipts
+	Senders      []common.Address // sender of each transaction
+
+	state gevm.WorldState // state after the block
+}
+
+// Hash returns the hash of the block header.
+func (b *Block) Hash() common.Hash {
+	return b.Header.Hash()
+}
+
+// Number returns the block number.
+func (b *Block) Number() uint64 {
+	return b.Header.Number.Uint64()
+}
+
+// txLookup locates a transaction in the chain.
+type txLookup struct {
+	block *Block
+	index int
+}
+
+// Node is a chain of blocks mined on demand. It is safe for concurrent use.
+type Node struct {
+	mu     sync.Mutex
+	config Config
+	signer types.Signer
+	blocks []*Block
+	hashes map[common.Hash]*Block
+	txs    map[common.Hash]txLookup
+	now    func() time.Time
+}
+
+// New creates a node whose chain holds only the genesis block.
+func New(config Config) *Node {
+	alloc := config.Alloc
+	if alloc == nil {
+		alloc = gevm.NewWorldState()
+	}
+	n := &Node{
+		config: config,
+		signer: types.LatestSignerForChainID(new(big.Int).SetUint64(config.ChainID)),
+		hashes: make(map[common.Hash]*Block),
+		txs:    make(map[common.Hash]txLookup),
+		now:    time.Now,
+	}
+	n.append(&Block{Header: n.header(nil, nil), state: alloc.Copy()})
+	return n
+}
+
+// header creates the header of the block following parent, nil for the genesis block.
+func (n *Node) header(parent *Block, receipts types.Receipts) *types.Header {
+	h := &types.Header{
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    n.config.Coinbase,
+		Root:        types.EmptyRootHash,
+		TxHash:      types.EmptyTxsHash,
+		ReceiptHash: types.EmptyReceiptsHash,
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int),
+		GasLimit:    n.config.GasLimit,
+		Time:        uint64(n.now().Unix()),
+		BaseFee:     new(big.Int).SetUint64(n.config.BaseFee),
+	}
+	if parent != nil {
+		h.ParentHash = parent.Hash()
+		h.Number.SetUint64(parent.Number() + 1)
+		h.Time = max(h.Time, parent.Header.Time+1)
+	}
+	for _, r := range receipts {
+		h.GasUsed += r.GasUsed
+	}
+	if len(receipts) > 0 {
+		h.ReceiptHash = types.DeriveSha(receipts, trie.NewStackTrie(nil))
+		h.Bloom = types.CreateBloom(receipts)
+	}
+	return h
+}
+
+func (n *Node) append(b *Block) {
+	n.blocks = append(n.blocks, b)
+	n.hashes[b.Hash()] = b
+	for i, tx := range b.Transactions {
+		n.txs[tx.Hash()] = txLookup{b, i}
+	}
+}
+
+func (n *Node) head() *Block {
+	return n.blocks[len(n.blocks)-1]
+}
+
+// ChainID returns the chain ID transactions must be signed for.
+func (n *Node) ChainID() uint64 {
+	return n.config.ChainID
+}
+
+// BaseFee returns the base fee of the blocks.
+func (n *Node) BaseFee() uint64 {
+	return n.config.BaseFee
+}
+
+// BlockNumber returns the number of the latest block.
+func (n *Node) BlockNumber() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.head().Number()
+}
+
+// BlockByNumber returns the block with a number, nil if there is none.
+func (n *Node) BlockByNumber(number uint64) *Block {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if number >= uint64(len(n.blocks)) {
+		return nil
+	}
+	return n.blocks[number]
+}
+
+// BlockByHash returns the block with a hash, nil if there is none.
+func (n *Node) BlockByHash(hash common.Hash) *Block {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.hashes[hash]
+}
+
+// Account returns a copy of the account at addr after a block, nil if it doesn't exist.
+func (n *Node) Account(addr common.Address, number uint64) (*gevm.Account, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if number >= uint64(len(n.blocks)) {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	acc, ok := n.blocks[number].state[addr]
+	if !ok {
+		return nil, nil
+	}
+	return acc.Copy(), nil
+}
+
+// SendTransaction executes a signed transaction and mines it in a new block.
+// An error means the transaction is invalid and wasn't mined; a failed execution is reported in its receipt.
+func (n *Node) SendTransaction(tx *types.Transaction) (*types.Receipt, error) {
+	if tx.Type() == types.BlobTxType {
+		return nil, errors.New("blob transactions are not supported")
+	}
+	from, err := types.Sender(n.signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %v", err)
+	}
+	msg, err := n.message(tx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.txs[tx.Hash()]; ok {
+		return nil, errors.New("already known")
+	}
+	parent := n.head()
+	state := parent.state.Copy()
+	number := parent.Number() + 1
+	blockTime := time.Unix(int64(max(uint64(n.now().Unix()), parent.Header.Time+1)), 0)
+	blockCtx := gevm.NewBlock(n.config.Coinbase, 0, number, 0, n.config.BaseFee, blockTime)
+	res, err := gevm.ApplyMessage(state, msg, blockCtx, n.chainConfig(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: res.UsedGas,
+		Logs:              res.Logs,
+		TxHash:            tx.Hash(),
+		ContractAddress:   res.ContractAddress,
+		GasUsed:           res.UsedGas,
+		EffectiveGasPrice: msg.GasPrice.ToBig(),
+		BlockNumber:       new(big.Int).SetUint64(number),
+	}
+	if res.Failed() {
+		receipt.Status = types.ReceiptStatusFailed
+	}
+	if receipt.Logs == nil {
+		receipt.Logs = []*types.Log{}
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	header := n.header(parent, types.Receipts{receipt})
+	header.TxHash = types.DeriveSha(types.Transactions{tx}, trie.NewStackTrie(nil))
+	block := &Block{
+		Header:       header,
+		Transactions: types.Transactions{tx},
+		Receipts:     types.Receipts{receipt},
+		Senders:      []common.Address{from},
+		state:        state,
+	}
+	receipt.BlockHash = block.Hash()
+	for _, log := range receipt.Logs {
+		log.BlockHash = receipt.BlockHash
+	}
+	n.append(block)
+	if n.config.Mined != nil {
+		n.config.Mined(block)
+	}
+	return receipt, nil
+}
+
+// message converts a transaction into the message it executes, the gas price being the effective gas price.
+func (n *Node) message(tx *types.Transaction, from common.Address) (*gevm.Message, error) {
+	value, overflow := uint256.FromBig(tx.Value())
+	if overflow {
+		return nil, errors.New("value overflows 256 bits")
+	}
+	baseFee := new(big.Int).SetUint64(n.config.BaseFee)
+	// The effective gas price of a dynamic fee transaction is min(maxFeePerGas, baseFee + maxPriorityFeePerGas)
+	gasPrice, overflow := uint256.FromBig(new(big.Int).Add(baseFee, tx.EffectiveGasTipValue(baseFee)))
+	if overflow {
+		return nil, errors.New("gas price overflows 256 bits")
+	}
+	return &gevm.Message{
+		From:       from,
+		To:         tx.To(),
+		Nonce:      tx.Nonce(),
+		Value:      value,
+		GasLimit:   tx.Gas(),
+		GasPrice:   gasPrice,
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+		TxHash:     tx.Hash(),
+	}, nil
+}
+
+// Call executes a message on top of the state after a block, without mining it. The nonce of the message is ignored,
+// and a message with a zero gas price isn't subject to the base fee.
+func (n *Node) Call(msg *gevm.Message, number uint64) (*gevm.ExecutionResult, error) {
+	state, msg, blockCtx, err := n.prepare(msg, number)
+	if err != nil {
+		return nil, err
+	}
+	return gevm.ApplyMessage(state, msg, blockCtx, n.chainConfig(), nil)
+}
+
+// EstimateGas returns the lowest gas limit a message succeeds with on top of the state after a block, like Call does.
+func (n *Node) EstimateGas(msg *gevm.Message, number uint64) (uint64, error) {
+	state, msg, blockCtx, err := n.prepare(msg, number)
+	if err != nil {
+		return 0, err
+	}
+	return gevm.EstimateGas(state, msg, blockCtx, n.chainConfig())
+}
+
+// CreateAccessList generates the access list lowering the gas a message uses the most, on top of the state after a
+// block like Call does.
+func (n *Node) CreateAccessList(msg *gevm.Message, number uint64) (*tracers.AccessListResult, error) {
+	state, msg, blockCtx, err := n.prepare(msg, number)
+	if err != nil {
+		return nil, err
+	}
+	return tracers.CreateAccessList(state, msg, blockCtx, n.chainConfig())
+}
+
+// TraceCall executes a message with a tracer, on top of the state after a block like Call does.
+func (n *Node) TraceCall(msg *gevm.Message, number uint64, tracer tracers.Tracer) (*gevm.ExecutionResult, error) {
+	state, msg, blockCtx, err := n.prepare(msg, number)
+	if err != nil {
+		return nil, err
+	}
+	return tracers.TraceMessage(state, msg, blockCtx, n.chainConfig(), tracer)
+}
+
+// TraceTransaction executes a mined transaction again with a tracer, on top of the state before its block.
+func (n *Node) TraceTransaction(hash common.Hash, tracer tracers.Tracer) (*gevm.ExecutionResult, error) {
+	tx, block, index, from := n.Transaction(hash)
+	if block == nil {
+		return nil, fmt.Errorf("transaction %v not found", hash)
+	}
+	msg, err := n.message(tx, from)
+	if err != nil {
+		return nil, err
+	}
+	msg.TxIndex = uint(index)
+	n.mu.Lock()
+	state := n.blocks[block.Number()-1].state.Copy()
+	n.mu.Unlock()
+	blockCtx := gevm.NewBlock(n.config.Coinbase, 0, block.Number(), 0, n.config.BaseFee, time.Unix(int64(block.Header.Time), 0))
+	return tracers.TraceMessage(state, msg, blockCtx, n.chainConfig(), tracer)
+}
+
+// prepare returns a copy of the state after a block to execute msg on, a copy of msg with the sender's nonce and a
+// default gas limit, and the context of the block.
+func (n *Node) prepare(msg *gevm.Message, number uint64) (gevm.WorldState, *gevm.Message, *gevm.Block, error) {
+	n.mu.Lock()
+	if number >= uint64(len(n.blocks)) {
+		n.mu.Unlock()
+		return nil, nil, nil, fmt.Errorf("block %d not found", number)
+	}
+	b := n.blocks[number]
+	state := b.state.Copy()
+	n.mu.Unlock()
+
+	cpy := *msg
+	cpy.Nonce = 0
+	if acc, ok := state[msg.From]; ok {
+		cpy.Nonce = acc.Nonce
+	}
+	if cpy.GasLimit == 0 {
+		cpy.GasLimit = n.config.GasLimit
+	}
+	baseFee := n.config.BaseFee
+	if cpy.GasPrice == nil || cpy.GasPrice.IsZero() {
+		baseFee = 0
+	}
+	blockCtx := gevm.NewBlock(n.config.Coinbase, 0, b.Number(), 0, baseFee, time.Unix(int64(b.Header.Time), 0))
+	return state, &cpy, blockCtx, nil
+}
+
+func (n *Node) chainConfig() gevm.ChainConfig {
+	return gevm.ChainConfig{ChainID: n.config.ChainID, GasLimit: n.config.GasLimit, Fork: n.config.Fork}
+}
+
+// Transaction returns a mined transaction with its block, index and sender, or a nil block if it isn't known.
+func (n *Node) Transaction(hash common.Hash) (tx *types.Transaction, block *Block, index int, from common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	l, ok := n.txs[hash]
+	if !ok {
+		return nil, nil, 0, common.Address{}
+	}
+	return l.block.Transactions[l.index], l.block, l.index, l.block.Senders[l.index]
+}
+
+// Logs returns the logs matching a filter. Missing block bounds default to the latest block.
+func (n *Node) Logs(q ethereum.FilterQuery) ([]*types.Log, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var blocks []*Block
+	if q.BlockHash != nil {
+		b, ok := n.hashes[*q.BlockHash]
+		if !ok {
+			return nil, fmt.Errorf("block %s not found", q.BlockHash.Hex())
+		}
+		blocks = []*Block{b}
+	} else {
+		head := n.head().Number()
+		from, to := head, head
+		if q.FromBlock != nil {
+			from = q.FromBlock.Uint64()
+		}
+		if q.ToBlock != nil {
+			to = min(q.ToBlock.Uint64(), head)
+		}
+		if from <= to {
+			blocks = n.blocks[from : to+1]
+		}
+	}
+
+	logs := []*types.Log{}
+	for _, b := range blocks {
+		for _, r := range b.Receipts {
+			for _, log := range r.Logs {
+				if matchLog(log, q.Addresses, q.Topics) {
+					logs = append(logs, log)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// matchLog reports whether a log was emitted by one of the addresses, if any, and matches the topics.
+func matchLog(log *types.Log, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		found := false
+		for _, addr := range addresses {
+			found = found || addr == log.Address
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, alternatives := range topics {
+		if len(alternatives) == 0 {
+			continue // wildcard
+		}
+		found := false
+		for _, topic := range alternatives {
+			found = found || topic == log.Topics[i]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package node
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Contract storing its calldata word in slot 0, logging it with the topic 0xaa and returning it
+var (
+	runtimeCode = common.FromHex("5f35805f555f5260aa60205fa160205ff3")
+	initCode    = append(common.FromHex("6011600a5f3960115ff3"), runtimeCode...)
+)
+
+var testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+func newTestNode(t *testing.T) (*Node, common.Address) {
+	t.Helper()
+	from := crypto.PubkeyToAddress(testKey.PublicKey)
+	alloc := gevm.NewWorldState()
+	alloc.GetOrNewAccount(from).Balance = uint256.NewInt(1e18)
+	n := New(Config{ChainID: 1337, GasLimit: 30_000_000, BaseFee: 7, Fork: gevm.Cancun, Alloc: alloc})
+	n.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	return n, from
+}
+
+func signTx(t *testing.T, key *ecdsa.PrivateKey, tx types.TxData) *types.Transaction {
+	t.Helper()
+	signed, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1337)), tx)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestSendTransaction(t *testing.T) {
+	n, from := newTestNode(t)
+	assert.Equal(t, uint64(0), n.BlockNumber())
+
+	deploy := signTx(t, testKey, &types.DynamicFeeTx{Nonce: 0, Gas: 100_000, GasFeeCap: big.NewInt(10), GasTipCap: big.NewInt(1), Data: initCode})
+	receipt, err := n.SendTransaction(deploy)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, uint64(1), n.BlockNumber())
+	contract := crypto.CreateAddress(from, 0)
+	assert.Equal(t, contract, receipt.ContractAddress)
+	assert.Equal(t, big.NewInt(8), receipt.EffectiveGasPrice) // base fee 7 + tip 1
+
+	acc, err := n.Account(contract, 1)
+	require.NoError(t, err)
+	assert.Equal(t, runtimeCode, acc.Code)
+	acc, err = n.Account(contract, 0)
+	require.NoError(t, err)
+	assert.Nil(t, acc, "the contract doesn't exist before it's deployed")
+
+	word := common.LeftPadBytes([]byte{42}, 32)
+	call := signTx(t, testKey, &types.LegacyTx{Nonce: 1, To: &contract, Gas: 100_000, GasPrice: big.NewInt(7), Data: word})
+	receipt, err = n.SendTransaction(call)
+	require.NoError(t, err)
+	require.Len(t, receipt.Logs, 1)
+	log := receipt.Logs[0]
+	assert.Equal(t, contract, log.Address)
+	assert.Equal(t, call.Hash(), log.TxHash)
+	assert.Equal(t, uint64(2), log.BlockNumber)
+	assert.Equal(t, receipt.BlockHash, log.BlockHash)
+	assert.Equal(t, word, log.Data)
+
+	block := n.BlockByNumber(2)
+	require.NotNil(t, block)
+	assert.Equal(t, n.BlockByNumber(1).Hash(), block.Header.ParentHash)
+	assert.Equal(t, block, n.BlockByHash(receipt.BlockHash))
+	assert.Equal(t, receipt.GasUsed, block.Header.GasUsed)
+	assert.Greater(t, block.Header.Time, n.BlockByNumber(1).Header.Time)
+
+	tx, b, index, sender := n.Transaction(call.Hash())
+	assert.Equal(t, call.Hash(), tx.Hash())
+	assert.Equal(t, block, b)
+	assert.Equal(t, 0, index)
+	assert.Equal(t, from, sender)
+
+	acc, _ = n.Account(contract, 2)
+	assert.Equal(t, common.BytesToHash(word), acc.Storage[common.Hash{}])
+
+	// Invalid transactions aren't mined
+	_, err = n.SendTransaction(call)
+	assert.ErrorContains(t, err, "already known")
+	stale := signTx(t, testKey, &types.LegacyTx{Nonce: 1, To: &contract, Gas: 100_000, GasPrice: big.NewInt(7)})
+	_, err = n.SendTransaction(stale)
+	assert.ErrorIs(t, err, gevm.ErrNonceTooLow)
+	cheap := signTx(t, testKey, &types.LegacyTx{Nonce: 2, To: &contract, Gas: 100_000, GasPrice: big.NewInt(6)})
+	_, err = n.SendTransaction(cheap)
+	assert.ErrorIs(t, err, gevm.ErrFeeCapTooLow)
+	assert.Equal(t, uint64(2), n.BlockNumber())
+
+	// Failed executions are mined with a failed receipt
+	failing := signTx(t, testKey, &types.LegacyTx{Nonce: 2, To: &contract, Gas: 21_500, GasPrice: big.NewInt(7)})
+	receipt, err = n.SendTransaction(failing)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	assert.Equal(t, uint64(3), n.BlockNumber())
+}
+
+func TestCall(t *testing.T) {
+	n, from := newTestNode(t)
+	_, err := n.SendTransaction(signTx(t, testKey, &types.LegacyTx{Gas: 100_000, GasPrice: big.NewInt(7), Data: initCode}))
+	require.NoError(t, err)
+	contract := crypto.CreateAddress(from, 0)
+
+	word := common.LeftPadBytes([]byte{42}, 32)
+	res, err := n.Call(&gevm.Message{From: from, To: &contract, Data: word}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, word, res.ReturnData)
+	acc, _ := n.Account(contract, 1)
+	assert.Empty(t, acc.Storage, "calls don't change the state")
+
+	res, err = n.Call(&gevm.Message{To: &contract}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, res.ReturnData, "no code at the genesis block")
+	_, err = n.Call(&gevm.Message{To: &contract}, 2)
+	assert.Error(t, err)
+}
+
+func TestLogs(t *testing.T) {
+	n, from := newTestNode(t)
+	_, err := n.SendTransaction(signTx(t, testKey, &types.LegacyTx{Gas: 100_000, GasPrice: big.NewInt(7), Data: initCode}))
+	require.NoError(t, err)
+	contract := crypto.CreateAddress(from, 0)
+	for i := uint64(1); i <= 3; i++ {
+		tx := signTx(t, testKey, &types.LegacyTx{Nonce: i, To: &contract, Gas: 100_000, GasPrice: big.NewInt(7), Data: common.LeftPadBytes([]byte{byte(i)}, 32)})
+		_, err := n.SendTransaction(tx)
+		require.NoError(t, err)
+	}
+
+	topic := common.HexToHash("0xaa")
+	tests := []struct {
+		name  string
+		query ethereum.FilterQuery
+		want  int
+	}{
+		{"latest only", ethereum.FilterQuery{}, 1},
+		{"range", ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(3)}, 2},
+		{"address", ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{contract}}, 3},
+		{"other address", ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{from}}, 0},
+		{"topic", ethereum.FilterQuery{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{topic}}}, 3},
+		{"wildcard", ethereum.FilterQuery{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{}}}, 3},
+		{"other topic", ethereum.FilterQuery{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{common.HexToHash("0xbb")}}}, 0},
+		{"too many topics", ethereum.FilterQuery{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{topic}, {}}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, err := n.Logs(tt.query)
+			require.NoError(t, err)
+			assert.Len(t, logs, tt.want)
+		})
+	}
+
+	hash := n.BlockByNumber(2).Hash()
+	logs, err := n.Logs(ethereum.FilterQuery{BlockHash: &hash})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint64(2), logs[0].BlockNumber)
+}
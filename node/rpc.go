@@ -0,0 +1,616 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/tracers"
+)
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+	codeReverted       = 3 // execution reverted, as geth reports it
+)
+
+// maxRequestSize bounds the size of a request body.
+const maxRequestSize = 5 << 20
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, args ...any) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// methods are the RPC methods, taking the positional parameters of a request.
+var methods = map[string]func(n *Node, params []json.RawMessage) (any, error){
+	"web3_clientVersion":        func(*Node, []json.RawMessage) (any, error) { return "gevm", nil },
+	"net_version":               func(n *Node, _ []json.RawMessage) (any, error) { return fmt.Sprint(n.ChainID()), nil },
+	"eth_chainId":               func(n *Node, _ []json.RawMessage) (any, error) { return hexutil.Uint64(n.ChainID()), nil },
+	"eth_blockNumber":           func(n *Node, _ []json.RawMessage) (any, error) { return hexutil.Uint64(n.BlockNumber()), nil },
+	"eth_gasPrice":              func(n *Node, _ []json.RawMessage) (any, error) { return hexutil.Uint64(n.BaseFee()), nil },
+	"eth_maxPriorityFeePerGas":  func(*Node, []json.RawMessage) (any, error) { return hexutil.Uint64(0), nil },
+	"eth_getBalance":            (*Node).rpcGetBalance,
+	"eth_getTransactionCount":   (*Node).rpcGetTransactionCount,
+	"eth_getCode":               (*Node).rpcGetCode,
+	"eth_getStorageAt":          (*Node).rpcGetStorageAt,
+	"eth_getBlockByNumber":      (*Node).rpcGetBlockByNumber,
+	"eth_call":                  (*Node).rpcCall,
+	"eth_estimateGas":           (*Node).rpcEstimateGas,
+	"eth_createAccessList":      (*Node).rpcCreateAccessList,
+	"eth_sendRawTransaction":    (*Node).rpcSendRawTransaction,
+	"eth_getTransactionReceipt": (*Node).rpcGetTransactionReceipt,
+	"eth_getLogs":               (*Node).rpcGetLogs,
+	"debug_traceCall":           (*Node).rpcTraceCall,
+	"debug_traceTransaction":    (*Node).rpcTraceTransaction,
+}
+
+// ServeHTTP implements http.Handler, answering JSON-RPC 2.0 requests and batches of requests sent with POST.
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Allow browser frontends served from another origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	switch r.Method {
+	case http.MethodOptions:
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			json.NewEncoder(w).Encode(errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()}))
+			return
+		}
+		responses := make([]rpcResponse, len(batch))
+		for i, req := range batch {
+			responses[i] = n.handle(req)
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+	json.NewEncoder(w).Encode(n.handle(body))
+}
+
+// handle answers a single request.
+func (n *Node) handle(data []byte) rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()})
+	}
+	method, ok := methods[req.Method]
+	if !ok {
+		return errorResponse(req.ID, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)})
+	}
+	var params []json.RawMessage
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, &rpcError{Code: codeInvalidRequest, Message: "params must be an array"})
+		}
+	}
+	result, err := method(n, params)
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: codeServerError, Message: err.Error()}
+		}
+		return errorResponse(req.ID, rerr)
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &rpcError{Code: codeServerError, Message: err.Error()})
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: encoded}
+}
+
+func errorResponse(id json.RawMessage, err *rpcError) rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// parseParams decodes positional parameters into dst, the parameters after the required ones being optional.
+func parseParams(params []json.RawMessage, required int, dst ...any) error {
+	if len(params) < required {
+		return invalidParams("missing value for required argument %d", len(params))
+	}
+	if len(params) > len(dst) {
+		return invalidParams("too many arguments, want at most %d", len(dst))
+	}
+	for i, p := range params {
+		if err := json.Unmarshal(p, dst[i]); err != nil {
+			return invalidParams("invalid argument %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// blockNumber resolves a block tag or number, an empty tag being the latest block.
+func (n *Node) blockNumber(tag string) (uint64, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return n.BlockNumber(), nil
+	case "earliest":
+		return 0, nil
+	}
+	number, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, invalidParams("invalid block number %q", tag)
+	}
+	if number > n.BlockNumber() {
+		return 0, errors.New("header not found")
+	}
+	return number, nil
+}
+
+// accountAt parses the address and block parameters shared by the account queries.
+func (n *Node) accountAt(params []json.RawMessage, extra ...any) (*gevm.Account, error) {
+	var (
+		addr common.Address
+		tag  string
+	)
+	dst := append([]any{&addr}, extra...)
+	if err := parseParams(params, len(dst), append(dst, &tag)...); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := n.Account(addr, number)
+	if acc == nil && err == nil {
+		acc = gevm.NewAccount()
+	}
+	return acc, err
+}
+
+func (n *Node) rpcGetBalance(params []json.RawMessage) (any, error) {
+	acc, err := n.accountAt(params)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(acc.Balance.ToBig()), nil
+}
+
+func (n *Node) rpcGetTransactionCount(params []json.RawMessage) (any, error) {
+	acc, err := n.accountAt(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(acc.Nonce), nil
+}
+
+func (n *Node) rpcGetCode(params []json.RawMessage) (any, error) {
+	acc, err := n.accountAt(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(acc.Code), nil
+}
+
+func (n *Node) rpcGetStorageAt(params []json.RawMessage) (any, error) {
+	var slot string
+	acc, err := n.accountAt(params, &slot)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hexutil.DecodeBig(normalizeQuantity(slot))
+	if err != nil || key.BitLen() > 256 {
+		return nil, invalidParams("invalid storage slot %q", slot)
+	}
+	return hexutil.Bytes(acc.Storage[common.BigToHash(key)].Bytes()), nil
+}
+
+// normalizeQuantity strips the leading zeros of a hex quantity, which storage slots are often padded with.
+func normalizeQuantity(s string) string {
+	if !strings.HasPrefix(s, "0x") {
+		return s
+	}
+	digits := strings.TrimLeft(s[2:], "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return "0x" + digits
+}
+
+func (n *Node) rpcGetBlockByNumber(params []json.RawMessage) (any, error) {
+	var (
+		tag  string
+		full bool
+	)
+	if err := parseParams(params, 1, &tag, &full); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	var rerr *rpcError
+	if errors.As(err, &rerr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, nil // unknown blocks are null
+	}
+	return marshalBlock(n.BlockByNumber(number), full)
+}
+
+// marshalBlock encodes a block the way eth_getBlockByNumber returns it.
+func marshalBlock(b *Block, full bool) (map[string]any, error) {
+	fields, err := marshalFields(b.Header)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]any, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		if full {
+			txs[i] = tx
+		} else {
+			txs[i] = tx.Hash()
+		}
+	}
+	fields["transactions"] = txs
+	fields["uncles"] = []common.Hash{}
+	fields["size"] = hexutil.Uint64(b.Header.Size())
+	return fields, nil
+}
+
+// marshalFields encodes v as JSON and returns its fields, to add more.
+func marshalFields(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	return fields, json.Unmarshal(data, &fields)
+}
+
+// callArgs are the arguments of eth_call.
+type callArgs struct {
+	From         *common.Address   `json:"from"`
+	To           *common.Address   `json:"to"`
+	Gas          *hexutil.Uint64   `json:"gas"`
+	GasPrice     *hexutil.Big      `json:"gasPrice"`
+	MaxFeePerGas *hexutil.Big      `json:"maxFeePerGas"`
+	Value        *hexutil.Big      `json:"value"`
+	Data         *hexutil.Bytes    `json:"data"`
+	Input        *hexutil.Bytes    `json:"input"`
+	AccessList   *types.AccessList `json:"accessList"`
+}
+
+// message converts the arguments into a message, missing fields being zero.
+func (args *callArgs) message() (*gevm.Message, error) {
+	msg := &gevm.Message{To: args.To}
+	if args.From != nil {
+		msg.From = *args.From
+	}
+	if args.Gas != nil {
+		msg.GasLimit = uint64(*args.Gas)
+	}
+	for _, arg := range []struct {
+		name string
+		v    *hexutil.Big
+		dst  **uint256.Int
+	}{{"gasPrice", args.GasPrice, &msg.GasPrice}, {"maxFeePerGas", args.MaxFeePerGas, &msg.GasPrice}, {"value", args.Value, &msg.Value}} {
+		if arg.v == nil || *arg.dst != nil {
+			continue
+		}
+		v, overflow := uint256.FromBig(arg.v.ToInt())
+		if overflow {
+			return nil, invalidParams("%s overflows 256 bits", arg.name)
+		}
+		*arg.dst = v
+	}
+	switch {
+	case args.Input != nil:
+		msg.Data = *args.Input
+	case args.Data != nil:
+		msg.Data = *args.Data
+	}
+	if args.AccessList != nil {
+		msg.AccessList = *args.AccessList
+	}
+	return msg, nil
+}
+
+func (n *Node) rpcCall(params []json.RawMessage) (any, error) {
+	var (
+		args callArgs
+		tag  string
+	)
+	if err := parseParams(params, 1, &args, &tag); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message()
+	if err != nil {
+		return nil, err
+	}
+	res, err := n.Call(msg, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := executionError(res); err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(res.ReturnData), nil
+}
+
+func (n *Node) rpcEstimateGas(params []json.RawMessage) (any, error) {
+	var (
+		args callArgs
+		tag  string
+	)
+	if err := parseParams(params, 1, &args, &tag); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message()
+	if err != nil {
+		return nil, err
+	}
+	gas, err := n.EstimateGas(msg, number)
+	var revert *gevm.RevertError
+	if errors.As(err, &revert) {
+		return nil, revertError(revert.Data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(gas), nil
+}
+
+func (n *Node) rpcCreateAccessList(params []json.RawMessage) (any, error) {
+	var (
+		args callArgs
+		tag  string
+	)
+	if err := parseParams(params, 1, &args, &tag); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message()
+	if err != nil {
+		return nil, err
+	}
+	res, err := n.CreateAccessList(msg, number)
+	if err != nil {
+		return nil, err
+	}
+	// gasUsedBefore isn't part of geth's result, it is the gas used with the access list of the arguments
+	result := struct {
+		AccessList    types.AccessList `json:"accessList"`
+		GasUsed       hexutil.Uint64   `json:"gasUsed"`
+		GasUsedBefore hexutil.Uint64   `json:"gasUsedBefore"`
+		Error         string           `json:"error,omitempty"`
+	}{AccessList: res.AccessList, GasUsed: hexutil.Uint64(res.GasUsed), GasUsedBefore: hexutil.Uint64(res.GasUsedBefore)}
+	if res.Err != nil {
+		result.Error = res.Err.Error()
+	}
+	return result, nil
+}
+
+// traceConfig selects the tracer of debug_traceCall and debug_traceTransaction.
+type traceConfig struct {
+	Tracer       string          `json:"tracer"`
+	TracerConfig json.RawMessage `json:"tracerConfig"`
+}
+
+// tracer creates the tracer of the configuration. There is no default, as geth's struct logger isn't supported.
+func (c *traceConfig) tracer() (tracers.Tracer, error) {
+	if c.Tracer == "" {
+		return nil, invalidParams("a tracer is required, such as callTracer")
+	}
+	tracer, err := tracers.New(c.Tracer, c.TracerConfig)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+	return tracer, nil
+}
+
+func (n *Node) rpcTraceCall(params []json.RawMessage) (any, error) {
+	var (
+		args   callArgs
+		tag    string
+		config traceConfig
+	)
+	if err := parseParams(params, 1, &args, &tag, &config); err != nil {
+		return nil, err
+	}
+	number, err := n.blockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message()
+	if err != nil {
+		return nil, err
+	}
+	tracer, err := config.tracer()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := n.TraceCall(msg, number, tracer); err != nil {
+		return nil, err
+	}
+	return tracer.GetResult()
+}
+
+func (n *Node) rpcTraceTransaction(params []json.RawMessage) (any, error) {
+	var (
+		hash   common.Hash
+		config traceConfig
+	)
+	if err := parseParams(params, 1, &hash, &config); err != nil {
+		return nil, err
+	}
+	tracer, err := config.tracer()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := n.TraceTransaction(hash, tracer); err != nil {
+		return nil, err
+	}
+	return tracer.GetResult()
+}
+
+// executionError converts the failure of an execution into an RPC error.
+func executionError(res *gevm.ExecutionResult) error {
+	switch {
+	case errors.Is(res.Err, gevm.ErrExecutionReverted):
+		return revertError(res.ReturnData)
+	case res.Failed():
+		return &rpcError{Code: codeServerError, Message: res.Err.Error()}
+	}
+	return nil
+}
+
+// revertError reports a revert the way geth does, with the reason in the message and the revert data.
+func revertError(data []byte) *rpcError {
+	err := gevm.NewRevertError(data)
+	err.Reason, _ = abi.DecodeRevert(data, nil)
+	return &rpcError{Code: codeReverted, Message: err.Error(), Data: hexutil.Bytes(data)}
+}
+
+func (n *Node) rpcSendRawTransaction(params []json.RawMessage) (any, error) {
+	var data hexutil.Bytes
+	if err := parseParams(params, 1, &data); err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, invalidParams("invalid transaction: %v", err)
+	}
+	if _, err := n.SendTransaction(tx); err != nil {
+		return nil, err
+	}
+	return tx.Hash(), nil
+}
+
+func (n *Node) rpcGetTransactionReceipt(params []json.RawMessage) (any, error) {
+	var hash common.Hash
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	tx, block, index, from := n.Transaction(hash)
+	if block == nil {
+		return nil, nil
+	}
+	fields, err := marshalFields(block.Receipts[index])
+	if err != nil {
+		return nil, err
+	}
+	fields["from"] = from
+	fields["to"] = tx.To()
+	return fields, nil
+}
+
+// filterArgs are the arguments of eth_getLogs.
+type filterArgs struct {
+	BlockHash *common.Hash      `json:"blockHash"`
+	FromBlock string            `json:"fromBlock"`
+	ToBlock   string            `json:"toBlock"`
+	Address   json.RawMessage   `json:"address"` // an address or a list of addresses
+	Topics    []json.RawMessage `json:"topics"`  // each null, a topic or a list of topics
+}
+
+func (n *Node) rpcGetLogs(params []json.RawMessage) (any, error) {
+	var args filterArgs
+	if err := parseParams(params, 1, &args); err != nil {
+		return nil, err
+	}
+	q := ethereum.FilterQuery{BlockHash: args.BlockHash}
+	if args.BlockHash == nil {
+		from, err := n.blockNumber(args.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		to, err := n.blockNumber(args.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+		q.FromBlock, q.ToBlock = new(big.Int).SetUint64(from), new(big.Int).SetUint64(to)
+	}
+	if err := unmarshalOneOrMany(args.Address, &q.Addresses); err != nil {
+		return nil, invalidParams("invalid address: %v", err)
+	}
+	q.Topics = make([][]common.Hash, len(args.Topics))
+	for i, t := range args.Topics {
+		if err := unmarshalOneOrMany(t, &q.Topics[i]); err != nil {
+			return nil, invalidParams("invalid topic %d: %v", i, err)
+		}
+	}
+	return n.Logs(q)
+}
+
+// unmarshalOneOrMany decodes null, a value or a list of values into a list.
+func unmarshalOneOrMany[T any](data json.RawMessage, dst *[]T) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if data[0] == '[' {
+		return json.Unmarshal(data, dst)
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*dst = []T{v}
+	return nil
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+	"github.com/Jesserc/gevm/tracers"
+)
+
+func TestRPC(t *testing.T) {
+	n, from := newTestNode(t)
+	server := httptest.NewServer(n)
+	defer server.Close()
+	client, err := ethclient.Dial(server.URL)
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1337), chainID)
+	balance, err := client.BalanceAt(ctx, from, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1e18), balance)
+
+	deploy := signTx(t, testKey, &types.LegacyTx{Gas: 100_000, GasPrice: big.NewInt(7), Data: initCode})
+	require.NoError(t, client.SendTransaction(ctx, deploy))
+	receipt, err := client.TransactionReceipt(ctx, deploy.Hash())
+	require.NoError(t, err)
+	contract := crypto.CreateAddress(from, 0)
+	assert.Equal(t, contract, receipt.ContractAddress)
+	assert.Equal(t, big.NewInt(1), receipt.BlockNumber)
+
+	number, err := client.BlockNumber(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), number)
+	header, err := client.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, receipt.BlockHash, header.Hash())
+	nonce, err := client.NonceAt(ctx, from, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+	code, err := client.CodeAt(ctx, contract, nil)
+	require.NoError(t, err)
+	assert.Equal(t, runtimeCode, code)
+	code, err = client.CodeAt(ctx, contract, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Empty(t, code)
+
+	word := common.LeftPadBytes([]byte{42}, 32)
+	out, err := client.CallContract(ctx, ethereum.CallMsg{From: from, To: &contract, Data: word}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, word, out)
+	gas, err := client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &contract, Data: word})
+	require.NoError(t, err)
+	res, err := n.Call(&gevm.Message{From: from, To: &contract, Data: word, GasLimit: gas - 1}, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Failed(), "the estimate must be the lowest gas limit succeeding")
+
+	// Listing the contract's slot costs more than its cold access
+	var accessList struct {
+		AccessList    types.AccessList `json:"accessList"`
+		GasUsed       hexutil.Uint64   `json:"gasUsed"`
+		GasUsedBefore hexutil.Uint64   `json:"gasUsedBefore"`
+	}
+	err = client.Client().Call(&accessList, "eth_createAccessList", map[string]any{
+		"from": from, "to": contract, "data": hexutil.Encode(word),
+		"accessList": types.AccessList{{Address: contract, StorageKeys: []common.Hash{{}}}},
+	}, "latest")
+	require.NoError(t, err)
+	assert.Empty(t, accessList.AccessList)
+	assert.Equal(t, 2400+1900-2100, int(accessList.GasUsedBefore-accessList.GasUsed))
+
+	call := signTx(t, testKey, &types.LegacyTx{Nonce: 1, To: &contract, Gas: 100_000, GasPrice: big.NewInt(7), Data: word})
+	require.NoError(t, client.SendTransaction(ctx, call))
+	slot, err := client.StorageAt(ctx, contract, common.Hash{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, word, slot)
+	logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{contract}})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, call.Hash(), logs[0].TxHash)
+	assert.Equal(t, word, logs[0].Data)
+
+	var frame tracers.CallFrame
+	err = client.Client().Call(&frame, "debug_traceTransaction", call.Hash(), map[string]any{"tracer": "callTracer", "tracerConfig": map[string]any{"withLog": true}})
+	require.NoError(t, err)
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, from, frame.From)
+	assert.Equal(t, &contract, frame.To)
+	assert.Equal(t, word, []byte(frame.Output))
+	receipt, err = client.TransactionReceipt(ctx, call.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, receipt.GasUsed, uint64(frame.GasUsed))
+	require.Len(t, frame.Logs, 1)
+	assert.Equal(t, word, []byte(frame.Logs[0].Data))
+
+	err = client.SendTransaction(ctx, call)
+	assert.ErrorContains(t, err, "already known")
+	receipt, err = client.TransactionReceipt(ctx, common.HexToHash("0x1"))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	assert.Nil(t, receipt)
+}
+
+func TestRPCRevert(t *testing.T) {
+	n, _ := newTestNode(t)
+	// Creation code reverting with Error("no")
+	revert := common.FromHex("08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000026e6f000000000000000000000000000000000000000000000000000000000000")
+	code := append([]byte{0x60, byte(len(revert)), 0x60, 0x0c, 0x5f, 0x39, 0x60, byte(len(revert)), 0x5f, 0xfd, 0x00, 0x00}, revert...)
+	server := httptest.NewServer(n)
+	defer server.Close()
+	c, err := rpc.Dial(server.URL)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var result string
+	err = c.Call(&result, "eth_call", map[string]any{"data": hexutil.Encode(code)}, "latest")
+	require.Error(t, err)
+	assert.Equal(t, `execution reverted: Error("no")`, err.Error())
+	var dataErr rpc.DataError
+	require.ErrorAs(t, err, &dataErr)
+	assert.Equal(t, hexutil.Encode(revert), dataErr.ErrorData())
+
+	var gas hexutil.Uint64
+	err = c.Call(&gas, "eth_estimateGas", map[string]any{"data": hexutil.Encode(code)})
+	assert.EqualError(t, err, `execution reverted: Error("no")`)
+	require.ErrorAs(t, err, &dataErr)
+	assert.Equal(t, hexutil.Encode(revert), dataErr.ErrorData())
+}
+
+func TestRPCRequests(t *testing.T) {
+	n, _ := newTestNode(t)
+	server := httptest.NewServer(n)
+	defer server.Close()
+
+	tests := []struct {
+		name, body, want string
+	}{
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"net_version"}]`,
+			`[{"jsonrpc":"2.0","id":1,"result":"0x539"},{"jsonrpc":"2.0","id":2,"result":"1337"}]`},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"eth_mining"}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_mining does not exist/is not available"}}`},
+		{"missing params", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":[]}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"missing value for required argument 0"}}`},
+		{"future block", `{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["0x0000000000000000000000000000000000000001","0x5"]}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`},
+		{"padded slot", `{"jsonrpc":"2.0","id":"a","method":"eth_getStorageAt","params":["0x0000000000000000000000000000000000000001","0x0000000000000000000000000000000000000000000000000000000000000000"]}`,
+			`{"jsonrpc":"2.0","id":"a","result":"0x0000000000000000000000000000000000000000000000000000000000000000"}`},
+		{"null receipt", `{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionReceipt","params":["0x0000000000000000000000000000000000000000000000000000000000000001"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":null}`},
+		{"no tracer", `{"jsonrpc":"2.0","id":1,"method":"debug_traceCall","params":[{},"latest"]}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"a tracer is required, such as callTracer"}}`},
+		{"call trace", `{"jsonrpc":"2.0","id":1,"method":"debug_traceCall","params":[{"to":"0x0000000000000000000000000000000000000001","gas":"0x5208"},"latest",{"tracer":"callTracer"}]}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"type":"CALL","from":"0x0000000000000000000000000000000000000000","gas":"0x5208","gasUsed":"0x5208","to":"0x0000000000000000000000000000000000000001","input":"0x","value":"0x0"}}`},
+		{"prestate diff", `{"jsonrpc":"2.0","id":1,"method":"debug_traceCall","params":[{"to":"0x0000000000000000000000000000000000000001"},"latest",{"tracer":"prestateTracer","tracerConfig":{"diffMode":true}}]}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"pre":{"0x0000000000000000000000000000000000000000":{"balance":"0x0"}},"post":{"0x0000000000000000000000000000000000000000":{"nonce":1}}}}`},
+		{"parse error", `{`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL, "application/json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+}
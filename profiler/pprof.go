@@ -0,0 +1,122 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// WritePprof writes the profile in the gzipped protobuf format of pprof, to open it with `go tool pprof` or any
+// viewer supporting the format. Each instruction is a sample whose stack is its call frame, then the instruction itself,
+// with the values gas and steps. Samples are labeled with their opcode, to break the profile down with -tags.
+//
+// The format is described in https://github.com/google/pprof/blob/main/proto/profile.proto.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var b protoBuffer
+	index := map[string]int{"": 0}
+	table := []string{""}
+	str := func(s string) uint64 {
+		i, ok := index[s]
+		if !ok {
+			i = len(table)
+			index[s] = i
+			table = append(table, s)
+		}
+		return uint64(i)
+	}
+
+	// Sample types: gas and steps
+	for _, t := range [][2]string{{"gas", "gas"}, {"steps", "count"}} {
+		b.message(1, func(m *protoBuffer) {
+			m.uint(1, str(t[0]))
+			m.uint(2, str(t[1]))
+		})
+	}
+
+	// A function and a location per frame and per instruction, ids starting at 1
+	id := uint64(0)
+	location := func(name string) uint64 {
+		id++
+		b.message(5, func(m *protoBuffer) { // Function
+			m.uint(1, id)
+			m.uint(2, str(name))
+		})
+		b.message(4, func(m *protoBuffer) { // Location
+			m.uint(1, id)
+			m.message(4, func(line *protoBuffer) { line.uint(1, id) })
+		})
+		return id
+	}
+	frames := make(map[string]uint64)
+	for _, s := range p.Sites() {
+		frame := s.Frame.Hex()
+		if _, ok := frames[frame]; !ok {
+			frames[frame] = location(frame)
+		}
+		leaf := location(fmt.Sprintf("%v 0x%04x", s.Op, s.PC))
+		b.message(2, func(m *protoBuffer) { // Sample
+			m.packed(1, leaf, frames[frame])
+			m.packed(2, s.Gas, s.Steps)
+			m.message(3, func(label *protoBuffer) {
+				label.uint(1, str("opcode"))
+				label.uint(2, str(s.Op.String()))
+			})
+		})
+	}
+
+	// Period: one unit of gas
+	b.message(11, func(m *protoBuffer) {
+		m.uint(1, str("gas"))
+		m.uint(2, str("gas"))
+	})
+	b.uint(12, 1)
+	b.uint(14, str("gas")) // default sample type
+
+	for _, s := range table {
+		b.bytes(6, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.buf); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer encodes protobuf messages, supporting the few wire types pprof uses.
+type protoBuffer struct {
+	buf []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.buf = append(b.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	b.buf = append(b.buf, byte(x))
+}
+
+func (b *protoBuffer) uint(field int, x uint64) {
+	b.varint(uint64(field) << 3) // wire type 0, varint
+	b.varint(x)
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2) // wire type 2, length-delimited
+	b.varint(uint64(len(data)))
+	b.buf = append(b.buf, data...)
+}
+
+func (b *protoBuffer) packed(field int, xs ...uint64) {
+	var m protoBuffer
+	for _, x := range xs {
+		m.varint(x)
+	}
+	b.bytes(field, m.buf)
+}
+
+func (b *protoBuffer) message(field int, encode func(m *protoBuffer)) {
+	var m protoBuffer
+	encode(&m)
+	b.bytes(field, m.buf)
+}
@@ -0,0 +1,65 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fields decodes the top-level fields of a protobuf message, which the profile only has as varints and byte strings
+func fields(t *testing.T, data []byte) map[int][][]byte {
+	out := make(map[int][][]byte)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		data = data[n:]
+		field, wire := int(key>>3), key&7
+		switch wire {
+		case 0:
+			_, n = binary.Uvarint(data)
+			out[field] = append(out[field], data[:n])
+			data = data[n:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			out[field] = append(out[field], data[n:n+int(size)])
+			data = data[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", wire)
+		}
+	}
+	return out
+}
+
+func TestWritePprof(t *testing.T) {
+	p := New()
+	evm := newEVM(countdown, 100_000)
+	evm.Tracer = p
+	evm.Execute()
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.WritePprof(&buf))
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+
+	profile := fields(t, data)
+	assert.Len(t, profile[1], 2)                // sample types
+	assert.Len(t, profile[2], len(p.Sites()))   // a sample per instruction
+	assert.Len(t, profile[4], len(p.Sites())+1) // a location per instruction and one for the frame
+	assert.Len(t, profile[5], len(profile[4]))  // a function per location
+	assert.Equal(t, []byte(""), profile[6][0])  // the string table starts with ""
+	assert.Contains(t, profile[6], []byte("SSTORE 0x0009"))
+	assert.Contains(t, profile[6], []byte(evm.Address.Hex()))
+
+	// The samples are sorted by gas, the first one being the SSTORE with its gas and steps
+	sample := fields(t, profile[2][0])
+	values := sample[2][0]
+	gas, n := binary.Uvarint(values)
+	steps, _ := binary.Uvarint(values[n:])
+	assert.Equal(t, p.Sites()[0].Gas, gas)
+	assert.Equal(t, uint64(3), steps)
+}
@@ -0,0 +1,202 @@
+// Package profiler measures where executions spend their gas, per PC, per opcode and per call frame.
+//
+// A Profiler is a tracer: set it on the EVMs to profile, possibly several runs of the same code, then print a summary
+// with WriteText or save it in the pprof format with WritePprof. The gas of an instruction is what it deducted,
+// so refunds aren't subtracted, and an instruction failing with an exceptional halt is charged all the gas left.
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Stat is the gas consumed by a group of instructions.
+type Stat struct {
+	Gas   uint64
+	Steps uint64 // instructions executed
+}
+
+func (s *Stat) add(gas uint64) {
+	s.Gas += gas
+	s.Steps++
+}
+
+// Site is an instruction of the code executed by a call frame.
+type Site struct {
+	Frame common.Address // address of the account whose code is executed
+	PC    uint64
+	Op    gevm.Opcode
+}
+
+// Profiler is a tracer accumulating the gas consumed by the executions it traces.
+type Profiler struct {
+	sites   map[Site]*Stat
+	opcodes map[gevm.Opcode]*Stat
+	frames  map[common.Address]*Stat
+	total   Stat
+
+	// Instruction being executed, charged once the next one is reached
+	current *Site
+	gas     uint64 // gas left before the current instruction
+}
+
+// New creates an empty profiler.
+func New() *Profiler {
+	return &Profiler{
+		sites:   make(map[Site]*Stat),
+		opcodes: make(map[gevm.Opcode]*Stat),
+		frames:  make(map[common.Address]*Stat),
+	}
+}
+
+// CaptureState implements gevm.Tracer.
+func (p *Profiler) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	p.charge(evm.Gas)
+	p.current = &Site{Frame: evm.Address, PC: pc, Op: op}
+	p.gas = evm.Gas
+}
+
+// CaptureEnd implements gevm.Tracer.
+func (p *Profiler) CaptureEnd(evm *gevm.EVM) {
+	p.charge(evm.Gas)
+	p.current = nil
+}
+
+// charge adds the gas consumed by the current instruction, given the gas left after it.
+func (p *Profiler) charge(gasLeft uint64) {
+	if p.current == nil {
+		return
+	}
+	gas := p.gas - gasLeft
+	site := *p.current
+	for _, stat := range []*Stat{stat(p.sites, site), stat(p.opcodes, site.Op), stat(p.frames, site.Frame), &p.total} {
+		stat.add(gas)
+	}
+}
+
+func stat[K comparable](m map[K]*Stat, key K) *Stat {
+	s, ok := m[key]
+	if !ok {
+		s = new(Stat)
+		m[key] = s
+	}
+	return s
+}
+
+// Total returns the gas consumed by all the instructions profiled.
+func (p *Profiler) Total() Stat {
+	return p.total
+}
+
+// SiteStat is the gas consumed by an instruction.
+type SiteStat struct {
+	Site
+	Stat
+}
+
+// Sites returns the gas consumed per instruction, most expensive first.
+func (p *Profiler) Sites() []SiteStat {
+	sites := make([]SiteStat, 0, len(p.sites))
+	for site, s := range p.sites {
+		sites = append(sites, SiteStat{site, *s})
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		a, b := sites[i], sites[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		if a.Frame != b.Frame {
+			return a.Frame.Cmp(b.Frame) < 0
+		}
+		return a.PC < b.PC
+	})
+	return sites
+}
+
+// OpcodeStat is the gas consumed by the instructions with an opcode.
+type OpcodeStat struct {
+	Op gevm.Opcode
+	Stat
+}
+
+// Opcodes returns the gas consumed per opcode, most expensive first.
+func (p *Profiler) Opcodes() []OpcodeStat {
+	ops := make([]OpcodeStat, 0, len(p.opcodes))
+	for op, s := range p.opcodes {
+		ops = append(ops, OpcodeStat{op, *s})
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Gas != ops[j].Gas {
+			return ops[i].Gas > ops[j].Gas
+		}
+		return ops[i].Op < ops[j].Op
+	})
+	return ops
+}
+
+// FrameStat is the gas consumed by a call frame.
+type FrameStat struct {
+	Frame common.Address
+	Stat
+}
+
+// Frames returns the gas consumed per call frame, most expensive first.
+func (p *Profiler) Frames() []FrameStat {
+	frames := make([]FrameStat, 0, len(p.frames))
+	for frame, s := range p.frames {
+		frames = append(frames, FrameStat{frame, *s})
+	}
+	sort.Slice(frames, func(i, j int) bool {
+		if frames[i].Gas != frames[j].Gas {
+			return frames[i].Gas > frames[j].Gas
+		}
+		return frames[i].Frame.Cmp(frames[j].Frame) < 0
+	})
+	return frames
+}
+
+// WriteText writes a summary of the profile: the n most expensive instructions, then the gas per opcode and per call frame.
+func (p *Profiler) WriteText(w io.Writer, n int) error {
+	pct := func(gas uint64) float64 {
+		if p.total.Gas == 0 {
+			return 0
+		}
+		return 100 * float64(gas) / float64(p.total.Gas)
+	}
+
+	ew := &errWriter{w: w}
+	ew.printf("Total: %d gas in %d steps\n\n", p.total.Gas, p.total.Steps)
+	ew.printf("%10s %7s %8s  %-6s  %-14s  %s\n", "gas", "gas%", "steps", "pc", "opcode", "frame")
+	for i, s := range p.Sites() {
+		if i == n {
+			break
+		}
+		ew.printf("%10d %6.2f%% %8d  0x%04x  %-14v  %s\n", s.Gas, pct(s.Gas), s.Steps, s.PC, s.Op, s.Frame.Hex())
+	}
+	ew.printf("\n%10s %7s %8s  %s\n", "gas", "gas%", "steps", "opcode")
+	for _, s := range p.Opcodes() {
+		ew.printf("%10d %6.2f%% %8d  %v\n", s.Gas, pct(s.Gas), s.Steps, s.Op)
+	}
+	ew.printf("\n%10s %7s %8s  %s\n", "gas", "gas%", "steps", "frame")
+	for _, s := range p.Frames() {
+		ew.printf("%10d %6.2f%% %8d  %s\n", s.Gas, pct(s.Gas), s.Steps, s.Frame.Hex())
+	}
+	return ew.err
+}
+
+// errWriter keeps the first error of a sequence of writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
@@ -0,0 +1,99 @@
+package profiler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func newEVM(code []byte, gas uint64) *gevm.EVM {
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+	evm := gevm.NewEVM(common.Address{}, gas, 0, 1, 30_000_000, code, nil, block)
+	evm.Verbose = false
+	return evm
+}
+
+// countdown stores 2, 1 and 0 in slot 0
+var countdown = gevm.NewProgram().Push(3).
+	Label("loop").Push(1).Op(gevm.SWAP1, gevm.SUB, gevm.DUP1).Push0().Op(gevm.SSTORE, gevm.DUP1).JumpI("loop").
+	Op(gevm.STOP).Bytes()
+
+func TestProfiler(t *testing.T) {
+	p := New()
+	evm := newEVM(countdown, 100_000)
+	evm.Tracer = p
+	result := evm.Execute()
+	assert.NoError(t, result.Err)
+
+	total := p.Total()
+	assert.Equal(t, result.UsedGas, total.Gas)
+	assert.Equal(t, uint64(32), total.Steps)
+
+	// The stores are the most expensive
+	sites := p.Sites()
+	assert.Equal(t, gevm.SSTORE, sites[0].Op)
+	assert.Equal(t, uint64(9), sites[0].PC)
+	assert.Equal(t, uint64(3), sites[0].Steps)
+
+	ops := p.Opcodes()
+	assert.Equal(t, OpcodeStat{gevm.SSTORE, sites[0].Stat}, ops[0])
+	steps := uint64(0)
+	for _, s := range ops {
+		steps += s.Steps
+		if s.Op == gevm.PUSH1 {
+			assert.Equal(t, Stat{Gas: 12, Steps: 4}, s.Stat)
+		}
+	}
+	assert.Equal(t, total.Steps, steps)
+	assert.Equal(t, []FrameStat{{evm.Address, total}}, p.Frames())
+}
+
+func TestProfilerAccumulatesRuns(t *testing.T) {
+	p := New()
+	for i := 0; i < 2; i++ {
+		evm := newEVM(countdown, 100_000)
+		evm.Tracer = p
+		evm.Execute()
+	}
+	assert.Equal(t, uint64(64), p.Total().Steps)
+}
+
+func TestProfilerChargesFailure(t *testing.T) {
+	// Out of gas on the first SSTORE, which is charged the gas left
+	p := New()
+	evm := newEVM(countdown, 1000)
+	evm.Tracer = p
+	result := evm.Execute()
+	assert.Error(t, result.Err)
+	assert.Equal(t, uint64(1000), p.Total().Gas)
+	assert.Equal(t, gevm.SSTORE, p.Sites()[0].Op)
+}
+
+func TestWriteText(t *testing.T) {
+	p := New()
+	evm := newEVM(gevm.NewProgram().Push(1).Push(2).Op(gevm.ADD, gevm.STOP).Bytes(), 100)
+	evm.Tracer = p
+	evm.Execute()
+
+	var sb strings.Builder
+	assert.NoError(t, p.WriteText(&sb, 2))
+	assert.Equal(t, `Total: 9 gas in 4 steps
+
+       gas    gas%    steps  pc      opcode          frame
+         3  33.33%        1  0x0000  PUSH1           0x0000000000000000000000000000000000000000
+         3  33.33%        1  0x0002  PUSH1           0x0000000000000000000000000000000000000000
+
+       gas    gas%    steps  opcode
+         6  66.67%        2  PUSH1
+         3  33.33%        1  ADD
+         0   0.00%        1  STOP
+
+       gas    gas%    steps  frame
+         9 100.00%        4  0x0000000000000000000000000000000000000000
+`, sb.String())
+}
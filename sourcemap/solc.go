@@ -0,0 +1,101 @@
+package sourcemap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Jesserc/gevm/artifacts"
+)
+
+// Contract is a compiled contract with the source map of its deployed bytecode.
+type Contract struct {
+	*artifacts.Contract
+	Map     *Map
+	Sources map[int]*Source // by file index, with their lines, including the sources generated by the compiler
+}
+
+// Load reads a solc standard JSON output and returns its contracts, sorted by full name.
+// Source files whose content isn't in the JSON are read relative to the directory of path, then to the working directory.
+func Load(path string) ([]*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStandardJSON(data, ReadSourceFrom(filepath.Dir(path)))
+}
+
+// ReadSourceFrom returns a function reading source files relative to dir, then to the working directory.
+func ReadSourceFrom(dir string) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			content, err = os.ReadFile(name)
+		}
+		return string(content), err
+	}
+}
+
+// ParseStandardJSON decodes a solc standard JSON output and returns its contracts, sorted by full name.
+// The output must have been compiled with evm.deployedBytecode selected. readSource, which may be nil,
+// provides the content of the source files that the JSON doesn't hold; files it fails to read have no lines.
+func ParseStandardJSON(data []byte, readSource func(name string) (string, error)) ([]*Contract, error) {
+	compiled, err := artifacts.ParseStandardJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var contracts []*Contract
+	for _, a := range compiled {
+		if len(a.DeployedBytecode.Code) == 0 {
+			continue // abstract contract or interface
+		}
+		c, err := NewContract(a, readSource)
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, nil
+}
+
+// NewContract maps the deployed bytecode of a compiled contract to its sources. readSource, which may be nil,
+// provides the content of the source files that the artifact doesn't hold.
+func NewContract(a *artifacts.Contract, readSource func(name string) (string, error)) (*Contract, error) {
+	entries, err := Parse(a.DeployedBytecode.SourceMap)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", a.FullName(), err)
+	}
+	c := &Contract{Contract: a, Sources: make(map[int]*Source)}
+	for id, s := range a.Sources {
+		content := s.Content
+		if content == "" && readSource != nil {
+			content, _ = readSource(s.Name)
+		}
+		c.Sources[id] = NewSource(id, s.Name, content)
+	}
+	for _, g := range a.DeployedBytecode.GeneratedSources {
+		c.Sources[g.ID] = NewSource(g.ID, g.Name, g.Content)
+	}
+	if c.Map, err = NewMap(a.DeployedBytecode.Code, entries, c.Sources); err != nil {
+		return nil, fmt.Errorf("%s: %v", a.FullName(), err)
+	}
+	return c, nil
+}
+
+// Find returns the contract with a name, as artifacts.Find does.
+func Find(contracts []*Contract, name string) (*Contract, error) {
+	compiled := make([]*artifacts.Contract, len(contracts))
+	for i, c := range contracts {
+		compiled[i] = c.Contract
+	}
+	found, err := artifacts.Find(compiled, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contracts {
+		if c.Contract == found {
+			return c, nil
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,89 @@
+package sourcemap
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/artifacts"
+)
+
+func TestLoad(t *testing.T) {
+	contracts, err := Load("testdata/Counter.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	c, err := Find(contracts, "Counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "Counter.sol:Counter", c.FullName())
+	assert.Equal(t, common.FromHex("600960015f54600d565b5f55005b019056"), c.DeployedBytecode.Code)
+
+	tests := []struct {
+		pc   uint64
+		want string
+		line string
+		jump JumpType
+	}{
+		{0, "Counter.sol:8:17", "        count = add(count, 1);", JumpRegular},
+		{8, "Counter.sol:8:17", "        count = add(count, 1);", JumpIn},
+		{12, "Counter.sol:7:5", "    function increment() external {", JumpRegular},
+		{14, "Counter.sol:12:16", "        return a + b;", JumpRegular},
+		{16, "Counter.sol:11:5", "    function add(uint256 a, uint256 b) internal pure returns (uint256) {", JumpOut},
+	}
+	for _, tt := range tests {
+		loc, ok := c.Map.Lookup(tt.pc)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, loc.String())
+		assert.Equal(t, tt.line, loc.Source.Line(loc.Line))
+		assert.Equal(t, tt.jump, loc.Jump)
+	}
+}
+
+func TestParseStandardJSON(t *testing.T) {
+	output := `{
+		"sources": {"A.sol": {"id": 0}},
+		"contracts": {"A.sol": {
+			"A": {"evm": {"deployedBytecode": {"object": "5f00", "sourceMap": "0:1:0;0:1:1",
+				"generatedSources": [{"id": 1, "name": "#utility.yul", "contents": "{}"}]}}},
+			"I": {"evm": {"deployedBytecode": {"object": ""}}}
+		}}
+	}`
+	contracts, err := ParseStandardJSON([]byte(output), nil)
+	assert.NoError(t, err)
+	assert.Len(t, contracts, 1) // the interface has no code
+
+	loc, ok := contracts[0].Map.Lookup(0)
+	assert.True(t, ok)
+	assert.Equal(t, "A.sol:@0", loc.String()) // no content
+	loc, ok = contracts[0].Map.Lookup(1)
+	assert.True(t, ok)
+	assert.Equal(t, "#utility.yul:1:1", loc.String())
+
+	_, err = ParseStandardJSON([]byte(`{"contracts": {"A.sol": {"A": {"evm": {"deployedBytecode": {"object": "73__$abc$__"}}}}}}`), nil)
+	assert.EqualError(t, err, "A.sol:A: bytecode has unlinked library references")
+}
+
+func TestFind(t *testing.T) {
+	contracts := []*Contract{
+		{Contract: &artifacts.Contract{Name: "A", File: "a.sol"}},
+		{Contract: &artifacts.Contract{Name: "A", File: "b.sol"}},
+		{Contract: &artifacts.Contract{Name: "B", File: "b.sol"}},
+	}
+
+	c, err := Find(contracts, "b.sol:A")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[1], c)
+	c, err = Find(contracts, "B")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[2], c)
+	_, err = Find(contracts, "A")
+	assert.EqualError(t, err, "several contracts match, choose one of a.sol:A, b.sol:A")
+	_, err = Find(contracts, "C")
+	assert.EqualError(t, err, "no contract C")
+	_, err = Find(contracts, "")
+	assert.Error(t, err)
+	c, err = Find(contracts[2:], "")
+	assert.NoError(t, err)
+	assert.Equal(t, contracts[2], c)
+}
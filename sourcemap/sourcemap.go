@@ -0,0 +1,181 @@
+// Package sourcemap maps the instructions of compiled Solidity code back to its source, using the source maps of solc.
+//
+// A source map has an entry per instruction, in the order the instructions appear in the code, as produced by
+// gevm.Disassemble. An entry is a range of a source file and the kind of jump the instruction is:
+// see https://docs.soliditylang.org/en/latest/internals/source_mappings.html.
+package sourcemap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// JumpType is how an instruction moves between functions.
+type JumpType byte
+
+const (
+	JumpRegular JumpType = '-' // not a function call or return
+	JumpIn      JumpType = 'i' // jumps into a function
+	JumpOut     JumpType = 'o' // returns from a function
+)
+
+// Entry is the source range an instruction was compiled from.
+type Entry struct {
+	Start         int // byte offset in the source file
+	Length        int
+	File          int // index of the source file, -1 if the instruction isn't tied to a source
+	Jump          JumpType
+	ModifierDepth int
+}
+
+// Parse decodes a source map. Fields left empty in an entry, and entries left empty, repeat the previous entry.
+func Parse(s string) ([]Entry, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var entries []Entry
+	prev := Entry{File: -1, Jump: JumpRegular}
+	for i, item := range strings.Split(s, ";") {
+		e := prev
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if field != "i" && field != "o" && field != "-" {
+					return nil, fmt.Errorf("entry %d: invalid jump type %q", i, field)
+				}
+				e.Jump = JumpType(field[0])
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: invalid field %q", i, field)
+			}
+			switch j {
+			case 0:
+				e.Start = n
+			case 1:
+				e.Length = n
+			case 2:
+				e.File = n
+			case 4:
+				e.ModifierDepth = n
+			default:
+				return nil, fmt.Errorf("entry %d: too many fields", i)
+			}
+		}
+		entries = append(entries, e)
+		prev = e
+	}
+	return entries, nil
+}
+
+// Source is a source file.
+type Source struct {
+	ID      int
+	Name    string
+	Content string // empty if the file couldn't be read
+	lines   []int  // offset of the start of each line
+}
+
+// NewSource creates a source file.
+func NewSource(id int, name, content string) *Source {
+	s := &Source{ID: id, Name: name, Content: content, lines: []int{0}}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			s.lines = append(s.lines, i+1)
+		}
+	}
+	return s
+}
+
+// Position returns the line and column, both starting at 1, of a byte offset. It returns 0, 0 if the content is unknown.
+func (s *Source) Position(offset int) (line, column int) {
+	if s.Content == "" || offset < 0 || offset > len(s.Content) {
+		return 0, 0
+	}
+	line = 1
+	for line < len(s.lines) && s.lines[line] <= offset {
+		line++
+	}
+	return line, offset - s.lines[line-1] + 1
+}
+
+// Line returns the text of a line, starting at 1, without its line ending.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lines) || s.Content == "" {
+		return ""
+	}
+	end := len(s.Content)
+	if n < len(s.lines) {
+		end = s.lines[n] - 1
+	}
+	return strings.TrimRight(s.Content[s.lines[n-1]:end], "\r")
+}
+
+// Lines returns the number of lines.
+func (s *Source) Lines() int {
+	return len(s.lines)
+}
+
+// Location is the source location of an instruction.
+type Location struct {
+	Source *Source
+	Start  int
+	Length int
+	Line   int // 0 if the source content is unknown
+	Column int
+	Jump   JumpType
+}
+
+// String formats the location as file:line:column, or file:offset if the content of the file is unknown.
+func (l Location) String() string {
+	if l.Line == 0 {
+		return fmt.Sprintf("%s:@%d", l.Source.Name, l.Start)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.Source.Name, l.Line, l.Column)
+}
+
+// Map maps the PCs of a bytecode to source locations.
+type Map struct {
+	entries map[uint64]Entry
+	sources map[int]*Source
+}
+
+// NewMap creates the map of code from its source map entries and source files, by file index.
+func NewMap(code []byte, entries []Entry, sources map[int]*Source) (*Map, error) {
+	instructions := gevm.Disassemble(code)
+	if len(entries) > len(instructions) {
+		return nil, fmt.Errorf("source map has %d entries, but the code has %d instructions", len(entries), len(instructions))
+	}
+	m := &Map{entries: make(map[uint64]Entry, len(entries)), sources: sources}
+	for i, e := range entries {
+		m.entries[instructions[i].PC] = e
+	}
+	return m, nil
+}
+
+// Entry returns the source map entry of the instruction at pc.
+func (m *Map) Entry(pc uint64) (Entry, bool) {
+	e, ok := m.entries[pc]
+	return e, ok
+}
+
+// Lookup returns the source location of the instruction at pc.
+// It reports false if pc isn't the start of an instruction or the instruction has no known source file.
+func (m *Map) Lookup(pc uint64) (Location, bool) {
+	e, ok := m.entries[pc]
+	if !ok {
+		return Location{}, false
+	}
+	src, ok := m.sources[e.File]
+	if !ok {
+		return Location{}, false
+	}
+	line, column := src.Position(e.Start)
+	return Location{Source: src, Start: e.Start, Length: e.Length, Line: line, Column: column, Jump: e.Jump}, true
+}
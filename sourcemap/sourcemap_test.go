@@ -0,0 +1,105 @@
+package sourcemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Entry
+		wantErr string
+	}{
+		{name: "empty", input: "", want: nil},
+		{
+			name:  "full entries",
+			input: "1:2:0:-:0;3:4:1:i:1",
+			want: []Entry{
+				{Start: 1, Length: 2, File: 0, Jump: JumpRegular},
+				{Start: 3, Length: 4, File: 1, Jump: JumpIn, ModifierDepth: 1},
+			},
+		},
+		{
+			name:  "compressed",
+			input: "1:2:0;:9;;::-1:o",
+			want: []Entry{
+				{Start: 1, Length: 2, File: 0, Jump: JumpRegular},
+				{Start: 1, Length: 9, File: 0, Jump: JumpRegular},
+				{Start: 1, Length: 9, File: 0, Jump: JumpRegular},
+				{Start: 1, Length: 9, File: -1, Jump: JumpOut},
+			},
+		},
+		{name: "invalid jump", input: "1:2:0:x", wantErr: `entry 0: invalid jump type "x"`},
+		{name: "invalid number", input: "1:2:0;a", wantErr: `entry 1: invalid field "a"`},
+		{name: "too many fields", input: "1:2:0:-:0:1", wantErr: "entry 0: too many fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSourcePosition(t *testing.T) {
+	src := NewSource(0, "a.sol", "ab\ncd\r\n\nef")
+	assert.Equal(t, 4, src.Lines())
+
+	tests := []struct {
+		offset, line, column int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{7, 3, 1},
+		{9, 4, 2},
+		{10, 4, 3},
+		{11, 0, 0},
+	}
+	for _, tt := range tests {
+		line, column := src.Position(tt.offset)
+		assert.Equal(t, tt.line, line, "line of offset %d", tt.offset)
+		assert.Equal(t, tt.column, column, "column of offset %d", tt.offset)
+	}
+
+	assert.Equal(t, "ab", src.Line(1))
+	assert.Equal(t, "cd", src.Line(2))
+	assert.Equal(t, "", src.Line(3))
+	assert.Equal(t, "ef", src.Line(4))
+	assert.Equal(t, "", src.Line(5))
+}
+
+func TestMapLookup(t *testing.T) {
+	// PUSH1 1, PUSH0, ADD: the PUSH1 takes 2 bytes, so the entries are for PCs 0, 2 and 3
+	code := gevm.NewProgram().Push(1).Push0().Op(gevm.ADD).Bytes()
+	entries, err := Parse("0:5:0;6:1;0:5:-1")
+	assert.NoError(t, err)
+	m, err := NewMap(code, entries, map[int]*Source{0: NewSource(0, "a.sol", "x = 1\ny")})
+	assert.NoError(t, err)
+
+	loc, ok := m.Lookup(2)
+	assert.True(t, ok)
+	assert.Equal(t, 2, loc.Line)
+	assert.Equal(t, "a.sol:2:1", loc.String())
+
+	_, ok = m.Lookup(1) // inside the PUSH1 operand
+	assert.False(t, ok)
+	_, ok = m.Lookup(3) // no source file
+	assert.False(t, ok)
+	e, ok := m.Entry(3)
+	assert.True(t, ok)
+	assert.Equal(t, -1, e.File)
+
+	_, err = NewMap(code[:1], entries, nil)
+	assert.EqualError(t, err, "source map has 3 entries, but the code has 1 instructions")
+}
@@ -0,0 +1,32 @@
+package statetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// Check runs the fixtures at path (a file or a directory) as subtests of t,
+// failing each subtest whose post state or logs differ from the expected ones.
+func Check(t *testing.T, path string) {
+	t.Helper()
+
+	results, err := RunPath(path, DefaultFork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		t.Run(fmt.Sprintf("%s/%s/%d", result.Name, result.Fork, result.Index), func(t *testing.T) {
+			switch {
+			case result.Skipped:
+				t.Skip(result.Err)
+			case !result.Pass:
+				t.Error(result.Err)
+				if result.State != nil {
+					state, _ := json.MarshalIndent(result.State, "", "  ")
+					t.Logf("post state computed by gevm:\n%s", state)
+				}
+			}
+		})
+	}
+}
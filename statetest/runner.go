@@ -0,0 +1,251 @@
+package statetest
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Result is the outcome of running a single subtest.
+type Result struct {
+	File    string
+	Name    string
+	Fork    string
+	Index   int
+	Pass    bool
+	Skipped bool
+	Err     error // why the subtest failed or was skipped
+
+	Root    common.Hash // post state root computed by gevm
+	LogHash common.Hash // hash of the logs emitted during execution
+	UsedGas uint64
+
+	// State is the post state computed by gevm, set when its root differs from the expected one
+	// and the fixture has no expected state to report the first difference against.
+	State types.GenesisAlloc
+}
+
+// String returns a one-line summary of the result.
+func (r *Result) String() string {
+	status := "PASS"
+	switch {
+	case r.Skipped:
+		status = "SKIP"
+	case !r.Pass:
+		status = "FAIL"
+	}
+	s := fmt.Sprintf("%s %s/%s/%d", status, r.Name, r.Fork, r.Index)
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// Run executes a subtest and checks the post state and logs against the expected ones.
+func (t *StateTest) Run(subtest Subtest) *Result {
+	result := &Result{Fork: subtest.Fork, Index: subtest.Index}
+	post, ok := t.Post[subtest.Fork]
+	if !ok || subtest.Index >= len(post) {
+		result.Skipped, result.Err = true, fmt.Errorf("no post state for %s/%d", subtest.Fork, subtest.Index)
+		return result
+	}
+	ps := post[subtest.Index]
+	fork, err := gevm.ParseFork(subtest.Fork)
+	if err != nil {
+		result.Skipped, result.Err = true, err
+		return result
+	}
+	if len(t.Tx.BlobVersionedHashes) > 0 {
+		result.Skipped, result.Err = true, fmt.Errorf("blob transactions are not supported")
+		return result
+	}
+
+	block, chainConfig := t.block()
+	chainConfig.Fork = fork
+	msg, err := t.message(ps, block.BaseFee)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	ws := worldState(t.Pre)
+	res, err := gevm.ApplyMessage(ws, msg, block, chainConfig, nil)
+	switch {
+	case err != nil && ps.ExpectException == "":
+		result.Err = fmt.Errorf("unexpected error: %w", err)
+		return result
+	case err == nil && ps.ExpectException != "":
+		result.Err = fmt.Errorf("expected error %q, got no error", ps.ExpectException)
+		return result
+	case err != nil:
+		// The transaction was rejected as expected, the post state must be the pre state
+	default:
+		result.UsedGas = res.UsedGas
+		result.LogHash = rlpHash(res.Logs)
+	}
+	if err == nil && result.LogHash != common.Hash(ps.Logs) {
+		result.Err = fmt.Errorf("logs hash mismatch: got %x, want %x", result.LogHash, ps.Logs)
+		return result
+	}
+
+	if result.Root, err = stateRoot(ws); err != nil {
+		result.Err = err
+		return result
+	}
+	if result.Root != common.Hash(ps.Root) {
+		if ps.State == nil {
+			result.State = genesisAlloc(ws)
+		} else if diff := diffState(ws, worldState(ps.State)); diff != "" {
+			result.Err = fmt.Errorf("post state mismatch: %s", diff)
+			return result
+		}
+		result.Err = fmt.Errorf("post state root mismatch: got %x, want %x", result.Root, ps.Root)
+		return result
+	}
+	result.Pass = true
+	return result
+}
+
+// RunFile runs every subtest of the given fork in a fixture file.
+func RunFile(path, fork string) ([]*Result, error) {
+	tests, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []*Result
+	for _, name := range names {
+		for _, subtest := range tests[name].Subtests() {
+			if subtest.Fork != fork {
+				continue
+			}
+			result := tests[name].Run(subtest)
+			result.File, result.Name = path, name
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// RunPath runs a fixture file, or every fixture file found under a directory.
+func RunPath(path, fork string) ([]*Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return RunFile(path, fork)
+	}
+
+	var results []*Result
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		res, err := RunFile(file, fork)
+		if err != nil {
+			return err
+		}
+		results = append(results, res...)
+		return nil
+	})
+	return results, err
+}
+
+// stateRoot computes the Merkle-Patricia root of the world state.
+func stateRoot(ws gevm.WorldState) (common.Hash, error) {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for addr, acc := range ws {
+		statedb.SetBalance(addr, acc.Balance, tracing.BalanceChangeUnspecified)
+		statedb.SetNonce(addr, acc.Nonce)
+		statedb.SetCode(addr, acc.Code)
+		for k, v := range acc.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	return statedb.IntermediateRoot(true), nil
+}
+
+// diffState returns a description of the first difference between two world states, or "" if they are equal.
+// Accounts and slots are compared in sorted order so that the reported difference is deterministic.
+func diffState(got, want gevm.WorldState) string {
+	addrs := make(map[common.Address]struct{})
+	for addr := range got {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range want {
+		addrs[addr] = struct{}{}
+	}
+	sorted := make([]common.Address, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+
+	for _, addr := range sorted {
+		g, w := got[addr], want[addr]
+		switch {
+		case g == nil:
+			return fmt.Sprintf("account %v: missing", addr)
+		case w == nil:
+			return fmt.Sprintf("account %v: unexpected account", addr)
+		case !g.Balance.Eq(w.Balance):
+			return fmt.Sprintf("account %v: balance got %v, want %v", addr, g.Balance, w.Balance)
+		case g.Nonce != w.Nonce:
+			return fmt.Sprintf("account %v: nonce got %d, want %d", addr, g.Nonce, w.Nonce)
+		case !bytes.Equal(g.Code, w.Code):
+			return fmt.Sprintf("account %v: code got %x, want %x", addr, g.Code, w.Code)
+		}
+
+		slots := make(map[common.Hash]struct{})
+		for k := range g.Storage {
+			slots[k] = struct{}{}
+		}
+		for k := range w.Storage {
+			slots[k] = struct{}{}
+		}
+		keys := make([]common.Hash, 0, len(slots))
+		for k := range slots {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+		for _, k := range keys {
+			if g.Storage[k] != w.Storage[k] {
+				return fmt.Sprintf("account %v: slot %v got %v, want %v", addr, k, g.Storage[k], w.Storage[k])
+			}
+		}
+	}
+	return ""
+}
+
+func rlpHash(x interface{}) common.Hash {
+	data, err := rlp.EncodeToBytes(x)
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(data)
+}
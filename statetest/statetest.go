@@ -0,0 +1,237 @@
+// Package statetest runs the Ethereum GeneralStateTests conformance suite against gevm.
+//
+// The fixtures are the JSON files published in https://github.com/ethereum/tests (GeneralStateTests)
+// and by https://github.com/ethereum/execution-spec-tests (state_tests).
+package statetest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// DefaultFork is the fork whose post states are checked when none is given.
+// Post states of forks gevm doesn't support (see gevm.Forks) are skipped.
+const DefaultFork = "Cancun"
+
+// StateTest is a single state test, as found under one key of a fixture file.
+type StateTest struct {
+	Env  stEnv                    `json:"env"`
+	Pre  types.GenesisAlloc       `json:"pre"`
+	Tx   stTransaction            `json:"transaction"`
+	Post map[string][]stPostState `json:"post"`
+}
+
+type stEnv struct {
+	Coinbase  common.Address        `json:"currentCoinbase"`
+	GasLimit  math.HexOrDecimal64   `json:"currentGasLimit"`
+	Number    math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp math.HexOrDecimal64   `json:"currentTimestamp"`
+	BaseFee   *math.HexOrDecimal256 `json:"currentBaseFee"`
+}
+
+type stTransaction struct {
+	GasPrice             *math.HexOrDecimal256 `json:"gasPrice"`
+	MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas"`
+	Nonce                math.HexOrDecimal64   `json:"nonce"`
+	To                   string                `json:"to"`
+	Data                 []string              `json:"data"`
+	AccessLists          []*types.AccessList   `json:"accessLists,omitempty"`
+	GasLimit             []math.HexOrDecimal64 `json:"gasLimit"`
+	Value                []string              `json:"value"`
+	PrivateKey           hexutil.Bytes         `json:"secretKey"`
+	Sender               *common.Address       `json:"sender"`
+	BlobVersionedHashes  []common.Hash         `json:"blobVersionedHashes,omitempty"`
+}
+
+type stPostState struct {
+	Root            common.UnprefixedHash `json:"hash"`
+	Logs            common.UnprefixedHash `json:"logs"`
+	ExpectException string                `json:"expectException"`
+	Indexes         struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+	// State is the expected post state. Only fixtures filled by execution-spec-tests carry it,
+	// without it a mismatch can only be reported as a differing state root.
+	State types.GenesisAlloc `json:"state"`
+}
+
+// Subtest identifies one post state of a StateTest: a fork and an index into its post states.
+type Subtest struct {
+	Fork  string
+	Index int
+}
+
+// LoadFile reads a fixture file and returns its tests by name.
+func LoadFile(path string) (map[string]*StateTest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tests map[string]*StateTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tests, nil
+}
+
+// Subtests returns all the subtests of the test, sorted by fork and index.
+func (t *StateTest) Subtests() []Subtest {
+	var sub []Subtest
+	for fork, pss := range t.Post {
+		for i := range pss {
+			sub = append(sub, Subtest{fork, i})
+		}
+	}
+	sort.Slice(sub, func(i, j int) bool {
+		if sub[i].Fork != sub[j].Fork {
+			return sub[i].Fork < sub[j].Fork
+		}
+		return sub[i].Index < sub[j].Index
+	})
+	return sub
+}
+
+// block builds the block the transaction is executed in.
+func (t *StateTest) block() (*gevm.Block, gevm.ChainConfig) {
+	var baseFee uint64
+	if t.Env.BaseFee != nil {
+		baseFee = (*big.Int)(t.Env.BaseFee).Uint64()
+	}
+	block := gevm.NewBlock(t.Env.Coinbase, 0, uint64(t.Env.Number), 0, baseFee, time.Unix(int64(t.Env.Timestamp), 0))
+	return block, gevm.ChainConfig{ChainID: 1, GasLimit: uint64(t.Env.GasLimit)}
+}
+
+// message builds the message selected by the indexes of a post state.
+func (t *StateTest) message(ps stPostState, baseFee uint64) (*gevm.Message, error) {
+	tx := t.Tx
+
+	var from common.Address
+	if tx.Sender != nil {
+		from = *tx.Sender
+	} else if len(tx.PrivateKey) > 0 {
+		key, err := crypto.ToECDSA(tx.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		from = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	var to *common.Address
+	if tx.To != "" {
+		to = new(common.Address)
+		if err := to.UnmarshalText([]byte(tx.To)); err != nil {
+			return nil, fmt.Errorf("invalid to address: %v", err)
+		}
+	}
+
+	if ps.Indexes.Data >= len(tx.Data) {
+		return nil, fmt.Errorf("tx data index %d out of bounds", ps.Indexes.Data)
+	}
+	if ps.Indexes.Value >= len(tx.Value) {
+		return nil, fmt.Errorf("tx value index %d out of bounds", ps.Indexes.Value)
+	}
+	if ps.Indexes.Gas >= len(tx.GasLimit) {
+		return nil, fmt.Errorf("tx gas limit index %d out of bounds", ps.Indexes.Gas)
+	}
+
+	value := new(uint256.Int)
+	if valueHex := tx.Value[ps.Indexes.Value]; valueHex != "0x" {
+		v, ok := math.ParseBig256(valueHex)
+		if !ok {
+			return nil, fmt.Errorf("invalid tx value %q", valueHex)
+		}
+		value.SetFromBig(v)
+	}
+	// Older fixtures prefix raw data with ":raw"
+	dataHex := strings.TrimPrefix(tx.Data[ps.Indexes.Data], ":raw ")
+	data, err := hex.DecodeString(strings.TrimPrefix(dataHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx data %q", dataHex)
+	}
+	var accessList types.AccessList
+	if tx.AccessLists != nil {
+		if ps.Indexes.Data >= len(tx.AccessLists) {
+			return nil, fmt.Errorf("tx access list index %d out of bounds", ps.Indexes.Data)
+		}
+		if tx.AccessLists[ps.Indexes.Data] != nil {
+			accessList = *tx.AccessLists[ps.Indexes.Data]
+		}
+	}
+
+	// The effective gas price of a dynamic fee transaction is min(maxFeePerGas, baseFee + maxPriorityFeePerGas)
+	var gasPrice *big.Int
+	switch {
+	case tx.GasPrice != nil:
+		gasPrice = (*big.Int)(tx.GasPrice)
+	case tx.MaxFeePerGas != nil:
+		gasPrice = (*big.Int)(tx.MaxFeePerGas)
+		if tx.MaxPriorityFeePerGas != nil {
+			gasPrice = math.BigMin(gasPrice, new(big.Int).Add((*big.Int)(tx.MaxPriorityFeePerGas), new(big.Int).SetUint64(baseFee)))
+		}
+	default:
+		return nil, errors.New("no gas price provided")
+	}
+
+	return &gevm.Message{
+		From:       from,
+		To:         to,
+		Nonce:      uint64(tx.Nonce),
+		Value:      value,
+		GasLimit:   uint64(tx.GasLimit[ps.Indexes.Gas]),
+		GasPrice:   uint256.MustFromBig(gasPrice),
+		Data:       data,
+		AccessList: accessList,
+	}, nil
+}
+
+// worldState converts a genesis allocation into a gevm world state.
+func worldState(alloc types.GenesisAlloc) gevm.WorldState {
+	state := gevm.NewWorldState()
+	for addr, account := range alloc {
+		acc := state.GetOrNewAccount(addr)
+		if account.Balance != nil {
+			acc.Balance.SetFromBig(account.Balance)
+		}
+		acc.Nonce = account.Nonce
+		acc.Code = account.Code
+		for k, v := range account.Storage {
+			if v != (common.Hash{}) {
+				acc.Storage[k] = v
+			}
+		}
+	}
+	return state
+}
+
+// genesisAlloc converts a gevm world state back into a genesis allocation, the format of the fixtures.
+func genesisAlloc(state gevm.WorldState) types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc, len(state))
+	for addr, acc := range state {
+		alloc[addr] = types.Account{
+			Balance: acc.Balance.ToBig(),
+			Nonce:   acc.Nonce,
+			Code:    acc.Code,
+			Storage: maps.Clone(acc.Storage),
+		}
+	}
+	return alloc
+}
@@ -0,0 +1,130 @@
+package statetest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	Check(t, "testdata")
+}
+
+// TestFixturesAgainstGeth runs the fixtures with go-ethereum's state test runner,
+// so that an expected root or logs hash can't be wrong in the same way as gevm.
+func TestFixturesAgainstGeth(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.json")
+	require.NoError(t, err)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var fixtures map[string]*tests.StateTest
+		require.NoError(t, json.Unmarshal(data, &fixtures), file)
+		for name, test := range fixtures {
+			for _, subtest := range test.Subtests() {
+				if subtest.Fork != DefaultFork {
+					continue
+				}
+				err := test.Run(subtest, vm.Config{}, false, rawdb.HashScheme, func(error, *tests.StateTestState) {})
+				assert.NoError(t, err, "%s/%s/%d", name, subtest.Fork, subtest.Index)
+			}
+		}
+	}
+}
+
+func TestRunReportsFirstDifference(t *testing.T) {
+	tests, err := LoadFile("testdata/sstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := tests["sstoreCalldata"]
+
+	// Expect slot 0 to hold 3 instead of 2
+	contract := common.HexToAddress("0x1000")
+	post := test.Post[DefaultFork][1]
+	post.Root = common.UnprefixedHash{}
+	post.State = types.GenesisAlloc{}
+	for addr, acc := range test.Pre {
+		post.State[addr] = acc
+	}
+	post.State[contract] = types.Account{
+		Balance: test.Pre[contract].Balance,
+		Code:    test.Pre[contract].Code,
+		Nonce:   test.Pre[contract].Nonce,
+		Storage: map[common.Hash]common.Hash{{}: common.HexToHash("0x03")},
+	}
+	test.Post[DefaultFork][1] = post
+
+	result := test.Run(Subtest{DefaultFork, 1})
+	assert.False(t, result.Pass)
+	assert.Contains(t, result.Err.Error(), "account 0x0000000000000000000000000000000000001000")
+}
+
+func TestRunReportsPostState(t *testing.T) {
+	tests, err := LoadFile("testdata/sstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := tests["sstoreCalldata"]
+
+	// Official fixtures only have the root, the executed post state is returned instead of a difference
+	post := test.Post[DefaultFork][1]
+	post.Root = common.UnprefixedHash{}
+	test.Post[DefaultFork][1] = post
+
+	result := test.Run(Subtest{DefaultFork, 1})
+	assert.False(t, result.Pass)
+	assert.Contains(t, result.Err.Error(), "post state root mismatch")
+	contract := common.HexToAddress("0x1000")
+	if assert.Contains(t, result.State, contract) {
+		assert.Equal(t, common.HexToHash("0x02"), result.State[contract].Storage[common.Hash{}])
+	}
+}
+
+func TestRunExpectedException(t *testing.T) {
+	tests, err := LoadFile("testdata/sstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := tests["sstoreCalldata"]
+	test.Tx.Nonce = 1
+
+	post := test.Post[DefaultFork][0]
+	post.ExpectException = "TransactionException.NONCE_MISMATCH_TOO_HIGH"
+	post.Root = common.UnprefixedHash(mustStateRoot(t, test.Pre))
+	test.Post[DefaultFork][0] = post
+
+	result := test.Run(Subtest{DefaultFork, 0})
+	assert.True(t, result.Pass, result.Err)
+}
+
+func TestRunAccessListOutOfBounds(t *testing.T) {
+	tests, err := LoadFile("testdata/sstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := tests["sstoreCalldata"]
+	// One access list for two data entries
+	test.Tx.AccessLists = []*types.AccessList{{}}
+
+	result := test.Run(Subtest{DefaultFork, 1})
+	assert.False(t, result.Pass)
+	assert.EqualError(t, result.Err, "tx access list index 1 out of bounds")
+}
+
+func mustStateRoot(t *testing.T, alloc types.GenesisAlloc) common.Hash {
+	root, err := stateRoot(worldState(alloc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.13;
+
+import {Test} from "forge-std/Test.sol";
+
+// The source of CounterTest.json. Its bytecode is assembled by hand to behave as this contract compiled by solc
+// does, as CounterTest.json must stay runnable without a compiler. `forge build` regenerates it as
+// out/CounterTest.t.sol/CounterTest.json.
+contract CounterTest is Test {
+    uint256 count;
+
+    function setUp() public {
+        count = 1;
+    }
+
+    function testIncrement() public {
+        count++;
+        assertEq(count, 2);
+    }
+
+    function testFailUnderflow() public {
+        count -= 2;
+    }
+
+    function testCreate() public {
+        new Counter();
+    }
+
+    function testExternalCall() public {
+        address(0xbeef).call("");
+    }
+
+    function testFailExternalCall() public {
+        address(0xbeef).call("");
+    }
+}
+
+contract Counter {}
@@ -0,0 +1,211 @@
+// Package testrunner runs the test functions of Solidity test contracts written with forge-std's conventions: the test
+// contract is deployed, setUp() is called if it exists, and then every test function runs on its own copy of the
+// resulting state, with the cheatcodes and console.log available.
+//
+// A test passes if it doesn't revert, once the expectations set with cheatcodes are accounted for, and if it didn't
+// record a failure the way DSTest's fail() does. Functions named testFail* pass if they fail instead.
+// Changes to the block made by setUp, with warp or roll, are not kept for the tests.
+//
+// It isn't a replacement for forge test: gevm runs a single call frame, so it can't create contracts nor call ones
+// other than the cheatcodes and console.log. Tests doing so are skipped as unsupported rather than failed, and so is
+// the whole contract when its constructor or setUp does, as for most tests of a separately deployed contract.
+package testrunner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/cheatcodes"
+	"github.com/Jesserc/gevm/console"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Defaults of forge test.
+var (
+	Sender = common.HexToAddress("0x1804c8AB1F12E6bbf3894d4083f33e07309d1f38") // deploys the test contracts and calls the tests
+)
+
+const (
+	ChainID  = 31337
+	GasLimit = 1 << 30 // gas available to each call
+)
+
+// failedSlot is the storage slot of the cheatcode address where DSTest's fail() records a failure.
+var failedSlot = common.BytesToHash(common.RightPadBytes([]byte("failed"), 32))
+
+// Tests returns the test functions of a contract, sorted by name: the functions without arguments whose name starts
+// with "test".
+func Tests(c *artifacts.Contract) []gethabi.Method {
+	if c.ABI == nil {
+		return nil
+	}
+	var tests []gethabi.Method
+	for _, m := range c.ABI.Methods {
+		if strings.HasPrefix(m.Name, "test") && len(m.Inputs) == 0 {
+			tests = append(tests, m)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	return tests
+}
+
+// Result is the result of a test function, or of setUp or the constructor if it failed or was skipped.
+type Result struct {
+	Test    string // signature of the function, e.g. "testIncrement()"
+	Passed  bool
+	Skipped bool   // the test created a contract or called an external one, which gevm can't run
+	Reason  string // why the test failed or was skipped, "" if it passed
+	GasUsed uint64 // gas used by the call, without the intrinsic gas of its transaction
+	Logs    []*types.Log
+	Events  []*abi.Event // logs decoded with the contract's ABI, nil where no event matches
+	Console []console.Message
+}
+
+// Run deploys the contract and runs its tests whose name match accepts, all of them if it's nil.
+// If setUp fails or is skipped, its result is the only one, and likewise for the constructor if it's skipped.
+// The error is non-nil if the contract can't be deployed.
+func Run(c *artifacts.Contract, match func(name string) bool) ([]Result, error) {
+	if c.ABI == nil || len(c.Bytecode.Code) == 0 {
+		return nil, fmt.Errorf("%s: a test contract needs an ABI and creation bytecode", c.Name)
+	}
+	state := gevm.NewWorldState()
+	state.GetOrNewAccount(Sender).Balance = new(uint256.Int).Lsh(uint256.NewInt(1), 128)
+
+	deploy, res, err := execute(state, c, nil, c.Bytecode.Code)
+	if err != nil {
+		return nil, err
+	}
+	if deploy.Skipped {
+		deploy.Test = "constructor()"
+		return []Result{deploy}, nil
+	}
+	if !deploy.Passed {
+		return nil, fmt.Errorf("deploying %s: %s", c.Name, deploy.Reason)
+	}
+	addr := res.ContractAddress
+
+	if setUp, ok := c.ABI.Methods["setUp"]; ok && len(setUp.Inputs) == 0 {
+		result, _, err := execute(state, c, &addr, setUp.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Passed {
+			result.Test = setUp.Sig
+			return []Result{result}, nil
+		}
+	}
+
+	var results []Result
+	for _, m := range Tests(c) {
+		if match != nil && !match(m.Name) {
+			continue
+		}
+		result, _, err := execute(state.Copy(), c, &addr, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Test = m.Sig
+		if strings.HasPrefix(m.Name, "testFail") && !result.Skipped {
+			result.Passed = !result.Passed
+			result.Reason = ""
+			if !result.Passed {
+				result.Reason = "testFail function did not fail"
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// execute applies a message from Sender to the state, with new cheatcodes and console.log, and returns its result.
+// A nil to deploys data. The error is non-nil if the message is invalid.
+func execute(state gevm.WorldState, c *artifacts.Contract, to *common.Address, data []byte) (Result, *gevm.ExecutionResult, error) {
+	chainConfig := gevm.ChainConfig{ChainID: ChainID, GasLimit: GasLimit}
+	cheats, logs := cheatcodes.New(), console.New(nil)
+	cheats.Register(&chainConfig)
+	logs.Register(&chainConfig)
+
+	msg := &gevm.Message{From: Sender, To: to, Nonce: state[Sender].Nonce, GasLimit: GasLimit, Data: data}
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(1, 0))
+	unsupported := &unsupportedTracer{}
+	res, err := gevm.ApplyMessage(state, msg, block, chainConfig, unsupported)
+	if err != nil {
+		return Result{}, nil, err
+	}
+
+	err = cheats.Verify(res)
+	if err == nil && state.Exist(cheatcodes.Address) && state[cheatcodes.Address].Storage[failedSlot] != (common.Hash{}) {
+		err = errors.New("assertion failed")
+	}
+	result := Result{Passed: err == nil, Logs: res.Logs, Console: logs.Messages}
+	if unsupported.reason != "" {
+		result.Passed, result.Skipped, result.Reason = false, true, unsupported.reason
+	} else if err != nil {
+		result.Reason = reason(err, res.ReturnData, c.ABI)
+	}
+	if intrinsic := gevm.IntrinsicGas(data, nil, to == nil); res.UsedGas > intrinsic {
+		result.GasUsed = res.UsedGas - intrinsic
+	}
+	events := abi.NewEvents(c.ABI)
+	for _, log := range res.Logs {
+		ev, _ := events.Decode(log.Topics, log.Data)
+		result.Events = append(result.Events, ev)
+	}
+	return result, res, nil
+}
+
+// reason describes why a test failed: the reason or error it reverted with, or the expectation it didn't meet.
+func reason(err error, revertData []byte, a *gethabi.ABI) string {
+	if !errors.Is(err, gevm.ErrExecutionReverted) {
+		return err.Error()
+	}
+	if s, ok := abi.DecodeError(revertData); ok {
+		return s
+	}
+	if s, ok := abi.DecodeRevert(revertData, a); ok {
+		return s
+	}
+	if len(revertData) == 0 {
+		return "execution reverted"
+	}
+	return "execution reverted: " + hexutil.Encode(revertData)
+}
+
+// unsupportedTracer records the first instruction creating a contract or calling an external one, which halt the
+// execution as gevm runs a single call frame.
+type unsupportedTracer struct {
+	reason string // "" if there was none
+}
+
+func (t *unsupportedTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	if t.reason != "" {
+		return
+	}
+	switch op {
+	case gevm.CREATE, gevm.CREATE2:
+		t.reason = fmt.Sprintf("unsupported: contract creation (%s)", op)
+	case gevm.DELEGATECALL, gevm.CALLCODE:
+		t.reason = fmt.Sprintf("unsupported: external call (%s)", op)
+	case gevm.CALL, gevm.STATICCALL:
+		if evm.Stack.Len() < 2 {
+			return
+		}
+		to := common.Address(evm.Stack.Back(1).Bytes20())
+		if _, ok := evm.Natives[to]; !ok {
+			t.reason = fmt.Sprintf("unsupported: external call (%s to %s)", op, to)
+		}
+	}
+}
+
+func (t *unsupportedTracer) CaptureEnd(evm *gevm.EVM) {}
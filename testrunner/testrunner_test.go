@@ -0,0 +1,185 @@
+package testrunner
+
+import (
+	"strings"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/artifacts"
+	"github.com/Jesserc/gevm/cheatcodes"
+	"github.com/Jesserc/gevm/console"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+const testABI = `[
+	{"type":"function","name":"setUp","inputs":[],"outputs":[]},
+	{"type":"function","name":"testIncrement","inputs":[],"outputs":[]},
+	{"type":"function","name":"testIsolated","inputs":[],"outputs":[]},
+	{"type":"function","name":"testRevert","inputs":[],"outputs":[]},
+	{"type":"function","name":"testAssert","inputs":[],"outputs":[]},
+	{"type":"function","name":"testFailReverts","inputs":[],"outputs":[]},
+	{"type":"function","name":"testFailPasses","inputs":[],"outputs":[]},
+	{"type":"function","name":"testLog","inputs":[],"outputs":[]},
+	{"type":"function","name":"testDSTestFail","inputs":[],"outputs":[]},
+	{"type":"function","name":"testFuzz","inputs":[{"name":"x","type":"uint256"}],"outputs":[]},
+	{"type":"event","name":"Ping","inputs":[{"name":"x","type":"uint256","indexed":false}],"anonymous":false}
+]`
+
+var pingTopic = crypto.Keccak256Hash([]byte("Ping(uint256)"))
+
+// callNative appends a call to a native contract, with the calldata of a method, bubbling up its revert the way
+// Solidity does.
+func callNative(t *testing.T, p *gevm.Program, addr common.Address, sig string, args ...any) *gevm.Program {
+	data, err := abi.PackCall(sig, args...)
+	require.NoError(t, err)
+	return p.MstoreBytes(0x100, data).Call(nil, addr, 0, 0x100, len(data), 0, 0).Op(gevm.ISZERO).JumpI("bubble")
+}
+
+// creationCode wraps runtime code in code deploying it.
+func creationCode(runtime []byte) []byte {
+	init := func(offset int) *gevm.Program {
+		return gevm.NewProgram().Push(len(runtime)).Push(offset).Push0().Op(gevm.CODECOPY).Return(0, len(runtime))
+	}
+	size := init(0).Size()
+	return append(init(size).Bytes(), runtime...)
+}
+
+// newContract returns a test contract with the functions of testABI, dispatching on their selectors.
+func newContract(t *testing.T) *artifacts.Contract {
+	a, err := gethabi.JSON(strings.NewReader(testABI))
+	require.NoError(t, err)
+
+	p := gevm.NewProgram().Push0().Op(gevm.CALLDATALOAD).Push(224).Op(gevm.SHR)
+	for name, m := range a.Methods {
+		p.Op(gevm.DUP1).Push(m.ID).Op(gevm.EQ).JumpI(name)
+	}
+	p.Revert(0, 0)
+
+	p.Label("setUp").Sstore(0, 1).Op(gevm.STOP)
+	p.Label("testIncrement").Push(1).Push0().Op(gevm.SLOAD, gevm.ADD).Push0().Op(gevm.SSTORE, gevm.STOP)
+	p.Label("testIsolated").Push0().Op(gevm.SLOAD).Push(1).Op(gevm.EQ).JumpI("ok").Revert(0, 0)
+	revert := abi.EncodeError("boom")
+	p.Label("testRevert").MstoreBytes(0, revert).Revert(0, len(revert))
+	callNative(t, p.Label("testAssert"), cheatcodes.Address, "assertEq(uint256,uint256)", common.Big1, common.Big2).Op(gevm.STOP)
+	p.Label("testFailReverts").Revert(0, 0)
+	p.Label("testFailPasses").Op(gevm.STOP)
+	callNative(t, p.Label("testLog"), console.Address, "log(string)", "hi")
+	p.Mstore(0, 7).Push(pingTopic).Push(32).Push0().Op(gevm.LOG1, gevm.STOP)
+	callNative(t, p.Label("testDSTestFail"), cheatcodes.Address, "store(address,bytes32,bytes32)",
+		cheatcodes.Address, failedSlot, common.BigToHash(common.Big1)).Op(gevm.STOP)
+	p.Label("testFuzz").Op(gevm.STOP)
+	p.Label("ok").Op(gevm.STOP)
+	p.Label("bubble").Op(gevm.RETURNDATASIZE).Push0().Push0().Op(gevm.RETURNDATACOPY, gevm.RETURNDATASIZE).Push0().Op(gevm.REVERT)
+
+	return &artifacts.Contract{Name: "CounterTest", ABI: &a, Bytecode: artifacts.Bytecode{Code: creationCode(p.Bytes())}}
+}
+
+func TestRun(t *testing.T) {
+	c := newContract(t)
+	results, err := Run(c, nil)
+	require.NoError(t, err)
+
+	want := []struct {
+		test   string
+		passed bool
+		reason string
+	}{
+		{"testAssert()", false, "assertion failed: 1 != 2"},
+		{"testDSTestFail()", false, "assertion failed"},
+		{"testFailPasses()", false, "testFail function did not fail"},
+		{"testFailReverts()", true, ""},
+		{"testIncrement()", true, ""},
+		{"testIsolated()", true, ""},
+		{"testLog()", true, ""},
+		{"testRevert()", false, "boom"},
+	}
+	require.Len(t, results, len(want))
+	for i, w := range want {
+		assert.Equal(t, w.test, results[i].Test)
+		assert.Equal(t, w.passed, results[i].Passed, w.test)
+		assert.Equal(t, w.reason, results[i].Reason, w.test)
+		assert.NotZero(t, results[i].GasUsed, w.test)
+	}
+
+	log := results[6]
+	require.Len(t, log.Logs, 1)
+	require.Len(t, log.Events, 1)
+	assert.Equal(t, "Ping(uint256 x = 7)", log.Events[0].String())
+	require.Len(t, log.Console, 1)
+	assert.Equal(t, "hi", log.Console[0].Text)
+
+	results, err = Run(c, func(name string) bool { return strings.HasPrefix(name, "testFail") })
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "testFailPasses()", results[0].Test)
+	assert.Equal(t, "testFailReverts()", results[1].Test)
+}
+
+func TestRunFailures(t *testing.T) {
+	c := newContract(t)
+	c.ABI.Methods["setUp"] = c.ABI.Methods["testRevert"]
+	results, err := Run(c, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "testRevert()", results[0].Test)
+	assert.False(t, results[0].Passed)
+	assert.Equal(t, "boom", results[0].Reason)
+
+	c.Bytecode.Code = gevm.NewProgram().Revert(0, 0).Bytes()
+	_, err = Run(c, nil)
+	assert.EqualError(t, err, "deploying CounterTest: execution reverted")
+
+	c.Bytecode.Code = nil
+	_, err = Run(c, nil)
+	assert.EqualError(t, err, "CounterTest: a test contract needs an ABI and creation bytecode")
+}
+
+func TestRunArtifact(t *testing.T) {
+	contracts, err := artifacts.Load("testdata/CounterTest.t.sol/CounterTest.json")
+	require.NoError(t, err)
+	c, err := artifacts.Find(contracts, "test/CounterTest.t.sol:CounterTest")
+	require.NoError(t, err)
+	results, err := Run(c, nil)
+	require.NoError(t, err)
+
+	want := []struct {
+		test    string
+		passed  bool
+		skipped bool
+		reason  string
+	}{
+		{"testCreate()", false, true, "unsupported: contract creation (CREATE)"},
+		{"testExternalCall()", false, true, "unsupported: external call (CALL to 0x000000000000000000000000000000000000bEEF)"},
+		{"testFailExternalCall()", false, true, "unsupported: external call (CALL to 0x000000000000000000000000000000000000bEEF)"},
+		{"testFailUnderflow()", true, false, ""},
+		{"testIncrement()", true, false, ""},
+	}
+	require.Len(t, results, len(want))
+	for i, w := range want {
+		assert.Equal(t, w.test, results[i].Test)
+		assert.Equal(t, w.passed, results[i].Passed, w.test)
+		assert.Equal(t, w.skipped, results[i].Skipped, w.test)
+		assert.Equal(t, w.reason, results[i].Reason, w.test)
+	}
+
+	// A setUp or constructor gevm can't run skips the whole contract
+	c.ABI.Methods["setUp"] = c.ABI.Methods["testCreate"]
+	results, err = Run(c, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, Result{Test: "testCreate()", Skipped: true, Reason: "unsupported: contract creation (CREATE)"},
+		Result{Test: results[0].Test, Skipped: results[0].Skipped, Reason: results[0].Reason})
+
+	c.Bytecode.Code = gevm.NewProgram().Push0().Push0().Push0().Op(gevm.CREATE).Bytes()
+	results, err = Run(c, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "constructor()", results[0].Test)
+	assert.True(t, results[0].Skipped)
+}
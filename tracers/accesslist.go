@@ -0,0 +1,153 @@
+package tracers
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// AccessListTracer records the accounts and storage slots an execution accesses.
+type AccessListTracer struct {
+	accessed map[common.Address]map[common.Hash]struct{}
+}
+
+// NewAccessListTracer creates a tracer that has recorded no access.
+func NewAccessListTracer() *AccessListTracer {
+	return &AccessListTracer{accessed: make(map[common.Address]map[common.Hash]struct{})}
+}
+
+// CaptureState implements gevm.Tracer.
+func (t *AccessListTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	switch op {
+	case gevm.SLOAD, gevm.SSTORE:
+		if evm.Stack.Len() >= 1 {
+			t.add(evm.Address).add(evm.Stack.Back(0).Bytes32())
+		}
+	case gevm.BALANCE, gevm.EXTCODESIZE, gevm.EXTCODECOPY, gevm.EXTCODEHASH, gevm.SELFDESTRUCT:
+		if evm.Stack.Len() >= 1 {
+			t.add(evm.Stack.Back(0).Bytes20())
+		}
+	case gevm.CALL, gevm.CALLCODE, gevm.DELEGATECALL, gevm.STATICCALL:
+		if evm.Stack.Len() >= 2 {
+			t.add(evm.Stack.Back(1).Bytes20())
+		}
+	}
+}
+
+// CaptureEnd implements gevm.Tracer.
+func (t *AccessListTracer) CaptureEnd(evm *gevm.EVM) {}
+
+type slotSet map[common.Hash]struct{}
+
+func (t *AccessListTracer) add(addr common.Address) slotSet {
+	slots, ok := t.accessed[addr]
+	if !ok {
+		slots = make(slotSet)
+		t.accessed[addr] = slots
+	}
+	return slots
+}
+
+func (s slotSet) add(slot common.Hash) {
+	s[slot] = struct{}{}
+}
+
+// AccessList returns the accesses recorded as an access list, sorted by address and slot.
+func (t *AccessListTracer) AccessList() types.AccessList {
+	list := make(types.AccessList, 0, len(t.accessed))
+	for addr, slots := range t.accessed {
+		tuple := types.AccessTuple{Address: addr, StorageKeys: make([]common.Hash, 0, len(slots))}
+		for slot := range slots {
+			tuple.StorageKeys = append(tuple.StorageKeys, slot)
+		}
+		sort.Slice(tuple.StorageKeys, func(i, j int) bool {
+			return bytes.Compare(tuple.StorageKeys[i][:], tuple.StorageKeys[j][:]) < 0
+		})
+		list = append(list, tuple)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address.Cmp(list[j].Address) < 0
+	})
+	return list
+}
+
+// AccessListResult is an access list generated for a message, with the gas the message used before and after it.
+type AccessListResult struct {
+	AccessList    types.AccessList
+	GasUsedBefore uint64 // gas used with the message's own access list
+	GasUsed       uint64 // gas used with AccessList
+	Err           error  // execution error with AccessList, nil if execution succeeded
+}
+
+// CreateAccessList generates the EIP-2930 access list lowering the gas msg uses the most, executing it on copies of
+// the state. The accesses of an execution are traced, then every entry that doesn't lower the gas used is removed:
+// listing an account or a slot costs gas up front, which only pays off when the execution accesses it cold.
+// The access list is empty if no entry lowers the gas used.
+func CreateAccessList(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig) (*AccessListResult, error) {
+	run := func(accessList types.AccessList, tracer gevm.Tracer) (*gevm.ExecutionResult, error) {
+		cpy := *msg
+		cpy.AccessList = accessList
+		return gevm.ApplyMessage(state.Copy(), &cpy, block, chainConfig, tracer)
+	}
+
+	tracer := NewAccessListTracer()
+	before, err := run(msg.AccessList, tracer)
+	if err != nil {
+		return nil, err
+	}
+	list := tracer.AccessList()
+	best, err := run(list, nil)
+	if err != nil {
+		return nil, err
+	}
+	// try keeps the access list without an entry if the gas used doesn't go up
+	try := func(without types.AccessList) error {
+		res, err := run(without, nil)
+		if err == nil && res.UsedGas <= best.UsedGas {
+			list, best = without, res
+		}
+		return err
+	}
+	for i := 0; i < len(list); i++ {
+		n := len(list)
+		if err := try(append(copyAccessList(list[:i]), list[i+1:]...)); err != nil {
+			return nil, err
+		}
+		if len(list) < n {
+			i--
+			continue
+		}
+		for j := 0; j < len(list[i].StorageKeys); j++ {
+			without := copyAccessList(list)
+			keys := list[i].StorageKeys
+			without[i].StorageKeys = append(append([]common.Hash{}, keys[:j]...), keys[j+1:]...)
+			if err := try(without); err != nil {
+				return nil, err
+			}
+			if len(list[i].StorageKeys) < len(keys) {
+				j--
+			}
+		}
+	}
+
+	empty, err := run(types.AccessList{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if best.UsedGas >= empty.UsedGas {
+		list, best = types.AccessList{}, empty
+	}
+	return &AccessListResult{AccessList: list, GasUsedBefore: before.UsedGas, GasUsed: best.UsedGas, Err: best.Err}, nil
+}
+
+func copyAccessList(list types.AccessList) types.AccessList {
+	cpy := make(types.AccessList, len(list))
+	for i, tuple := range list {
+		cpy[i] = types.AccessTuple{Address: tuple.Address, StorageKeys: append([]common.Hash{}, tuple.StorageKeys...)}
+	}
+	return cpy
+}
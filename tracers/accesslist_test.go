@@ -0,0 +1,111 @@
+package tracers
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+var (
+	sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	contract = common.HexToAddress("0x1000")
+	other    = common.HexToAddress("0xbeef")
+)
+
+// applyCode runs a message calling code deployed at contract, with the tracer.
+func applyCode(t *testing.T, code []byte, tracer gevm.Tracer) (gevm.WorldState, *gevm.Message, *gevm.Block, gevm.ChainConfig) {
+	t.Helper()
+	state := gevm.NewWorldState()
+	state.GetOrNewAccount(sender).Balance = uint256.NewInt(1e18)
+	state.GetOrNewAccount(contract).Code = code
+	msg := &gevm.Message{From: sender, To: &contract, GasLimit: 1_000_000}
+	block := gevm.NewBlock(common.Address{}, 0, 1, 0, 0, time.Unix(0, 0))
+	chainConfig := gevm.ChainConfig{ChainID: 1, GasLimit: 30_000_000}
+	if tracer != nil {
+		res, err := gevm.ApplyMessage(state.Copy(), msg, block, chainConfig, tracer)
+		require.NoError(t, err)
+		require.NoError(t, res.Err)
+	}
+	return state, msg, block, chainConfig
+}
+
+func TestAccessListTracer(t *testing.T) {
+	code := gevm.NewProgram().
+		Push(2).Op(SLOAD, POP).Push(1).Op(SLOAD, POP).Push(2).Op(SLOAD, POP).
+		Push(other.Bytes()).Op(BALANCE, POP).Push(sender.Bytes()).Op(BALANCE, POP).
+		Sstore(3, 1).Op(STOP).Bytes()
+	tracer := NewAccessListTracer()
+	applyCode(t, code, tracer)
+	assert.Equal(t, types.AccessList{
+		{Address: contract, StorageKeys: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")}},
+		{Address: other, StorageKeys: []common.Hash{}},
+		{Address: sender, StorageKeys: []common.Hash{}},
+	}, tracer.AccessList())
+}
+
+const (
+	SLOAD   = gevm.SLOAD
+	POP     = gevm.POP
+	BALANCE = gevm.BALANCE
+	STOP    = gevm.STOP
+)
+
+func TestCreateAccessList(t *testing.T) {
+	manySlots := gevm.NewProgram()
+	slots := make([]common.Hash, 30)
+	for i := range slots {
+		manySlots.Push(i).Op(SLOAD, POP)
+		slots[i] = common.BigToHash(big.NewInt(int64(i)))
+	}
+
+	tests := []struct {
+		name  string
+		code  []byte
+		want  types.AccessList
+		saved uint64
+	}{
+		{
+			name: "Nothing to save",
+			code: gevm.NewProgram().Push(1).Op(POP, STOP).Bytes(),
+			want: types.AccessList{},
+		},
+		{
+			// Listing the contract's own slot costs more than it saves, listing a cold account saves 100
+			name:  "Cold account",
+			code:  gevm.NewProgram().Push(1).Op(SLOAD, POP).Push(other.Bytes()).Op(BALANCE, POP).Push(sender.Bytes()).Op(BALANCE, POP, STOP).Bytes(),
+			want:  types.AccessList{{Address: other, StorageKeys: []common.Hash{}}},
+			saved: 100,
+		},
+		{
+			// Each slot saves 100, which pays for listing the contract past 24 slots
+			name:  "Many slots",
+			code:  manySlots.Op(STOP).Bytes(),
+			want:  types.AccessList{{Address: contract, StorageKeys: slots}},
+			saved: 30*100 - 2400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg, block, chainConfig := applyCode(t, tt.code, nil)
+			result, err := CreateAccessList(state, msg, block, chainConfig)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.AccessList)
+			assert.Equal(t, tt.saved, result.GasUsedBefore-result.GasUsed)
+			assert.NoError(t, result.Err)
+
+			cpy := *msg
+			cpy.AccessList = result.AccessList
+			res, err := gevm.ApplyMessage(state.Copy(), &cpy, block, chainConfig, nil)
+			require.NoError(t, err)
+			assert.Equal(t, result.GasUsed, res.UsedGas)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Jesserc/gevm/abi"
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// CallFrame is a call of a call tree, as geth's callTracer reports it.
+type CallFrame struct {
+	Type         string          `json:"type"` // CALL, STATICCALL, DELEGATECALL, CREATE, CREATE2 or SELFDESTRUCT
+	From         common.Address  `json:"from"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	To           *common.Address `json:"to,omitempty"` // nil for a contract creation that failed
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"` // returned data, revert data or deployed code
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []CallFrame     `json:"calls,omitempty"`
+	Logs         []CallLog       `json:"logs,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+}
+
+// CallLog is a log emitted by a call frame.
+type CallLog struct {
+	Address  common.Address `json:"address"`
+	Topics   []common.Hash  `json:"topics"`
+	Data     hexutil.Bytes  `json:"data"`
+	Position hexutil.Uint   `json:"position"` // number of calls of the frame made before the log
+}
+
+// CallTracerConfig is the configuration of the callTracer.
+type CallTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // leave out the calls of the message's frame
+	WithLog     bool `json:"withLog"`     // include the logs of the frames that didn't fail
+}
+
+// CallTracer builds the call tree of a message, in the format of geth's callTracer.
+//
+// gevm only supports calls to native contracts, which run in the frame of their caller, so the tree has the message's
+// frame with a child frame for each native contract it called.
+type CallTracer struct {
+	config   CallTracerConfig
+	frame    CallFrame
+	callLogs []int // number of logs emitted before each call
+}
+
+// NewCallTracer creates a callTracer.
+func NewCallTracer(config CallTracerConfig) *CallTracer {
+	return &CallTracer{config: config}
+}
+
+func newCallTracerFromJSON(config json.RawMessage) (Tracer, error) {
+	var c CallTracerConfig
+	if err := unmarshalConfig(config, &c); err != nil {
+		return nil, err
+	}
+	return NewCallTracer(c), nil
+}
+
+// CaptureTxStart implements Tracer.
+func (t *CallTracer) CaptureTxStart(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig) {
+	t.frame = CallFrame{
+		Type:  "CALL",
+		From:  msg.From,
+		Gas:   hexutil.Uint64(msg.GasLimit),
+		To:    msg.To,
+		Input: common.CopyBytes(msg.Data),
+		Value: new(hexutil.Big),
+	}
+	t.callLogs = nil
+	if msg.To == nil {
+		t.frame.Type = "CREATE"
+		to := crypto.CreateAddress(msg.From, msg.Nonce)
+		t.frame.To = &to
+	}
+	if msg.Value != nil {
+		t.frame.Value = (*hexutil.Big)(msg.Value.ToBig())
+	}
+}
+
+// CaptureState implements gevm.Tracer.
+func (t *CallTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {}
+
+// CaptureEnd implements gevm.Tracer.
+func (t *CallTracer) CaptureEnd(evm *gevm.EVM) {}
+
+// CaptureNativeCall implements gevm.NativeCallTracer.
+func (t *CallTracer) CaptureNativeCall(evm *gevm.EVM, call gevm.NativeCall) {
+	frame := CallFrame{
+		Type:  call.Op.String(),
+		From:  evm.Address,
+		Gas:   hexutil.Uint64(call.Gas),
+		To:    &call.To,
+		Input: call.Input,
+	}
+	switch {
+	case call.Err == nil:
+		frame.Output = call.Output
+	case errors.Is(call.Err, gevm.ErrExecutionReverted):
+		frame.Error = gevm.ErrExecutionReverted.Error()
+		if len(call.Output) > 0 {
+			frame.Output = call.Output
+			frame.RevertReason, _ = abi.DecodeRevert(call.Output, nil)
+		}
+	default:
+		frame.Error = call.Err.Error()
+	}
+	if call.Op == gevm.CALL {
+		frame.Value = (*hexutil.Big)(call.Value.ToBig())
+	}
+	t.frame.Calls = append(t.frame.Calls, frame)
+	t.callLogs = append(t.callLogs, len(*evm.LogRecord))
+}
+
+// CaptureTxEnd implements Tracer.
+func (t *CallTracer) CaptureTxEnd(state gevm.WorldState, res *gevm.ExecutionResult) {
+	t.frame.GasUsed = hexutil.Uint64(res.UsedGas)
+	switch {
+	case res.Err == nil:
+		t.frame.Output = common.CopyBytes(res.ReturnData)
+	case errors.Is(res.Err, gevm.ErrExecutionReverted):
+		t.frame.Error = gevm.ErrExecutionReverted.Error()
+		if len(res.ReturnData) > 0 {
+			t.frame.Output = common.CopyBytes(res.ReturnData)
+			t.frame.RevertReason, _ = abi.DecodeRevert(res.ReturnData, nil)
+		}
+	default:
+		t.frame.Error = res.Err.Error()
+	}
+	if res.Err != nil && t.frame.Type == "CREATE" {
+		t.frame.To = nil
+	}
+	if t.config.WithLog {
+		// The failed frames' logs were discarded with their state changes
+		calls := 0
+		for i, log := range res.Logs {
+			for calls < len(t.callLogs) && t.callLogs[calls] <= i {
+				calls++
+			}
+			t.frame.Logs = append(t.frame.Logs, CallLog{
+				Address: log.Address, Topics: log.Topics, Data: log.Data, Position: hexutil.Uint(calls),
+			})
+		}
+	}
+	if t.config.OnlyTopCall {
+		t.frame.Calls = nil
+	}
+}
+
+// Frame returns the frame of the message, with its calls.
+func (t *CallTracer) Frame() *CallFrame {
+	return &t.frame
+}
+
+// GetResult implements Tracer.
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.frame)
+}
@@ -0,0 +1,186 @@
+package tracers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// revertNo is the revert data of Error("no").
+var revertNo = common.FromHex("08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000026e6f000000000000000000000000000000000000000000000000000000000000")
+
+func TestCallTracer(t *testing.T) {
+	// LOG1 of the calldata with topic 0xaa, returning it
+	logCode := gevm.NewProgram().Op(gevm.CALLDATASIZE).Push0().Push0().Op(gevm.CALLDATACOPY).
+		Push(0xaa).Op(gevm.CALLDATASIZE).Push0().Op(gevm.LOG1).Return(0, 1).Bytes()
+	// Reverting with Error("no"), copied from the end of the code
+	revertCode := append(common.FromHex("6064600c5f3960645ffd0000"), revertNo...)
+
+	tests := []struct {
+		name   string
+		code   []byte
+		config CallTracerConfig
+		want   string
+	}{
+		{
+			name:   "Call with logs",
+			code:   logCode,
+			config: CallTracerConfig{WithLog: true},
+			want: `{"type":"CALL","from":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b","gas":"0xf4240","gasUsed":"0x%x",
+				"to":"0x0000000000000000000000000000000000001000","input":"0xff","output":"0xff","value":"0x5",
+				"logs":[{"address":"0x0000000000000000000000000000000000001000","topics":["0x00000000000000000000000000000000000000000000000000000000000000aa"],"data":"0xff","position":"0x0"}]}`,
+		},
+		{
+			name: "Call without logs",
+			code: logCode,
+			want: `{"type":"CALL","from":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b","gas":"0xf4240","gasUsed":"0x%x",
+				"to":"0x0000000000000000000000000000000000001000","input":"0xff","output":"0xff","value":"0x5"}`,
+		},
+		{
+			name: "Revert",
+			code: revertCode,
+			want: `{"type":"CALL","from":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b","gas":"0xf4240","gasUsed":"0x%x",
+				"to":"0x0000000000000000000000000000000000001000","input":"0xff","output":"` + hexutil.Encode(revertNo) + `",
+				"error":"execution reverted","revertReason":"Error(\"no\")","value":"0x5"}`,
+		},
+		{
+			name: "Invalid opcode",
+			code: []byte{0xfe},
+			want: `{"type":"CALL","from":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b","gas":"0xf4240","gasUsed":"0xf4240",
+				"to":"0x0000000000000000000000000000000000001000","input":"0xff","error":"invalid opcode","value":"0x5"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg, block, chainConfig := applyCode(t, tt.code, nil)
+			msg.Data, msg.Value = []byte{0xff}, uint256.NewInt(5)
+			tracer := NewCallTracer(tt.config)
+			res, err := TraceMessage(state, msg, block, chainConfig, tracer)
+			require.NoError(t, err)
+
+			result, err := tracer.GetResult()
+			require.NoError(t, err)
+			want := tt.want
+			if res.UsedGas != msg.GasLimit {
+				want = fmt.Sprintf(tt.want, res.UsedGas)
+			}
+			assert.JSONEq(t, want, string(result))
+		})
+	}
+}
+
+// nativeFunc is a native contract running a function.
+type nativeFunc func(*gevm.EVM, []byte) ([]byte, error)
+
+func (f nativeFunc) Run(evm *gevm.EVM, input []byte) ([]byte, error) { return f(evm, input) }
+
+// echo is a native contract returning its input, or reverting with Error("no") without one.
+var echo = nativeFunc(func(_ *gevm.EVM, input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return revertNo, gevm.ErrExecutionReverted
+	}
+	return input, nil
+})
+
+func TestCallTracerNativeCalls(t *testing.T) {
+	native := common.HexToAddress("0x2000")
+	code := gevm.NewProgram().
+		Mstore(0, common.HexToHash("0xa9059cbb00000000000000000000000000000000000000000000000000000000")).
+		Push0().Push0().Op(gevm.LOG0).
+		StaticCall(nil, native, 0, 5, 0, 5).Op(gevm.POP).
+		Push0().Push0().Op(gevm.LOG0).
+		Call(0x1000, native, 0, 0, 0, 0, 0).Op(gevm.POP).
+		Call(0x1000, native, 1, 0, 4, 0, 0).Op(gevm.POP).
+		Push0().Push0().Op(gevm.LOG0).Bytes()
+	state, msg, block, chainConfig := applyCode(t, code, nil)
+	chainConfig.Natives = map[common.Address]gevm.NativeContract{native: echo}
+	tracer := NewCallTracer(CallTracerConfig{WithLog: true})
+	res, err := TraceMessage(state, msg, block, chainConfig, tracer)
+	require.NoError(t, err)
+	require.NoError(t, res.Err)
+
+	frame := tracer.Frame()
+	require.Len(t, frame.Calls, 3)
+	static := frame.Calls[0]
+	assert.Equal(t, "STATICCALL", static.Type)
+	assert.Equal(t, contract, static.From)
+	assert.Equal(t, &native, static.To)
+	assert.Equal(t, common.FromHex("a9059cbb00"), []byte(static.Input))
+	assert.Equal(t, common.FromHex("a9059cbb00"), []byte(static.Output))
+	assert.Nil(t, static.Value)
+	assert.Empty(t, static.Error)
+
+	reverted := frame.Calls[1]
+	assert.Equal(t, "CALL", reverted.Type)
+	assert.Equal(t, hexutil.Uint64(0x1000), reverted.Gas)
+	assert.Equal(t, "execution reverted", reverted.Error)
+	assert.Equal(t, `Error("no")`, reverted.RevertReason)
+	assert.Equal(t, revertNo, []byte(reverted.Output))
+	assert.Equal(t, "0x0", reverted.Value.String())
+
+	withValue := frame.Calls[2]
+	assert.Equal(t, gevm.ErrNativeValue.Error(), withValue.Error)
+	assert.Equal(t, "0x1", withValue.Value.String())
+	assert.Empty(t, withValue.Output)
+
+	var positions []hexutil.Uint
+	for _, log := range frame.Logs {
+		positions = append(positions, log.Position)
+	}
+	assert.Equal(t, []hexutil.Uint{0, 1, 3}, positions)
+}
+
+func TestCallTracerCreate(t *testing.T) {
+	runtime := []byte{0x00}
+	tests := []struct {
+		name     string
+		initCode []byte
+		wantTo   bool
+		wantErr  string
+	}{
+		{"Success", gevm.NewProgram().Mstore(0, 0).Return(0, 1).Bytes(), true, ""},
+		{"Revert", gevm.NewProgram().Revert(0, 0).Bytes(), false, "execution reverted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg, block, chainConfig := applyCode(t, nil, nil)
+			msg.To, msg.Data = nil, tt.initCode
+			tracer := NewCallTracer(CallTracerConfig{})
+			res, err := TraceMessage(state, msg, block, chainConfig, tracer)
+			require.NoError(t, err)
+
+			frame := tracer.Frame()
+			assert.Equal(t, "CREATE", frame.Type)
+			assert.Equal(t, tt.initCode, []byte(frame.Input))
+			assert.Equal(t, res.UsedGas, uint64(frame.GasUsed))
+			assert.Equal(t, tt.wantErr, frame.Error)
+			if tt.wantTo {
+				require.NotNil(t, frame.To)
+				assert.Equal(t, crypto.CreateAddress(sender, 0), *frame.To)
+				assert.Equal(t, runtime, []byte(frame.Output))
+			} else {
+				assert.Nil(t, frame.To)
+				assert.Empty(t, frame.Output)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tracer, err := New("callTracer", []byte(`{"withLog":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, CallTracerConfig{WithLog: true}, tracer.(*CallTracer).config)
+
+	_, err = New("callTracer", []byte(`{"withLog":1}`))
+	assert.ErrorContains(t, err, "invalid tracer config")
+	_, err = New("noopTracer", nil)
+	assert.EqualError(t, err, `unknown tracer "noopTracer", expected one of [4byteTracer callTracer prestateTracer]`)
+}
@@ -0,0 +1,65 @@
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// FourByteTracer counts the function selectors called and the size of their arguments, in the format of geth's
+// 4byteTracer: "0xa9059cbb-64" is a call of transfer(address,uint256) with 64 bytes of arguments.
+// Calls of precompiles, calldata shorter than a selector and contract creations are left out.
+//
+// gevm only supports calls to native contracts, so the message's call is counted with the native contracts it called.
+type FourByteTracer struct {
+	ids map[string]int
+}
+
+// NewFourByteTracer creates a 4byteTracer.
+func NewFourByteTracer() *FourByteTracer {
+	return &FourByteTracer{ids: make(map[string]int)}
+}
+
+func newFourByteTracerFromJSON(config json.RawMessage) (Tracer, error) {
+	return NewFourByteTracer(), nil
+}
+
+// CaptureTxStart implements Tracer.
+func (t *FourByteTracer) CaptureTxStart(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig) {
+	if msg.To != nil && !gevm.IsPrecompile(*msg.To, chainConfig.Fork) {
+		t.count(msg.Data)
+	}
+}
+
+func (t *FourByteTracer) count(input []byte) {
+	if len(input) >= 4 {
+		t.ids[fmt.Sprintf("0x%x-%d", input[:4], len(input)-4)]++
+	}
+}
+
+// CaptureState implements gevm.Tracer.
+func (t *FourByteTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {}
+
+// CaptureEnd implements gevm.Tracer.
+func (t *FourByteTracer) CaptureEnd(evm *gevm.EVM) {}
+
+// CaptureNativeCall implements gevm.NativeCallTracer.
+func (t *FourByteTracer) CaptureNativeCall(evm *gevm.EVM, call gevm.NativeCall) {
+	if !gevm.IsPrecompile(call.To, evm.Fork) {
+		t.count(call.Input)
+	}
+}
+
+// CaptureTxEnd implements Tracer.
+func (t *FourByteTracer) CaptureTxEnd(state gevm.WorldState, res *gevm.ExecutionResult) {}
+
+// Counts returns the number of calls by selector and size of the arguments.
+func (t *FourByteTracer) Counts() map[string]int {
+	return t.ids
+}
+
+// GetResult implements Tracer.
+func (t *FourByteTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.ids)
+}
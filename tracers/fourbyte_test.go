@@ -0,0 +1,59 @@
+package tracers
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func TestFourByteTracer(t *testing.T) {
+	precompile := common.BytesToAddress([]byte{0x02})
+	tests := []struct {
+		name string
+		to   *common.Address
+		data []byte
+		want string
+	}{
+		{"Call", &contract, common.FromHex("a9059cbb" + "00" + "ff"), `{"0xa9059cbb-2":1}`},
+		{"Selector only", &contract, common.FromHex("a9059cbb"), `{"0xa9059cbb-0":1}`},
+		{"Short calldata", &contract, common.FromHex("a9059c"), `{}`},
+		{"Precompile", &precompile, common.FromHex("a9059cbb"), `{}`},
+		{"Creation", nil, common.FromHex("a9059cbb"), `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg, block, chainConfig := applyCode(t, []byte{byte(gevm.STOP)}, nil)
+			msg.To, msg.Data = tt.to, tt.data
+			tracer := NewFourByteTracer()
+			_, err := TraceMessage(state, msg, block, chainConfig, tracer)
+			require.NoError(t, err)
+			result, err := tracer.GetResult()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(result))
+		})
+	}
+}
+
+func TestFourByteTracerNativeCalls(t *testing.T) {
+	native := common.HexToAddress("0x2000")
+	precompile := common.BytesToAddress([]byte{0x02})
+	code := gevm.NewProgram().
+		Mstore(0, common.HexToHash("0xa9059cbb00000000000000000000000000000000000000000000000000000000")).
+		StaticCall(nil, native, 0, 6, 0, 0).Op(gevm.POP).
+		Call(nil, native, 0, 0, 6, 0, 0).Op(gevm.POP).
+		Call(nil, native, 0, 0, 3, 0, 0).Op(gevm.POP).
+		StaticCall(nil, precompile, 0, 4, 0, 0).Op(gevm.POP).Bytes()
+	state, msg, block, chainConfig := applyCode(t, code, nil)
+	chainConfig.Natives = map[common.Address]gevm.NativeContract{native: echo, precompile: echo}
+	msg.Data = common.FromHex("12345678")
+	tracer := NewFourByteTracer()
+	_, err := TraceMessage(state, msg, block, chainConfig, tracer)
+	require.NoError(t, err)
+	result, err := tracer.GetResult()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"0x12345678-0":1,"0xa9059cbb-2":2}`, string(result))
+}
@@ -0,0 +1,192 @@
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// PrestateAccount is the state of an account, as geth's prestateTracer reports it.
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// exists reports whether the account has any state.
+func (a *PrestateAccount) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.ToInt().Sign() != 0)
+}
+
+// Prestate is the state of the accounts an execution touched.
+type Prestate map[common.Address]*PrestateAccount
+
+// PrestateTracerConfig is the configuration of the prestateTracer.
+type PrestateTracerConfig struct {
+	DiffMode       bool `json:"diffMode"` // report what changed, before and after execution
+	DisableCode    bool `json:"disableCode"`
+	DisableStorage bool `json:"disableStorage"`
+}
+
+// PrestateTracer records the state of the accounts and storage slots a message touches before it executes, in the
+// format of geth's prestateTracer. The touched accounts are the sender, the recipient or created contract, the
+// coinbase and the accounts the code looks up; the touched slots are those the code loads or stores.
+//
+// In diff mode, only the changed accounts are kept, with the fields and slots that changed before execution and
+// after it. An account deleted by the execution has no post-state.
+type PrestateTracer struct {
+	config   PrestateTracerConfig
+	state    gevm.WorldState // state before execution
+	accessed *AccessListTracer
+	created  *common.Address
+	pre      Prestate
+	post     Prestate
+}
+
+// NewPrestateTracer creates a prestateTracer.
+func NewPrestateTracer(config PrestateTracerConfig) *PrestateTracer {
+	return &PrestateTracer{config: config, accessed: NewAccessListTracer(), pre: make(Prestate), post: make(Prestate)}
+}
+
+func newPrestateTracerFromJSON(config json.RawMessage) (Tracer, error) {
+	var c PrestateTracerConfig
+	if err := unmarshalConfig(config, &c); err != nil {
+		return nil, err
+	}
+	return NewPrestateTracer(c), nil
+}
+
+// CaptureTxStart implements Tracer.
+func (t *PrestateTracer) CaptureTxStart(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig) {
+	t.state = state.Copy()
+	t.accessed.add(msg.From)
+	t.accessed.add(block.Coinbase)
+	if msg.To == nil {
+		created := crypto.CreateAddress(msg.From, msg.Nonce)
+		t.created = &created
+		t.accessed.add(created)
+	} else {
+		t.accessed.add(*msg.To)
+	}
+}
+
+// CaptureState implements gevm.Tracer.
+func (t *PrestateTracer) CaptureState(evm *gevm.EVM, pc uint64, op gevm.Opcode) {
+	t.accessed.CaptureState(evm, pc, op)
+}
+
+// CaptureEnd implements gevm.Tracer.
+func (t *PrestateTracer) CaptureEnd(evm *gevm.EVM) {}
+
+// CaptureTxEnd implements Tracer.
+func (t *PrestateTracer) CaptureTxEnd(state gevm.WorldState, res *gevm.ExecutionResult) {
+	for addr, slots := range t.accessed.accessed {
+		t.pre[addr] = t.account(t.state[addr], slots)
+	}
+	if t.config.DiffMode {
+		t.diff(state)
+	}
+	// The pre-state of a created contract is empty, unless something was at its address
+	if t.created != nil {
+		if acc := t.pre[*t.created]; acc != nil && !acc.exists() {
+			delete(t.pre, *t.created)
+		}
+	}
+}
+
+// account returns the state of an account with the given slots, acc being nil for an account that doesn't exist.
+func (t *PrestateTracer) account(acc *gevm.Account, slots slotSet) *PrestateAccount {
+	if acc == nil {
+		acc = gevm.NewAccount()
+	}
+	a := &PrestateAccount{Balance: (*hexutil.Big)(acc.Balance.ToBig()), Nonce: acc.Nonce}
+	if !t.config.DisableCode {
+		a.Code = common.CopyBytes(acc.Code)
+	}
+	if !t.config.DisableStorage && len(slots) > 0 {
+		a.Storage = make(map[common.Hash]common.Hash, len(slots))
+		for slot := range slots {
+			a.Storage[slot] = acc.Storage[slot]
+		}
+	}
+	return a
+}
+
+// diff keeps the accounts that changed in the pre-state, with the fields that changed, and records their post-state.
+func (t *PrestateTracer) diff(state gevm.WorldState) {
+	for addr, pre := range t.pre {
+		acc, ok := state[addr]
+		if !ok {
+			// Deleted, or never existed
+			if !pre.exists() {
+				delete(t.pre, addr)
+			}
+			continue
+		}
+		post := t.account(acc, nil)
+		modified := false
+		if post.Balance.ToInt().Cmp(pre.Balance.ToInt()) == 0 {
+			post.Balance = nil
+		} else {
+			modified = true
+		}
+		if post.Nonce == pre.Nonce {
+			post.Nonce = 0
+		} else {
+			modified = true
+		}
+		if bytes.Equal(post.Code, pre.Code) {
+			post.Code = nil
+		} else {
+			modified = true
+		}
+		for slot, value := range pre.Storage {
+			newValue := acc.Storage[slot]
+			if value == (common.Hash{}) || value == newValue {
+				delete(pre.Storage, slot)
+			}
+			if value == newValue {
+				continue
+			}
+			modified = true
+			if newValue != (common.Hash{}) {
+				if post.Storage == nil {
+					post.Storage = make(map[common.Hash]common.Hash)
+				}
+				post.Storage[slot] = newValue
+			}
+		}
+		if modified {
+			t.post[addr] = post
+		} else {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+// Prestate returns the state of the touched accounts before execution, only the changes in diff mode.
+func (t *PrestateTracer) Prestate() Prestate {
+	return t.pre
+}
+
+// Poststate returns the changes of the accounts after execution in diff mode, and nothing otherwise.
+func (t *PrestateTracer) Poststate() Prestate {
+	return t.post
+}
+
+// GetResult implements Tracer.
+func (t *PrestateTracer) GetResult() (json.RawMessage, error) {
+	if t.config.DiffMode {
+		return json.Marshal(struct {
+			Pre  Prestate `json:"pre"`
+			Post Prestate `json:"post"`
+		}{t.pre, t.post})
+	}
+	return json.Marshal(t.pre)
+}
@@ -0,0 +1,98 @@
+package tracers
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+func TestPrestateTracer(t *testing.T) {
+	// Clears slot 1, sets slot 3, reads slot 2 and the balance of other
+	code := gevm.NewProgram().Sstore(1, 0).Sstore(3, 4).Push(2).Op(gevm.SLOAD, gevm.POP).
+		Push(other.Bytes()).Op(gevm.BALANCE, gevm.STOP).Bytes()
+	codeHex := common.Bytes2Hex(code)
+
+	tests := []struct {
+		name   string
+		config PrestateTracerConfig
+		want   string
+	}{
+		{
+			name: "Prestate",
+			want: `{
+				"0x0000000000000000000000000000000000000000": {"balance": "0x0"},
+				"0x0000000000000000000000000000000000001000": {"balance": "0x0", "code": "0x` + codeHex + `", "storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000005",
+					"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000007",
+					"0x0000000000000000000000000000000000000000000000000000000000000003": "0x0000000000000000000000000000000000000000000000000000000000000000"
+				}},
+				"0x000000000000000000000000000000000000beef": {"balance": "0x0"},
+				"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0xde0b6b3a7640000"}
+			}`,
+		},
+		{
+			name:   "Prestate without code and storage",
+			config: PrestateTracerConfig{DisableCode: true, DisableStorage: true},
+			want: `{
+				"0x0000000000000000000000000000000000000000": {"balance": "0x0"},
+				"0x0000000000000000000000000000000000001000": {"balance": "0x0"},
+				"0x000000000000000000000000000000000000beef": {"balance": "0x0"},
+				"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0xde0b6b3a7640000"}
+			}`,
+		},
+		{
+			name:   "Diff",
+			config: PrestateTracerConfig{DiffMode: true},
+			want: `{
+				"pre": {
+					"0x0000000000000000000000000000000000001000": {"balance": "0x0", "code": "0x` + codeHex + `", "storage": {
+						"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000005"
+					}},
+					"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0xde0b6b3a7640000"}
+				},
+				"post": {
+					"0x0000000000000000000000000000000000001000": {"balance": "0x5", "storage": {
+						"0x0000000000000000000000000000000000000000000000000000000000000003": "0x0000000000000000000000000000000000000000000000000000000000000004"
+					}},
+					"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0xde0b6b3a763fffb", "nonce": 1}
+				}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, msg, block, chainConfig := applyCode(t, code, nil)
+			storage := state[contract].Storage
+			storage[common.HexToHash("0x01")] = common.HexToHash("0x05")
+			storage[common.HexToHash("0x02")] = common.HexToHash("0x07")
+			msg.Value = uint256.NewInt(5)
+
+			tracer := NewPrestateTracer(tt.config)
+			res, err := TraceMessage(state, msg, block, chainConfig, tracer)
+			require.NoError(t, err)
+			require.NoError(t, res.Err)
+			result, err := tracer.GetResult()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(result))
+		})
+	}
+}
+
+func TestPrestateTracerCreate(t *testing.T) {
+	state, msg, block, chainConfig := applyCode(t, nil, nil)
+	msg.To, msg.Data = nil, gevm.NewProgram().Mstore(0, 0).Return(0, 1).Bytes()
+	tracer := NewPrestateTracer(PrestateTracerConfig{DiffMode: true})
+	res, err := TraceMessage(state, msg, block, chainConfig, tracer)
+	require.NoError(t, err)
+	require.NoError(t, res.Err)
+
+	// The created contract only has a post-state
+	assert.NotContains(t, tracer.Prestate(), res.ContractAddress)
+	assert.Equal(t, &PrestateAccount{Nonce: 1, Code: []byte{0x00}}, tracer.Poststate()[res.ContractAddress])
+	assert.Equal(t, uint64(1), tracer.Poststate()[sender].Nonce)
+}
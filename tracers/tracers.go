@@ -0,0 +1,67 @@
+// Package tracers holds tracers reporting what an execution did, in the formats of go-ethereum's built-in tracers.
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Jesserc/gevm/gevm"
+)
+
+// Tracer traces the execution of a message, on top of the steps of its code.
+type Tracer interface {
+	gevm.Tracer
+
+	// CaptureTxStart is called before msg is applied to state in block, with the rules of chainConfig.
+	CaptureTxStart(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig)
+
+	// CaptureTxEnd is called once msg was applied, with the state it left and its result.
+	CaptureTxEnd(state gevm.WorldState, res *gevm.ExecutionResult)
+
+	// GetResult returns the trace, in the JSON format of the geth tracer of the same name.
+	GetResult() (json.RawMessage, error)
+}
+
+// constructors creates the tracers by name, from their JSON configuration (nil for the defaults).
+var constructors = map[string]func(config json.RawMessage) (Tracer, error){
+	"4byteTracer":    newFourByteTracerFromJSON,
+	"callTracer":     newCallTracerFromJSON,
+	"prestateTracer": newPrestateTracerFromJSON,
+}
+
+// New creates the tracer with a geth tracer name, such as "callTracer".
+func New(name string, config json.RawMessage) (Tracer, error) {
+	ctor, ok := constructors[name]
+	if !ok {
+		names := make([]string, 0, len(constructors))
+		for name := range constructors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown tracer %q, expected one of %v", name, names)
+	}
+	return ctor(config)
+}
+
+// TraceMessage applies msg to state with a tracer, the way gevm.ApplyMessage does.
+func TraceMessage(state gevm.WorldState, msg *gevm.Message, block *gevm.Block, chainConfig gevm.ChainConfig, tracer Tracer) (*gevm.ExecutionResult, error) {
+	tracer.CaptureTxStart(state, msg, block, chainConfig)
+	res, err := gevm.ApplyMessage(state, msg, block, chainConfig, tracer)
+	if err != nil {
+		return nil, err
+	}
+	tracer.CaptureTxEnd(state, res)
+	return res, nil
+}
+
+// unmarshalConfig decodes the configuration of a tracer, leaving the defaults if there is none.
+func unmarshalConfig(config json.RawMessage, dst any) error {
+	if len(config) == 0 || string(config) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(config, dst); err != nil {
+		return fmt.Errorf("invalid tracer config: %v", err)
+	}
+	return nil
+}